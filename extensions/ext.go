@@ -4,3 +4,18 @@ package extensions
 func CallMe() {}
 
 // Extension files (.go) can be added and have initializations in init -functions
+//
+// Go functions can be added as module functions with std.GoFuncInfo, like:
+//
+//	func init() {
+//		funl.AddExtensionInitializer(func(interpreter *funl.Interpreter) error {
+//			topFrame := funl.NewTopFrameWithInterpreter(interpreter)
+//			funcs := []std.StdFuncInfo{
+//				std.GoFuncInfo("repeat", strings.Repeat, true),
+//				std.GoFuncInfo("read-file", ioutil.ReadFile, false),
+//			}
+//			return std.SetSTDFunctions(topFrame, "myext", funcs, interpreter)
+//		})
+//	}
+//
+// then call(myext.read-file 'some.txt') returns list(ok err <bytearray>)
@@ -3,11 +3,14 @@ package funl
 import (
 	"fmt"
 	"math"
+	"math/big"
 	"runtime"
 	"runtime/debug"
 	"strconv"
 	"sync"
 	"sync/atomic"
+
+	"github.com/anssihalmeaho/funl/pvec"
 )
 
 type ValueType int
@@ -29,10 +32,16 @@ const (
 	OpaqueValue
 	MapValue
 	ThunkValue
+	BigIntValue
+	DecimalValue
+	RecordValue
+	VectorValue
+	SetValue
 
 	ValueItem ItemType = iota
 	SymbolPathItem
 	OperCallItem
+	PatternItem
 
 	AndOP OperType = iota
 	OrOP
@@ -101,6 +110,12 @@ const (
 	RecwithOP
 	DeferOP
 	ForceOP
+	TryeOP
+	MatchOP
+	RectypeOP
+	VectorOP
+	SetindOP
+	SetOP
 	MaximumOP
 )
 
@@ -119,6 +134,8 @@ func (it ItemType) GoString() string {
 		return "SymbolPathItem"
 	case OperCallItem:
 		return "OperCallItem"
+	case PatternItem:
+		return "PatternItem"
 	}
 	return "Unknown Item type"
 }
@@ -131,6 +148,16 @@ func (vt ValueType) GoString() string {
 	switch vt {
 	case IntValue:
 		return "Int-Value"
+	case BigIntValue:
+		return "BigInt-Value"
+	case DecimalValue:
+		return "Decimal-Value"
+	case RecordValue:
+		return "Record-Value"
+	case VectorValue:
+		return "Vector-Value"
+	case SetValue:
+		return "Set-Value"
 	case StringValue:
 		return "String-Value"
 	case BoolValue:
@@ -218,6 +245,12 @@ func operTypeFromIntToString(ot OperType) string {
 		RecwithOP:  "recwith",
 		DeferOP:    "defer",
 		ForceOP:    "force",
+		TryeOP:     "trye",
+		MatchOP:    "match",
+		RectypeOP:  "rectype",
+		VectorOP:   "vector",
+		SetindOP:   "setind",
+		SetOP:      "set",
 		MaximumOP:  "MAX",
 	}[ot]
 	if !ok {
@@ -236,8 +269,15 @@ func (ot OperType) String() string {
 
 type SymID int
 
+// String returns symbol name by using default converter (SymIDMap),
+// StringWith should be used for symbols of interpreter
 func (sid SymID) String() string {
-	return SymIDMap.AsString(sid)
+	return sid.StringWith(SymIDMap)
+}
+
+// StringWith returns symbol name by using given converter
+func (sid SymID) StringWith(sidc *SymbolToIDConverter) string {
+	return sidc.AsString(sid)
 }
 
 func (sid SymID) GoString() string {
@@ -250,8 +290,9 @@ type SymbolToIDConverter struct {
 	sync.RWMutex
 }
 
+// NewSymbolToIDConverter returns new converter, "_" has same id (AnySymSid) in all converters
 func NewSymbolToIDConverter() *SymbolToIDConverter {
-	return &SymbolToIDConverter{counter: 1, symIDmap: make(map[string]SymID)}
+	return &SymbolToIDConverter{counter: 2, symIDmap: map[string]SymID{"_": AnySymSid}}
 }
 
 func (sidc *SymbolToIDConverter) SymbolCount() int {
@@ -262,6 +303,9 @@ func (sidc *SymbolToIDConverter) SymbolCount() int {
 }
 
 func (sidc *SymbolToIDConverter) AsString(sid SymID) string {
+	if sid < 0 {
+		return "_"
+	}
 	sidc.RLock()
 	defer sidc.RUnlock()
 
@@ -300,8 +344,20 @@ func (sidc *SymbolToIDConverter) Add(symbol string) SymID {
 	return sid
 }
 
+// AnySymSid is id of "_" symbol
+const AnySymSid SymID = 1
+
+// SymIDMap is default converter which is used if parser or symbol table
+// is not bound to interpreter (each interpreter has own converter)
 var SymIDMap = NewSymbolToIDConverter()
-var AnySymSid = SymIDMap.Add("_")
+
+var anonymousCounter int64
+
+// newAnonymousSID returns unique id for "_" symbol which is bound in runtime,
+// such ids are negative and they are not stored to any converter
+func newAnonymousSID() SymID {
+	return SymID(-atomic.AddInt64(&anonymousCounter, 1))
+}
 
 var wasteCounter uint64
 var wasteMutex = &sync.Mutex{}
@@ -332,18 +388,33 @@ func GetWastedName() string {
 type Symt struct {
 	ordered []SymID // keys
 	mapped  map[SymID]*Item
+	sidc    *SymbolToIDConverter
 	sync.RWMutex
 }
 
+// NewSymt returns symbol table which uses default converter (SymIDMap)
 func NewSymt() *Symt {
-	return &Symt{mapped: make(map[SymID]*Item)}
+	return NewSymtWithConverter(SymIDMap)
+}
+
+// NewSymtWithConverter returns symbol table which uses given converter for symbol names
+func NewSymtWithConverter(sidc *SymbolToIDConverter) *Symt {
+	return &Symt{mapped: make(map[SymID]*Item), sidc: sidc}
+}
+
+// Converter returns converter used by symbol table
+func (sym *Symt) Converter() *SymbolToIDConverter {
+	if sym == nil {
+		return SymIDMap
+	}
+	return sym.sidc
 }
 
 func (sym *Symt) MakeCopy() *Symt {
 	sym.RLock()
 	defer sym.RUnlock()
 
-	newsyms := NewSymt()
+	newsyms := NewSymtWithConverter(sym.sidc)
 	for k, v := range sym.mapped {
 		newsyms.mapped[k] = v
 	}
@@ -356,10 +427,15 @@ func (sym *Symt) MakeCopy() *Symt {
 type SymbolPath []SymID
 
 func (sp *SymbolPath) ToString() string {
+	return sp.ToStringWith(SymIDMap)
+}
+
+// ToStringWith returns symbol path as string by using given converter
+func (sp *SymbolPath) ToStringWith(sidc *SymbolToIDConverter) string {
 	var targetStr string
 	pathLen := len(*sp)
 	for ind, part := range *sp {
-		targetStr = targetStr + SymIDMap.AsString(part)
+		targetStr = targetStr + sidc.AsString(part)
 		if ind < pathLen-1 {
 			targetStr += "."
 		}
@@ -391,7 +467,7 @@ func (sym *Symt) AsMap() map[SymID]*Item {
 
 func (sym *Symt) Print(depth int) (s string) {
 	for k, v := range sym.mapped {
-		s += fmt.Sprintf("%ssym: %s: %v\n", depthPrint(depth), SymIDMap.AsString(k), v.Print(depth))
+		s += fmt.Sprintf("%ssym: %s: %v\n", depthPrint(depth), sym.sidc.AsString(k), v.PrintWith(depth, sym.sidc))
 	}
 	return
 }
@@ -415,7 +491,7 @@ func (sym *Symt) FindFuncSID(fptr *Function) (sid SymID, found bool) {
 }
 
 func (sym *Symt) GetByName(symbol string) (*Item, bool) {
-	sid, found := SymIDMap.Get(symbol)
+	sid, found := sym.sidc.Get(symbol)
 	if !found {
 		return nil, false
 	}
@@ -432,7 +508,7 @@ func (sym *Symt) GetBySID(sid SymID) (*Item, bool) {
 
 func (sym *Symt) AddBySIDByOverwriteIfNeeded(sid SymID, item *Item) bool {
 	if sid == AnySymSid {
-		sid = SymIDMap.Add(getWastedName())
+		sid = newAnonymousSID()
 	}
 
 	sym.Lock()
@@ -445,7 +521,7 @@ func (sym *Symt) AddBySIDByOverwriteIfNeeded(sid SymID, item *Item) bool {
 
 func (sym *Symt) AddBySID(sid SymID, item *Item) bool {
 	if sid == AnySymSid {
-		sid = SymIDMap.Add(getWastedName())
+		sid = newAnonymousSID()
 	}
 
 	sym.Lock()
@@ -464,7 +540,7 @@ func (sym *Symt) Add(symbol string, item *Item) error {
 		symbol = getWastedName()
 	}
 
-	sid := SymIDMap.Add(symbol)
+	sid := sym.sidc.Add(symbol)
 
 	sym.Lock()
 	defer sym.Unlock()
@@ -478,7 +554,8 @@ func (sym *Symt) Add(symbol string, item *Item) error {
 }
 
 type ImportInfo struct {
-	importPath string // "" if not defined
+	importPath string  // "" if not defined
+	src        *SrcPos // position of import in source code
 }
 
 func (imp *ImportInfo) Path() string {
@@ -490,8 +567,9 @@ func (imp *ImportInfo) SetPath(path string) {
 }
 
 type NSpace struct {
-	Syms    *Symt
-	OtherNS map[SymID]ImportInfo
+	Syms       *Symt
+	OtherNS    map[SymID]ImportInfo
+	importRefs []SymbolPath // imported symbols referred in namespace
 }
 
 func depthPrint(depth int) (s string) {
@@ -513,9 +591,30 @@ type Item struct {
 	Data             interface{}
 	Expand           bool
 	ExpandArgIndexes map[int]bool
+	ref              *symRef // resolved symbol (nil if looked up by name)
+	Src              *SrcPos // position in source code (nil if not from parser)
+}
+
+// SrcPos is source code position of item
+type SrcPos struct {
+	SrcFileName string
+	Lineno      int
+	Col         int
+}
+
+func (pos *SrcPos) String() string {
+	if pos.SrcFileName == "" {
+		return fmt.Sprintf("%d:%d", pos.Lineno, pos.Col)
+	}
+	return fmt.Sprintf("%s:%d:%d", pos.SrcFileName, pos.Lineno, pos.Col)
 }
 
 func (item *Item) Print(depth int) (s string) {
+	return item.PrintWith(depth, SymIDMap)
+}
+
+// PrintWith is like Print but symbols are converted by using given converter
+func (item *Item) PrintWith(depth int, sidc *SymbolToIDConverter) (s string) {
 	switch item.Type {
 	case ValueItem:
 		s = "VALUE"
@@ -524,6 +623,10 @@ func (item *Item) Print(depth int) (s string) {
 		case IntValue:
 			intv := vi.Data.(int)
 			s = fmt.Sprintf("%d", intv)
+		case BigIntValue:
+			s = vi.Data.(*big.Int).String()
+		case DecimalValue:
+			s = DecimalString(vi.Data.(*big.Rat))
 		case StringValue:
 			s = vi.Data.(string)
 		case OpaqueValue:
@@ -550,14 +653,16 @@ func (item *Item) Print(depth int) (s string) {
 		}
 	case SymbolPathItem:
 		sp := item.Data.(SymbolPath)
-		s = sp.ToString()
+		s = sp.ToStringWith(sidc)
 	case OperCallItem:
 		opc := item.Data.(OpCall)
 		s2 := ""
 		for _, v := range opc.Operands {
-			s2 += (v.Print(depth) + ", ")
+			s2 += (v.PrintWith(depth, sidc) + ", ")
 		}
 		s += fmt.Sprintf("op-call: %d, (operands: %s)", opc.OperID, s2)
+	case PatternItem:
+		s = "pattern: " + item.Data.(*MatchClause).pattern.StringWith(sidc)
 	default:
 		s = "UNKNOWN ITEM"
 	}
@@ -574,6 +679,16 @@ func (val Value) String() string {
 	case IntValue:
 		intv := val.Data.(int)
 		return fmt.Sprintf("%d", intv)
+	case BigIntValue:
+		return val.Data.(*big.Int).String()
+	case DecimalValue:
+		return DecimalString(val.Data.(*big.Rat))
+	case RecordValue:
+		return val.Data.(*Record).String()
+	case VectorValue:
+		return vectorString(val.Data.(*pvec.Vector))
+	case SetValue:
+		return setString(val.Data.(*Set))
 	case FloatValue:
 		floatv := val.Data.(float64)
 		if math.Trunc(floatv) == floatv {
@@ -626,16 +741,33 @@ type FuncValue struct {
 type OpCall struct {
 	OperID   OperType
 	Operands []*Item
+	Src      *SrcPos
 }
 
 type Function struct {
-	IsProc      bool
-	ArgNames    []SymID
-	Body        *Item
-	NSpace      NSpace
-	Lineno      int
-	Pos         int
-	SrcFileName string
+	IsProc        bool
+	ArgNames      []SymID
+	Body          *Item
+	NSpace        NSpace
+	Lineno        int
+	Pos           int
+	SrcFileName   string
+	code          atomic.Value // compiled bytecode (*funcCode)
+	slotIndex     atomic.Value // slot index of argument/let definition (map[SymID]int)
+	resolved      bool         // symbols resolved to frame slots
+	hasDuplicates bool         // argument/let names overlap with ones in scope
+	params        *funcParams  // nil if there's only plain positional arguments
+	variadic      bool         // argslist used so any amount of arguments accepted
+}
+
+// funcParams describes default values, rest argument and keyword arguments,
+// all arguments are in ArgNames in order: positional, rest and keyword arguments
+type funcParams struct {
+	defaults []*Item // default value of each argument (nil if not given)
+	required int     // count of positional arguments without default value
+	posCount int     // count of positional arguments
+	hasRest  bool    // rest argument (list of remaining arguments)
+	kwCount  int     // count of keyword arguments (given in map as last argument)
 }
 
 type Thunk struct {
@@ -0,0 +1,105 @@
+package funl
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+)
+
+// Integers are IntValue as long as they fit to Go int, results which
+// overflow are promoted to BigIntValue (Data is *big.Int).
+// BigIntValue is always normalized back to IntValue if it fits to int,
+// so same integer has always same representation.
+
+const (
+	maxInt = 1<<(strconv.IntSize-1) - 1
+	minInt = -1 << (strconv.IntSize - 1)
+)
+
+var (
+	bigMaxInt = big.NewInt(maxInt)
+	bigMinInt = big.NewInt(minInt)
+)
+
+// MakeIntValue returns integer value from big.Int (IntValue if it fits to int)
+func MakeIntValue(b *big.Int) Value {
+	if b.Cmp(bigMinInt) >= 0 && b.Cmp(bigMaxInt) <= 0 {
+		return Value{Kind: IntValue, Data: int(b.Int64())}
+	}
+	return Value{Kind: BigIntValue, Data: b}
+}
+
+// IsIntegerValue returns true for IntValue and BigIntValue
+func IsIntegerValue(val Value) bool {
+	return val.Kind == IntValue || val.Kind == BigIntValue
+}
+
+// ToBigInt returns integer value (IntValue or BigIntValue) as new big.Int
+func ToBigInt(val Value) *big.Int {
+	if val.Kind == BigIntValue {
+		return new(big.Int).Set(val.Data.(*big.Int))
+	}
+	return big.NewInt(int64(val.Data.(int)))
+}
+
+// parseIntValue parses decimal string to integer value
+func parseIntValue(s string) (Value, bool) {
+	if intv, err := strconv.Atoi(s); err == nil {
+		return Value{Kind: IntValue, Data: intv}, true
+	}
+	b, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return Value{}, false
+	}
+	return MakeIntValue(b), true
+}
+
+func bigIntToFloat(b *big.Int) float64 {
+	f, _ := new(big.Float).SetInt(b).Float64()
+	return f
+}
+
+// intToFloat converts integer value to float64
+func intToFloat(val Value) float64 {
+	if val.Kind == BigIntValue {
+		return bigIntToFloat(val.Data.(*big.Int))
+	}
+	return float64(val.Data.(int))
+}
+
+// floatToIntValue truncates float to integer value
+func floatToIntValue(f float64) Value {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return Value{Kind: IntValue, Data: int(f)}
+	}
+	if f >= float64(minInt) && f < float64(maxInt) {
+		return MakeIntValue(big.NewInt(int64(f)))
+	}
+	b, _ := big.NewFloat(f).Int(nil)
+	return MakeIntValue(b)
+}
+
+func addInts(a, b int) (int, bool) {
+	c := a + b
+	return c, (c > a) == (b > 0)
+}
+
+func subInts(a, b int) (int, bool) {
+	c := a - b
+	return c, (c < a) == (b > 0)
+}
+
+func mulInts(a, b int) (int, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	c := a * b
+	if (c < 0) != ((a < 0) != (b < 0)) || c/b != a {
+		return c, false
+	}
+	return c, true
+}
+
+func getHashedBigInt(from *big.Int) int {
+	return getHashedString(from.String())
+}
@@ -0,0 +1,50 @@
+package funl
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestIntOverflowChecks(t *testing.T) {
+	cases := []struct {
+		a, b                int
+		addOk, subOk, mulOk bool
+	}{
+		{1, 2, true, true, true},
+		{maxInt, 1, false, true, true},
+		{minInt, 1, true, false, true},
+		{minInt, -1, false, true, false},
+		{maxInt, 2, false, true, false},
+		{-1, minInt, false, true, false},
+		{0, minInt, true, false, true},
+		{1 << 31, 1 << 31, true, true, true},
+		{1 << 32, 1 << 31, true, true, false},
+	}
+	for _, c := range cases {
+		if _, ok := addInts(c.a, c.b); ok != c.addOk {
+			t.Errorf("add %d %d: %v", c.a, c.b, ok)
+		}
+		if _, ok := subInts(c.a, c.b); ok != c.subOk {
+			t.Errorf("sub %d %d: %v", c.a, c.b, ok)
+		}
+		if _, ok := mulInts(c.a, c.b); ok != c.mulOk {
+			t.Errorf("mul %d %d: %v", c.a, c.b, ok)
+		}
+	}
+}
+
+func TestMakeIntValue(t *testing.T) {
+	if v := MakeIntValue(big.NewInt(maxInt)); v.Kind != IntValue || v.Data.(int) != maxInt {
+		t.Errorf("IntValue assumed: %v", v)
+	}
+	over := new(big.Int).Add(big.NewInt(maxInt), big.NewInt(1))
+	if v := MakeIntValue(over); v.Kind != BigIntValue || v.String() != "9223372036854775808" {
+		t.Errorf("BigIntValue assumed: %v", v)
+	}
+	if v, ok := parseIntValue("-9223372036854775809"); !ok || v.Kind != BigIntValue {
+		t.Errorf("BigIntValue assumed: %v", v)
+	}
+	if _, ok := parseIntValue("12x"); ok {
+		t.Errorf("parse failure assumed")
+	}
+}
@@ -0,0 +1,153 @@
+package funl
+
+import (
+	"github.com/anssihalmeaho/funl/pmap"
+)
+
+// MapBuilder is transient map which is modified in place and then frozen
+// to map value. It's meant to be used inside one operation only
+// (it's not safe to share it) and it cannot be used after freezing.
+// Only HAMT backend (-hamt) is modified in place, with red-black tree
+// (default backend) builder does normal persistent puts so it's not faster
+// than put -operator (see BenchmarkMapBuilder).
+type MapBuilder struct {
+	hb     *pmap.HAMTBuilder
+	pm     *PMap // used if maps are red-black trees
+	count  int
+	sorted bool
+	frozen bool
+}
+
+// NewMapBuilder returns builder for empty map (map backend is taken
+// from settings of interpreter to which frame belongs)
+func NewMapBuilder(frame *Frame) *MapBuilder {
+	return newMapBuilderFrom(newPMap(frame))
+}
+
+// NewMapBuilderFrom returns builder which has items of given map
+func NewMapBuilderFrom(frame *Frame, mapVal Value) *MapBuilder {
+	if mapVal.Kind != MapValue {
+		runTimeError2(frame, "map builder requires map")
+	}
+	return newMapBuilderFrom(mapVal.Data.(*PMap))
+}
+
+func newMapBuilderFrom(pm *PMap) *MapBuilder {
+	if pm.Hamt != nil {
+		return &MapBuilder{hb: pm.Hamt.Builder(), count: pm.itemCount, sorted: pm.sorted}
+	}
+	return &MapBuilder{pm: pm, count: pm.itemCount, sorted: pm.sorted}
+}
+
+func (mb *MapBuilder) check(frame *Frame) {
+	if mb.frozen {
+		runTimeError2(frame, "map builder used after it's frozen")
+	}
+}
+
+func (mb *MapBuilder) hashOf(frame *Frame, key Value) pmap.MKey {
+	hashedKey, err := hashOfValue(frame, key)
+	if err != nil {
+		runTimeError2(frame, "illegal type for map key")
+	}
+	return pmap.MKey(hashedKey)
+}
+
+// Get returns value for key
+func (mb *MapBuilder) Get(frame *Frame, key Value) (Value, bool) {
+	mb.check(frame)
+	var nodeval pmap.MValue
+	var found bool
+	if mb.hb != nil {
+		nodeval, found = mb.hb.Get(mb.hashOf(frame, key))
+	} else {
+		nodeval, found = mb.pm.get(mb.hashOf(frame, key))
+	}
+	if !found {
+		return Value{}, false
+	}
+	nval := nodeval.(NodeValue)
+	return getMatchingValue(&nval, key)
+}
+
+// Put adds key-value, it's runtime error if key already exists
+func (mb *MapBuilder) Put(frame *Frame, key, val Value) {
+	mb.check(frame)
+	hashedKey := mb.hashOf(frame, key)
+	if mb.hb != nil {
+		mb.hb.Put(hashedKey, NewNodeValue(key, val))
+	} else {
+		mb.pm = mb.pm.put(hashedKey, NewNodeValue(key, val))
+	}
+	mb.count++
+}
+
+// Set adds key-value, value is replaced if key already exists
+func (mb *MapBuilder) Set(frame *Frame, key, val Value) {
+	mb.Del(frame, key)
+	mb.Put(frame, key, val)
+}
+
+// Del removes key, returns false if key was not found
+func (mb *MapBuilder) Del(frame *Frame, key Value) (found bool) {
+	mb.check(frame)
+	hashedKey := mb.hashOf(frame, key)
+	if mb.hb != nil {
+		found = mb.hb.Delete(hashedKey, key)
+	} else {
+		mb.pm, found = mb.pm.del(hashedKey, key)
+	}
+	if found {
+		mb.count--
+	}
+	return
+}
+
+// Len returns number of items
+func (mb *MapBuilder) Len() int {
+	return mb.count
+}
+
+// Freeze returns map value, builder cannot be used after that
+func (mb *MapBuilder) Freeze(frame *Frame) Value {
+	mb.check(frame)
+	mb.frozen = true
+	if mb.hb != nil {
+		return Value{Kind: MapValue, Data: &PMap{Hamt: mb.hb.Freeze(), itemCount: mb.count, sorted: mb.sorted}}
+	}
+	return Value{Kind: MapValue, Data: &PMap{Rbm: mb.pm.Rbm, itemCount: mb.count, sorted: mb.sorted}}
+}
+
+// ListBuilder is transient list to which items are appended in place
+// and which is then frozen to list value (same restrictions as in MapBuilder)
+type ListBuilder struct {
+	values []Value
+	frozen bool
+}
+
+// NewListBuilder returns builder for empty list
+func NewListBuilder() *ListBuilder {
+	return &ListBuilder{}
+}
+
+// Append adds values to end of list
+func (lb *ListBuilder) Append(frame *Frame, values ...Value) {
+	if lb.frozen {
+		runTimeError2(frame, "list builder used after it's frozen")
+	}
+	lb.values = append(lb.values, values...)
+}
+
+// Len returns number of items
+func (lb *ListBuilder) Len() int {
+	return len(lb.values)
+}
+
+// Freeze returns list value, builder cannot be used after that
+func (lb *ListBuilder) Freeze(frame *Frame) Value {
+	if lb.frozen {
+		runTimeError2(frame, "list builder used after it's frozen")
+	}
+	lb.frozen = true
+	return MakeListOfValues(frame, lb.values)
+}
@@ -0,0 +1,425 @@
+package funl
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Diagnostic is problem found by static checker
+type Diagnostic struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Col      int    `json:"col"`
+	Severity string `json:"severity"` // error or warning
+	Code     string `json:"code"`
+	Message  string `json:"message"`
+}
+
+// severities of diagnostics
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// HasErrors is true if there's any diagnostic with error severity
+func HasErrors(diags []Diagnostic) bool {
+	for _, diag := range diags {
+		if diag.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckModule checks module source statically (imports are searched by file importer)
+func CheckModule(content, srcFileName string, initSTD func(*Interpreter) error) ([]Diagnostic, error) {
+	interpreter := NewInterpreter()
+	interpreter.Importer = &fileImporter{}
+	return CheckModuleWithInterpreter(content, srcFileName, initSTD, interpreter)
+}
+
+// CheckModuleWithPackImport checks module source statically, imports are done from package
+func CheckModuleWithPackImport(impPackName, content, srcFileName string, initSTD func(*Interpreter) error) ([]Diagnostic, error) {
+	packs := &pack{}
+	data, err := os.ReadFile(impPackName)
+	if err != nil {
+		return nil, err
+	}
+	if packs.modContents, err = GetModsFromTar(data); err != nil {
+		return nil, err
+	}
+	interpreter := NewInterpreter()
+	interpreter.Importer = &packageImporter{mods: packs}
+	return CheckModuleWithInterpreter(content, srcFileName, initSTD, interpreter)
+}
+
+// CheckModuleWithInterpreter checks module source statically and follows imports
+// by importer of interpreter. It checks:
+//   - symbol resolution (also symbols in imported modules)
+//   - amount of arguments in operator calls
+//   - proc calls and procedure-only operators in functions
+//   - unused let definitions (warning)
+//   - overlapping symbol names in scope (rejected in runtime)
+func CheckModuleWithInterpreter(content, srcFileName string, initSTD func(*Interpreter) error, interpreter *Interpreter) ([]Diagnostic, error) {
+	if err := interpreter.Init(initSTD); err != nil {
+		return nil, err
+	}
+	c := &checker{
+		interpreter: interpreter,
+		operators:   NewDefaultOperators(),
+		modules:     make(map[SymID]*checkedModule),
+		diags:       []Diagnostic{},
+	}
+	if nsName, ns, ok := c.parse(content, srcFileName); ok {
+		// module itself can be imported by other modules
+		c.modules[c.interpreter.SymIDs.Add(nsName)] = &checkedModule{syms: ns.Syms.AsMap()}
+		c.checkNSpace(ns)
+	}
+	sort.SliceStable(c.diags, func(i, j int) bool {
+		di, dj := c.diags[i], c.diags[j]
+		if di.File != dj.File {
+			return di.File < dj.File
+		}
+		if di.Line != dj.Line {
+			return di.Line < dj.Line
+		}
+		return di.Col < dj.Col
+	})
+	return c.diags, nil
+}
+
+// checkedModule contains symbols of imported module
+type checkedModule struct {
+	syms       map[SymID]*Item
+	notChecked bool // symbols not known (module could not be parsed)
+}
+
+type checker struct {
+	interpreter *Interpreter
+	operators   Operators
+	modules     map[SymID]*checkedModule
+	diags       []Diagnostic
+}
+
+// checkSym is symbol defined in scope
+type checkSym struct {
+	item  *Item // value of let definition (nil for arguments)
+	pos   *SrcPos
+	isLet bool
+	used  bool
+}
+
+// checkScope is module, function or match clause scope
+type checkScope struct {
+	parent  *checkScope
+	names   map[SymID]*checkSym
+	order   []SymID
+	imports map[SymID]ImportInfo
+	inFunc  bool // procs not allowed
+}
+
+func newCheckScope(parent *checkScope, imports map[SymID]ImportInfo, inFunc bool) *checkScope {
+	return &checkScope{
+		parent:  parent,
+		names:   make(map[SymID]*checkSym),
+		imports: imports,
+		inFunc:  inFunc,
+	}
+}
+
+func (sc *checkScope) lookup(sid SymID) (*checkSym, bool) {
+	for s := sc; s != nil; s = s.parent {
+		if sym, found := s.names[sid]; found {
+			return sym, true
+		}
+	}
+	return nil, false
+}
+
+func (sc *checkScope) isImported(sid SymID) bool {
+	for s := sc; s != nil; s = s.parent {
+		if _, found := s.imports[sid]; found {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *checker) report(pos *SrcPos, severity, code, format string, args ...interface{}) {
+	diag := Diagnostic{Severity: severity, Code: code, Message: fmt.Sprintf(format, args...)}
+	if pos != nil {
+		diag.File, diag.Line, diag.Col = pos.SrcFileName, pos.Lineno, pos.Col
+	}
+	c.diags = append(c.diags, diag)
+}
+
+var syntaxErrorLine = regexp.MustCompile(`line (\d+)`)
+
+func (c *checker) parse(content, srcFileName string) (nsName string, ns *NSpace, ok bool) {
+	parser := c.interpreter.NewParser(&srcFileName)
+	nsName, ns, err := parser.Parse(content)
+	if err != nil {
+		pos := &SrcPos{SrcFileName: srcFileName}
+		if match := syntaxErrorLine.FindStringSubmatch(err.Error()); match != nil {
+			pos.Lineno, _ = strconv.Atoi(match[1])
+		}
+		c.report(pos, SeverityError, "syntax-error", "%v", err)
+		return
+	}
+	return nsName, ns, true
+}
+
+// importModule finds symbols of imported module, module which is not
+// yet loaded to interpreter is parsed and checked too
+func (c *checker) importModule(sid SymID, importInfo ImportInfo) {
+	if _, found := c.modules[sid]; found {
+		return
+	}
+	if topFrame, found := c.interpreter.NsDir.GetTopFrameBySID(sid); found {
+		c.modules[sid] = &checkedModule{syms: topFrame.Syms.AsMap()}
+		return
+	}
+	importModName := c.interpreter.SymIDs.AsString(sid)
+	importFileName, fileExtensionName := getImportFileName(importModName, importInfo.importPath, "fnl")
+	targetPath, content, err := c.interpreter.Importer.FindModule(importFileName, fileExtensionName)
+	if err != nil {
+		c.modules[sid] = &checkedModule{notChecked: true}
+		c.report(importInfo.src, SeverityError, "module-not-found", "Module not found: %s", importModName)
+		return
+	}
+	module := &checkedModule{notChecked: true}
+	c.modules[sid] = module
+	nsName, ns, ok := c.parse(string(content), targetPath)
+	if !ok {
+		return
+	}
+	if nsName != importModName {
+		c.report(importInfo.src, SeverityError, "module-name-mismatch", "Mismatch in module name: %s vs %s", nsName, importModName)
+	}
+	module.syms, module.notChecked = ns.Syms.AsMap(), false
+	c.checkNSpace(ns)
+}
+
+func (c *checker) checkImports(imports map[SymID]ImportInfo) {
+	for sid, importInfo := range imports {
+		c.importModule(sid, importInfo)
+	}
+}
+
+func (c *checker) checkNSpace(ns *NSpace) {
+	c.checkImports(ns.OtherNS)
+	// module level symbols are not checked for usage as those can be imported
+	moduleScope := newCheckScope(nil, ns.OtherNS, false)
+	symbolMap := ns.Syms.AsMap()
+	for _, sid := range ns.Syms.Keys() {
+		moduleScope.names[sid] = &checkSym{item: symbolMap[sid], pos: symbolMap[sid].Src}
+	}
+	for _, sid := range ns.Syms.Keys() {
+		c.checkItem(moduleScope, symbolMap[sid])
+	}
+}
+
+// define adds symbol to scope, overlapping names are reported
+// (let definition of outer function is rejected in runtime only
+// if it's evaluated before, so that's warning)
+func (c *checker) define(sc *checkScope, sid SymID, sym *checkSym) {
+	if sid == AnySymSid {
+		return
+	}
+	if prev, found := sc.lookup(sid); found {
+		if _, inSameScope := sc.names[sid]; !inSameScope && prev.isLet {
+			c.report(sym.pos, SeverityWarning, "shadowed-symbol", "Symbol overlaps with let definition in outer scope (%s)", c.interpreter.SymIDs.AsString(sid))
+		} else {
+			c.report(sym.pos, SeverityError, "duplicate-symbol", "Duplicate symbol name in scope not allowed (%s)", c.interpreter.SymIDs.AsString(sid))
+		}
+	}
+	sc.names[sid] = sym
+	sc.order = append(sc.order, sid)
+}
+
+func (c *checker) checkFunction(sc *checkScope, f *Function) {
+	c.checkImports(f.NSpace.OtherNS)
+	fscope := newCheckScope(sc, f.NSpace.OtherNS, !f.IsProc)
+	funcPos := &SrcPos{SrcFileName: f.SrcFileName, Lineno: f.Lineno}
+	for _, sid := range f.ArgNames {
+		c.define(fscope, sid, &checkSym{pos: funcPos})
+	}
+	symbolMap := f.NSpace.Syms.AsMap()
+	for _, sid := range f.NSpace.Syms.Keys() {
+		pos := symbolMap[sid].Src
+		if pos == nil {
+			pos = funcPos
+		}
+		c.define(fscope, sid, &checkSym{item: symbolMap[sid], pos: pos, isLet: true})
+	}
+
+	if f.params != nil {
+		for _, defItem := range f.params.defaults {
+			if defItem != nil {
+				c.checkItem(fscope, defItem)
+			}
+		}
+	}
+	for _, sid := range f.NSpace.Syms.Keys() {
+		c.checkItem(fscope, symbolMap[sid])
+	}
+	if f.Body != nil {
+		c.checkItem(fscope, f.Body)
+	}
+
+	for _, sid := range fscope.order {
+		sym := fscope.names[sid]
+		symName := c.interpreter.SymIDs.AsString(sid)
+		if sym.isLet && !sym.used && !strings.HasPrefix(symName, "__waste_") {
+			c.report(sym.pos, SeverityWarning, "unused-let", "Let definition not used (%s)", symName)
+		}
+	}
+}
+
+func (c *checker) checkItem(sc *checkScope, item *Item) {
+	switch item.Type {
+	case ValueItem:
+		if v := item.Data.(Value); v.Kind == FuncProtoValue {
+			c.checkFunction(sc, v.Data.(*Function))
+		}
+	case SymbolPathItem:
+		c.checkSymbolPath(sc, item)
+	case OperCallItem:
+		c.checkOperCall(sc, item)
+	}
+}
+
+func (c *checker) checkSymbolPath(sc *checkScope, item *Item) {
+	sp := item.Data.(SymbolPath)
+	switch len(sp) {
+	case 1:
+		if sp[0] == AnySymSid {
+			return
+		}
+		sym, found := sc.lookup(sp[0])
+		if !found {
+			c.report(item.Src, SeverityError, "undefined-symbol", "symbol not found: %s", c.interpreter.SymIDs.AsString(sp[0]))
+			return
+		}
+		sym.used = true
+	case 2:
+		if !sc.isImported(sp[0]) {
+			c.report(item.Src, SeverityError, "module-not-imported", "Module not imported: %s", c.interpreter.SymIDs.AsString(sp[0]))
+			return
+		}
+		module := c.modules[sp[0]]
+		if module == nil || module.notChecked {
+			return
+		}
+		if _, found := module.syms[sp[1]]; !found {
+			c.report(item.Src, SeverityError, "undefined-symbol", "symbol not found: %s", sp.ToStringWith(c.interpreter.SymIDs))
+		}
+	}
+}
+
+// isProcValue is true if item is known to be procedure
+func (c *checker) isProcValue(sc *checkScope, item *Item) bool {
+	switch item.Type {
+	case ValueItem:
+		v := item.Data.(Value)
+		switch v.Kind {
+		case FuncProtoValue:
+			return v.Data.(*Function).IsProc
+		case FunctionValue:
+			return v.Data.(FuncValue).FuncProto.IsProc
+		case ExtProcValue:
+			return !v.Data.(ExtProcType).IsFunction
+		}
+	case SymbolPathItem:
+		sp := item.Data.(SymbolPath)
+		if len(sp) == 1 {
+			if sym, found := sc.lookup(sp[0]); found && sym.item != nil {
+				return c.isProcValue(sc, sym.item)
+			}
+		} else if module := c.modules[sp[0]]; len(sp) == 2 && module != nil && !module.notChecked {
+			if modItem, found := module.syms[sp[1]]; found && modItem.Type == ValueItem {
+				return c.isProcValue(sc, modItem)
+			}
+		}
+	}
+	return false
+}
+
+func (c *checker) checkOperCall(sc *checkScope, item *Item) {
+	opcall := item.Data.(OpCall)
+	opName := opcall.OperID.String()
+	operands := opcall.Operands
+
+	// arity cannot be known if some argument is expanded
+	var hasExpanded bool
+	for _, operand := range operands {
+		hasExpanded = hasExpanded || operand.Expand
+	}
+	if operInfo, found := c.operators[opName]; found {
+		l := len(operands)
+		if !hasExpanded && (l < operInfo.MinArgs || (operInfo.MaxArgs != AnyArgs && l > operInfo.MaxArgs)) {
+			c.report(opcall.Src, SeverityError, "wrong-arity", "Wrong amount of arguments for %s (%d given)", opName, l)
+		}
+		if operInfo.ProcOnly && sc.inFunc {
+			c.report(opcall.Src, SeverityError, "proc-in-func", "%s not allowed in function", opName)
+		}
+	}
+
+	switch opcall.OperID {
+	case NameOP:
+		// symbol is not evaluated, just used as name
+		for _, operand := range operands {
+			if sp, isSym := operand.Data.(SymbolPath); isSym && len(sp) == 1 {
+				if sym, found := sc.lookup(sp[0]); found {
+					sym.used = true
+				}
+			}
+		}
+		return
+	case ImpOP:
+		return
+	case LetOP:
+		if len(operands) == 2 {
+			c.checkItem(sc, operands[1])
+			if sp, isSym := operands[0].Data.(SymbolPath); isSym && len(sp) == 1 {
+				c.define(sc, sp[0], &checkSym{item: operands[1], pos: operands[0].Src})
+			}
+		}
+		return
+	case MatchOP:
+		c.checkMatch(sc, operands)
+		return
+	case CallOP:
+		if len(operands) > 0 && sc.inFunc && c.isProcValue(sc, operands[0]) {
+			c.report(opcall.Src, SeverityError, "proc-in-func", "proc call not allowed from func")
+		}
+	}
+	for _, operand := range operands {
+		c.checkItem(sc, operand)
+	}
+}
+
+func (c *checker) checkMatch(sc *checkScope, operands []*Item) {
+	if len(operands) == 0 {
+		return
+	}
+	c.checkItem(sc, operands[0])
+	for i := 1; i+1 < len(operands); i += 2 {
+		clause, isClause := operands[i].Data.(*MatchClause)
+		if !isClause {
+			continue
+		}
+		clauseScope := newCheckScope(sc, nil, sc.inFunc)
+		for _, sid := range clause.syms {
+			c.define(clauseScope, sid, &checkSym{pos: operands[i].Src})
+		}
+		if clause.guard != nil {
+			c.checkItem(clauseScope, clause.guard)
+		}
+		c.checkItem(clauseScope, operands[i+1])
+	}
+}
@@ -0,0 +1,98 @@
+package funl
+
+import (
+	"fmt"
+	"testing"
+)
+
+const checkerTestSrc = `
+ns main
+
+import stdfu
+import cmod
+import nomod
+
+helper = proc(x) x end
+
+pure = func(a)
+	unused = 10
+	y = call(helper a)
+	z = call(stdfu.aply list(1) func(v) v end)
+	w = minus(1)
+	q = call(cmod.foo nosuch)
+	list(y z w q tryl(1))
+end
+
+main = proc()
+	helper = 1
+	r = match(list(1 2) list(h t:) plus(h 1) _ 0)
+	call(pure r call(cmod.bar 1))
+end
+
+endns
+`
+
+const checkerTestModSrc = `
+ns cmod
+foo = func(x) plus(x 1) end
+endns
+`
+
+func TestCheckModule(t *testing.T) {
+	interpreter := NewInterpreter()
+	interpreter.Importer = &packageImporter{mods: &pack{modContents: map[string][]byte{"cmod": []byte(checkerTestModSrc)}}}
+	// fun source std-lib needs some of Go std-lib modules, stubs are enough for checking
+	noSTD := func(interpreter *Interpreter) error {
+		stubs := map[string]string{
+			"stdos":  "getenv = proc(n) list(false '') end",
+			"stdrun": "backtrace = proc() list() end",
+		}
+		for _, name := range []string{"stdfiles", "stdbase64", "stdjson", "stdstr", "stdio", "stdbytes", "stdcsv", "stdmath", "stdlex", "stdast", "stdrec", "stdpset", "stdtrans", "stdorder", "stdopaque"} {
+			stubs[name] = ""
+		}
+		for name, defs := range stubs {
+			src := fmt.Sprintf("ns %s %s endns", name, defs)
+			if err := AddFunModToNamespace(name, []byte(src), interpreter); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	diags, err := CheckModuleWithInterpreter(checkerTestSrc, "main.fnl", noSTD, interpreter)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var found []string
+	for _, diag := range diags {
+		found = append(found, fmt.Sprintf("%d:%s:%s", diag.Line, diag.Severity, diag.Code))
+	}
+	expected := []string{
+		"6:error:module-not-found",
+		"11:warning:unused-let",
+		"12:error:proc-in-func",
+		"13:error:undefined-symbol",
+		"14:error:wrong-arity",
+		"15:error:undefined-symbol",
+		"16:error:proc-in-func",
+		"20:error:duplicate-symbol",
+		"20:warning:unused-let",
+		"22:error:undefined-symbol",
+	}
+	if fmt.Sprint(found) != fmt.Sprint(expected) {
+		t.Errorf("unexpected diagnostics:\n%v\nassumed:\n%v\n(%v)", found, expected, diags)
+	}
+	for _, diag := range diags {
+		if diag.Line == 13 && diag.Message != "symbol not found: stdfu.aply" {
+			t.Errorf("unexpected message: %s", diag.Message)
+		}
+	}
+	if !HasErrors(diags) {
+		t.Errorf("errors assumed")
+	}
+
+	diags, err = CheckModuleWithInterpreter(checkerTestModSrc, "cmod.fnl", noSTD, NewInterpreter())
+	if err != nil || len(diags) != 0 {
+		t.Errorf("no diagnostics assumed: %v, %v", diags, err)
+	}
+}
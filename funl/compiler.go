@@ -0,0 +1,405 @@
+package funl
+
+import (
+	"fmt"
+)
+
+type opCode byte
+
+const (
+	opConst       opCode = iota // push consts[a]
+	opFuncProto                 // push function value for consts[a] (closure to current frame)
+	opLoadSym                   // push value of symbol syms[a]
+	opOper                      // call operator of opers[a] (computed arguments from stack)
+	opCall                      // call function with b-1 arguments from stack
+	opTailCall                  // like opCall but replaces current call
+	opJump                      // jump to a
+	opJumpIfFalse               // pop bool, jump to a if false (RTE msgs[b] if not bool)
+	opJumpIfTrue                // pop bool, jump to a if true (RTE msgs[b] if not bool)
+	opCaseEq                    // pop value and compare with top of stack, push bool
+	opPop                       // drop top of stack
+	opDefine                    // pop value and put it to let definition slot a (symbol b)
+	opStore                     // pop value and put it to slot a (no duplicate check)
+	opWhileNext                 // pop a arguments and bind them to new frame for next while round
+	opLet                       // add top of stack as symbol a to frame (let -operator)
+	opMatch                     // match top of stack to clauses[b], if matched pop it and enter clause frame, else jump to a
+	opMatchFail                 // pop value and raise error that no pattern matched
+	opLeaveScope                // return from clause frame to enclosing frame
+	opEvalItem                  // evaluate items[a] with tree walker (b=1 if body)
+	opEvalTail                  // evaluate items[a] in tail position with tree walker
+	opFail                      // runtime error msgs[a]
+	opReturn                    // return top of stack
+)
+
+// operSite is operator call in compiled code, constant and symbol operands
+// are given as such to operator, others are evaluated to stack before call
+type operSite struct {
+	operID   OperType
+	operands []*Item
+	computed []bool
+	argCount int // count of computed arguments in stack
+}
+
+type instruction struct {
+	op opCode
+	a  int
+	b  int
+}
+
+// funcCode is compiled bytecode for one function
+type funcCode struct {
+	instrs  []instruction
+	srcPos  []*SrcPos // source position of each instruction
+	consts  []Value
+	syms    []*Item
+	items   []*Item
+	opers   []operSite
+	clauses []*MatchClause
+	msgs    []string
+
+	// frame may be referred after instruction (by closure, thunk, fiber...)
+	// so while -operator cannot reuse frame for next round
+	framesCaptured bool
+}
+
+type compiler struct {
+	code *funcCode
+	pos  *SrcPos // position of item being compiled
+}
+
+// getCode returns compiled code of function (compiled when needed)
+func (f *Function) getCode() *funcCode {
+	if code, ok := f.code.Load().(*funcCode); ok {
+		return code
+	}
+	code := compileFunction(f)
+	f.code.Store(code)
+	return code
+}
+
+func compileFunction(f *Function) *funcCode {
+	c := &compiler{code: &funcCode{}}
+
+	// let definitions are evaluated first (in order)
+	c.compileLetDefs(f, opDefine)
+	c.compileBody(f)
+	c.pos = nil
+	c.emit(opReturn, 0, 0)
+	c.code.framesCaptured = c.capturesFrame()
+	return c.code
+}
+
+// capturesFrame is true if code may create values which refer to frame
+// (tree walker is assumed to do so)
+func (c *compiler) capturesFrame() bool {
+	for _, instr := range c.code.instrs {
+		switch instr.op {
+		case opFuncProto, opEvalItem, opEvalTail, opLet:
+			return true
+		case opOper:
+			if c.code.opers[instr.a].operID == EvalOP {
+				return true
+			}
+		case opMatch:
+			if c.code.clauses[instr.b].guard != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (c *compiler) compileLetDefs(f *Function, op opCode) {
+	base := len(f.ArgNames)
+	symbolMap := f.NSpace.Syms.AsMap()
+	for index, sid := range f.NSpace.Syms.Keys() {
+		c.compileItem(symbolMap[sid], false)
+		c.pos = symbolMap[sid].Src
+		c.emit(op, base+index, int(sid))
+	}
+}
+
+func (c *compiler) emit(op opCode, a, b int) int {
+	c.code.instrs = append(c.code.instrs, instruction{op: op, a: a, b: b})
+	c.code.srcPos = append(c.code.srcPos, c.pos)
+	return len(c.code.instrs) - 1
+}
+
+func (c *compiler) patch(loc int) {
+	c.code.instrs[loc].a = len(c.code.instrs)
+}
+
+func (c *compiler) addConst(v Value) int {
+	c.code.consts = append(c.code.consts, v)
+	return len(c.code.consts) - 1
+}
+
+func (c *compiler) addMsg(format string, args ...interface{}) int {
+	c.code.msgs = append(c.code.msgs, fmt.Sprintf(format, args...))
+	return len(c.code.msgs) - 1
+}
+
+func (c *compiler) evalByTreeWalker(item *Item, isBody bool) {
+	c.code.items = append(c.code.items, item)
+	var bodyFlag int
+	if isBody {
+		bodyFlag = 1
+	}
+	c.emit(opEvalItem, len(c.code.items)-1, bodyFlag)
+}
+
+func (c *compiler) compileBody(f *Function) {
+	// while -operator is allowed only as immediate operator in body
+	item := f.Body
+	if item.Type == OperCallItem && item.Data.(OpCall).OperID == WhileOP {
+		if operands := item.Data.(OpCall).Operands; item.Expand || len(operands) < 2 {
+			c.evalByTreeWalker(item, true)
+		} else {
+			c.compileWhile(f, item, operands)
+		}
+		return
+	}
+	c.compileItem(item, true)
+}
+
+// compileWhile compiles while -operator to loop which binds new
+// argument values to copy of frame on each round (previous frame
+// may be referred by closures) and evaluates let definitions again
+func (c *compiler) compileWhile(f *Function, item *Item, operands []*Item) {
+	c.pos = item.Src
+	loopStart := len(c.code.instrs)
+	c.compileItem(operands[0], false)
+	endJump := c.emit(opJumpIfFalse, 0, c.addMsg("while: condition should be boolean expression"))
+	for _, operand := range operands[1 : len(operands)-1] {
+		c.compileItem(operand, false)
+	}
+	c.emit(opWhileNext, len(operands)-2, 0)
+	c.compileLetDefs(f, opStore)
+	c.pos = item.Src
+	c.emit(opJump, loopStart, 0)
+	c.patch(endJump)
+	c.compileItem(operands[len(operands)-1], true)
+}
+
+// compileLet compiles let -operator, false is returned if it needs
+// to be evaluated by tree walker
+func (c *compiler) compileLet(operands []*Item) bool {
+	if len(operands) != 2 || operands[0].Type != SymbolPathItem || len(operands[0].Data.(SymbolPath)) != 1 {
+		return false
+	}
+	c.compileItem(operands[1], false)
+	c.emit(opLet, int(operands[0].Data.(SymbolPath)[0]), 0)
+	return true
+}
+
+// compileMatch compiles match -operator so that result of matched
+// clause is evaluated in clause frame (which has bound symbols),
+// false is returned if it needs to be evaluated by tree walker
+func (c *compiler) compileMatch(operands []*Item, tail bool) bool {
+	if l := len(operands); l < 3 || (l%2) == 0 {
+		return false
+	}
+	for i := 1; i < len(operands); i += 2 {
+		if _, ok := operands[i].Data.(*MatchClause); !ok {
+			return false
+		}
+	}
+	c.compileItem(operands[0], false)
+	var endJumps []int
+	for i := 1; i < len(operands); i += 2 {
+		c.code.clauses = append(c.code.clauses, operands[i].Data.(*MatchClause))
+		nextJump := c.emit(opMatch, 0, len(c.code.clauses)-1)
+		c.compileItem(operands[i+1], tail)
+		c.emit(opLeaveScope, 0, 0)
+		endJumps = append(endJumps, c.emit(opJump, 0, 0))
+		c.patch(nextJump)
+	}
+	c.emit(opMatchFail, 0, 0)
+	for _, loc := range endJumps {
+		c.patch(loc)
+	}
+	return true
+}
+
+// isLazyOperCall is true for operators which do not evaluate
+// all of their arguments (or need those unevaluated)
+func isLazyOperCall(opcall OpCall) bool {
+	switch opcall.OperID {
+	case TryOP, TrylOP, TryeOP, DeferOP, SpawnOP, NameOP, LetOP, ImpOP, WhileOP, MatchOP:
+		return true
+	case EqOP:
+		// eq stops evaluating arguments when types differ
+		return len(opcall.Operands) > 2
+	case GetOP:
+		// default value is evaluated only if key is not found
+		return len(opcall.Operands) == 3
+	}
+	return false
+}
+
+// isComputedOperand is true for operands which are evaluated to stack
+// before operator call (func/proc prototypes need closure to frame)
+func isComputedOperand(operand *Item) bool {
+	switch operand.Type {
+	case ValueItem:
+		return operand.Data.(Value).Kind == FuncProtoValue
+	case SymbolPathItem:
+		return false
+	}
+	return true
+}
+
+func (c *compiler) compileItem(item *Item, tail bool) {
+	if item.Src != nil {
+		prevPos := c.pos
+		c.pos = item.Src
+		defer func() { c.pos = prevPos }()
+	}
+	switch item.Type {
+	case ValueItem:
+		v := item.Data.(Value)
+		if v.Kind == FuncProtoValue {
+			c.emit(opFuncProto, c.addConst(v), 0)
+		} else {
+			c.emit(opConst, c.addConst(v), 0)
+		}
+	case SymbolPathItem:
+		c.code.syms = append(c.code.syms, item)
+		c.emit(opLoadSym, len(c.code.syms)-1, 0)
+	case OperCallItem:
+		opcall := item.Data.(OpCall)
+		if !item.Expand {
+			switch opcall.OperID {
+			case LetOP:
+				if c.compileLet(opcall.Operands) {
+					return
+				}
+			case MatchOP:
+				if c.compileMatch(opcall.Operands, tail) {
+					return
+				}
+				if tail {
+					// tree walker passes tail call back to VM
+					c.code.items = append(c.code.items, item)
+					c.emit(opEvalTail, len(c.code.items)-1, 0)
+					return
+				}
+			}
+		}
+		if item.Expand || isLazyOperCall(opcall) {
+			c.evalByTreeWalker(item, false)
+			return
+		}
+		operands := opcall.Operands
+		argCount := len(operands)
+		switch opcall.OperID {
+		case CallOP:
+			if argCount == 0 {
+				c.evalByTreeWalker(item, false)
+				return
+			}
+			for _, operand := range operands {
+				c.compileItem(operand, false)
+			}
+			if tail {
+				c.emit(opTailCall, 0, argCount)
+			} else {
+				c.emit(opCall, 0, argCount)
+			}
+		case IfOP:
+			if argCount != 3 {
+				c.evalByTreeWalker(item, false)
+				return
+			}
+			c.compileItem(operands[0], false)
+			elseJump := c.emit(opJumpIfFalse, 0, c.addMsg("if: condition should be boolean expression"))
+			c.compileItem(operands[1], tail)
+			endJump := c.emit(opJump, 0, 0)
+			c.patch(elseJump)
+			c.compileItem(operands[2], tail)
+			c.patch(endJump)
+		case CondOP:
+			if argCount < 2 || argCount%2 == 0 {
+				c.evalByTreeWalker(item, false)
+				return
+			}
+			var endJumps []int
+			for i := 0; i < argCount-1; i += 2 {
+				c.compileItem(operands[i], false)
+				nextJump := c.emit(opJumpIfFalse, 0, c.addMsg("cond: compared value assumed to be bool value (%d)", i))
+				c.compileItem(operands[i+1], tail)
+				endJumps = append(endJumps, c.emit(opJump, 0, 0))
+				c.patch(nextJump)
+			}
+			c.compileItem(operands[argCount-1], tail)
+			for _, loc := range endJumps {
+				c.patch(loc)
+			}
+		case CaseOP:
+			if argCount < 2 {
+				c.evalByTreeWalker(item, false)
+				return
+			}
+			hasDefault := (argCount % 2) == 0
+			matchCounter := argCount
+			if hasDefault {
+				matchCounter--
+			}
+			c.compileItem(operands[0], false)
+			var endJumps []int
+			for i := 1; i < matchCounter; i += 2 {
+				c.compileItem(operands[i], false)
+				c.emit(opCaseEq, 0, 0)
+				nextJump := c.emit(opJumpIfFalse, 0, c.addMsg("Invalid result from eq"))
+				c.emit(opPop, 0, 0)
+				c.compileItem(operands[i+1], tail)
+				endJumps = append(endJumps, c.emit(opJump, 0, 0))
+				c.patch(nextJump)
+			}
+			c.emit(opPop, 0, 0)
+			if hasDefault {
+				c.compileItem(operands[argCount-1], tail)
+			} else {
+				c.emit(opFail, c.addMsg("case: could not evaluate value"), 0)
+			}
+			for _, loc := range endJumps {
+				c.patch(loc)
+			}
+		case AndOP, OrOP:
+			if argCount < 1 {
+				c.evalByTreeWalker(item, false)
+				return
+			}
+			shortCut, result := opJumpIfFalse, false
+			if opcall.OperID == OrOP {
+				shortCut, result = opJumpIfTrue, true
+			}
+			msgIdx := c.addMsg("Invalid type for %s", opcall.OperID)
+			var shortJumps []int
+			for _, operand := range operands {
+				c.compileItem(operand, false)
+				shortJumps = append(shortJumps, c.emit(shortCut, 0, msgIdx))
+			}
+			c.emit(opConst, c.addConst(Value{Kind: BoolValue, Data: !result}), 0)
+			endJump := c.emit(opJump, 0, 0)
+			for _, loc := range shortJumps {
+				c.patch(loc)
+			}
+			c.emit(opConst, c.addConst(Value{Kind: BoolValue, Data: result}), 0)
+			c.patch(endJump)
+		default:
+			site := operSite{operID: opcall.OperID, operands: operands, computed: make([]bool, argCount)}
+			for i, operand := range operands {
+				if isComputedOperand(operand) {
+					c.compileItem(operand, false)
+					site.computed[i] = true
+					site.argCount++
+				}
+			}
+			c.code.opers = append(c.code.opers, site)
+			c.emit(opOper, len(c.code.opers)-1, 0)
+		}
+	default:
+		c.evalByTreeWalker(item, false)
+	}
+}
@@ -0,0 +1,472 @@
+package funl
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// ConversionError is error in conversion between Go value and FunL value,
+// path tells location of failing value (like .Items[1].name)
+type ConversionError struct {
+	Path    string
+	Message string
+}
+
+func (e *ConversionError) Error() string {
+	if e.Path == "" {
+		return "conversion failed: " + e.Message
+	}
+	return fmt.Sprintf("conversion failed at %s: %s", e.Path, e.Message)
+}
+
+func convError(path string, format string, args ...interface{}) error {
+	return &ConversionError{Path: path, Message: fmt.Sprintf(format, args...)}
+}
+
+// BytesOpaque is opaque value which has byte slice (like bytearray)
+type BytesOpaque interface {
+	OpaqueAPI
+	GetBytes() []byte
+}
+
+// NewBytesValue is used by ToValue for []byte, std-lib sets it to make
+// bytearray (without it []byte is converted to string)
+var NewBytesValue func([]byte) Value
+
+var (
+	valueType  = reflect.TypeOf(Value{})
+	timeType   = reflect.TypeOf(time.Time{})
+	bigIntType = reflect.TypeOf(&big.Int{})
+	bigRatType = reflect.TypeOf(&big.Rat{})
+)
+
+// ToValue converts Go value to FunL value:
+//   - bool, integers, floats and string to corresponding values
+//   - *big.Int to int and *big.Rat to decimal
+//   - []byte to bytearray
+//   - time.Time to string (RFC 3339)
+//   - slices and arrays to list, maps to map
+//   - structs to map which has field names as keys
+//   - pointers and interfaces to value they refer to
+//   - Value as such
+//
+// Key for struct field can be given with tag (`funl:"name"`),
+// "-" skips field and omitempty option leaves out zero value.
+//
+// Maps are created with default settings (see toValueIn).
+func ToValue(v interface{}) (retVal Value, err error) {
+	return toValueIn(nil, v)
+}
+
+// toValueIn converts Go value to FunL value, maps are created
+// with settings of interpreter to which frame belongs
+func toValueIn(frame *Frame, v interface{}) (retVal Value, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = convError("", "%s", asRuntimeError(r).Message)
+		}
+	}()
+	return toValue(frame, reflect.ValueOf(v), "")
+}
+
+func toValue(frame *Frame, rv reflect.Value, path string) (Value, error) {
+	if !rv.IsValid() {
+		return Value{}, convError(path, "nil value")
+	}
+	switch rv.Type() {
+	case valueType:
+		return rv.Interface().(Value), nil
+	case timeType:
+		return Value{Kind: StringValue, Data: rv.Interface().(time.Time).Format(time.RFC3339Nano)}, nil
+	case bigIntType:
+		if rv.IsNil() {
+			return Value{}, convError(path, "nil value")
+		}
+		return MakeIntValue(new(big.Int).Set(rv.Interface().(*big.Int))), nil
+	case bigRatType:
+		if rv.IsNil() {
+			return Value{}, convError(path, "nil value")
+		}
+		return MakeDecimalValue(new(big.Rat).Set(rv.Interface().(*big.Rat))), nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Bool:
+		return Value{Kind: BoolValue, Data: rv.Bool()}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return MakeIntValue(big.NewInt(rv.Int())), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return MakeIntValue(new(big.Int).SetUint64(rv.Uint())), nil
+	case reflect.Float32, reflect.Float64:
+		return Value{Kind: FloatValue, Data: rv.Float()}, nil
+	case reflect.String:
+		return Value{Kind: StringValue, Data: rv.String()}, nil
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			return Value{}, convError(path, "nil value")
+		}
+		return toValue(frame, rv.Elem(), path)
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8 {
+			data := append([]byte{}, rv.Bytes()...)
+			if NewBytesValue != nil {
+				return NewBytesValue(data), nil
+			}
+			return Value{Kind: StringValue, Data: string(data)}, nil
+		}
+		values := make([]Value, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			val, err := toValue(frame, rv.Index(i), fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return Value{}, err
+			}
+			values = append(values, val)
+		}
+		return MakeListOfValues(nil, values), nil
+	case reflect.Map:
+		mb := NewMapBuilder(frame)
+		iter := rv.MapRange()
+		for iter.Next() {
+			keyPath := fmt.Sprintf("%s[%v]", path, iter.Key())
+			key, err := toValue(frame, iter.Key(), keyPath)
+			if err != nil {
+				return Value{}, err
+			}
+			val, err := toValue(frame, iter.Value(), keyPath)
+			if err != nil {
+				return Value{}, err
+			}
+			if _, found := mb.Get(nil, key); found {
+				return Value{}, convError(keyPath, "duplicate key %s", key)
+			}
+			mb.Put(nil, key, val)
+		}
+		return mb.Freeze(nil), nil
+	case reflect.Struct:
+		mb := NewMapBuilder(frame)
+		for _, field := range structFields(rv.Type()) {
+			fv := rv.FieldByIndex(field.index)
+			if field.omitEmpty && fv.IsZero() {
+				continue
+			}
+			val, err := toValue(frame, fv, path+"."+field.name)
+			if err != nil {
+				return Value{}, err
+			}
+			mb.Put(nil, Value{Kind: StringValue, Data: field.name}, val)
+		}
+		return mb.Freeze(nil), nil
+	}
+	return Value{}, convError(path, "unsupported type: %s", rv.Type())
+}
+
+type structField struct {
+	index     []int
+	name      string
+	omitEmpty bool
+}
+
+// structFields returns exported fields of struct type which are not skipped by tag
+func structFields(t reflect.Type) (fields []structField) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		field := structField{index: f.Index, name: f.Name}
+		if tag, found := f.Tag.Lookup("funl"); found {
+			parts := strings.Split(tag, ",")
+			if parts[0] == "-" {
+				continue
+			}
+			if parts[0] != "" {
+				field.name = parts[0]
+			}
+			for _, opt := range parts[1:] {
+				field.omitEmpty = field.omitEmpty || opt == "omitempty"
+			}
+		}
+		fields = append(fields, field)
+	}
+	return
+}
+
+// FromValue converts FunL value to Go value to which target points,
+// conversions are reverse of ToValue and in addition:
+//   - int, float and decimal can be converted to Go float
+//   - vector and set can be converted to slice or array
+//   - string can be converted to []byte
+//   - int (nanoseconds since Unix epoch) can be converted to time.Time
+//   - records can be converted like maps
+//   - to empty interface values are converted to bool, int, *big.Int,
+//     float64, *big.Rat, string, []interface{} and map[string]interface{}
+//     (map[interface{}]interface{} if there are other than string keys),
+//     other values are given as Value
+//
+// Map keys which have no matching struct field are ignored and struct
+// fields for which there are no keys are left as they are.
+func FromValue(val Value, target interface{}) (err error) {
+	rv := reflect.ValueOf(target)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return convError("", "target should be non-nil pointer")
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = convError("", "%s", asRuntimeError(r).Message)
+		}
+	}()
+	return fromValue(val, rv.Elem(), "")
+}
+
+func cannotConvert(path string, val Value, t reflect.Type) error {
+	return convError(path, "cannot convert %s to %s", TypeNameOf(val), t)
+}
+
+func fromValue(val Value, rv reflect.Value, path string) error {
+	t := rv.Type()
+	switch t {
+	case valueType:
+		rv.Set(reflect.ValueOf(val))
+		return nil
+	case timeType:
+		switch val.Kind {
+		case StringValue:
+			tm, err := time.Parse(time.RFC3339Nano, val.Data.(string))
+			if err != nil {
+				return convError(path, "invalid time: %v", err)
+			}
+			rv.Set(reflect.ValueOf(tm))
+		case IntValue:
+			rv.Set(reflect.ValueOf(time.Unix(0, int64(val.Data.(int)))))
+		default:
+			return cannotConvert(path, val, t)
+		}
+		return nil
+	case bigIntType:
+		if !IsIntegerValue(val) {
+			return cannotConvert(path, val, t)
+		}
+		rv.Set(reflect.ValueOf(ToBigInt(val)))
+		return nil
+	case bigRatType:
+		if !isExactNumber(val) {
+			return cannotConvert(path, val, t)
+		}
+		rv.Set(reflect.ValueOf(new(big.Rat).Set(ToRat(val))))
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		if val.Kind != BoolValue {
+			return cannotConvert(path, val, t)
+		}
+		rv.SetBool(val.Data.(bool))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if !IsIntegerValue(val) {
+			return cannotConvert(path, val, t)
+		}
+		b := ToBigInt(val)
+		if !b.IsInt64() || rv.OverflowInt(b.Int64()) {
+			return convError(path, "%s overflows %s", b, t)
+		}
+		rv.SetInt(b.Int64())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if !IsIntegerValue(val) {
+			return cannotConvert(path, val, t)
+		}
+		b := ToBigInt(val)
+		if !b.IsUint64() || rv.OverflowUint(b.Uint64()) {
+			return convError(path, "%s overflows %s", b, t)
+		}
+		rv.SetUint(b.Uint64())
+	case reflect.Float32, reflect.Float64:
+		switch val.Kind {
+		case FloatValue:
+			rv.SetFloat(val.Data.(float64))
+		case IntValue, BigIntValue, DecimalValue:
+			rv.SetFloat(numberToFloat(val))
+		default:
+			return cannotConvert(path, val, t)
+		}
+	case reflect.String:
+		if val.Kind != StringValue {
+			return cannotConvert(path, val, t)
+		}
+		rv.SetString(val.Data.(string))
+	case reflect.Ptr:
+		elem := reflect.New(t.Elem())
+		if err := fromValue(val, elem.Elem(), path); err != nil {
+			return err
+		}
+		rv.Set(elem)
+	case reflect.Interface:
+		v := reflect.ValueOf(toInterface(val))
+		if !v.Type().AssignableTo(t) {
+			if v = reflect.ValueOf(val.Data); !v.IsValid() || !v.Type().AssignableTo(t) {
+				return cannotConvert(path, val, t)
+			}
+		}
+		rv.Set(v)
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return bytesFromValue(val, rv, path)
+		}
+		values, ok := sequenceValues(val)
+		if !ok {
+			return cannotConvert(path, val, t)
+		}
+		slice := reflect.MakeSlice(t, len(values), len(values))
+		for i, v := range values {
+			if err := fromValue(v, slice.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+		rv.Set(slice)
+	case reflect.Array:
+		values, ok := sequenceValues(val)
+		if !ok {
+			return cannotConvert(path, val, t)
+		}
+		if len(values) != t.Len() {
+			return convError(path, "length %d does not match %s", len(values), t)
+		}
+		for i, v := range values {
+			if err := fromValue(v, rv.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if val.Kind != MapValue && val.Kind != RecordValue {
+			return cannotConvert(path, val, t)
+		}
+		kvs := mapKeyVals(recordFields(val).Data.(*PMap))
+		m := reflect.MakeMapWithSize(t, len(kvs))
+		for _, kv := range kvs {
+			keyPath := fmt.Sprintf("%s[%s]", path, kv.Key)
+			key := reflect.New(t.Key()).Elem()
+			if err := fromValue(kv.Key, key, keyPath); err != nil {
+				return err
+			}
+			elem := reflect.New(t.Elem()).Elem()
+			if err := fromValue(kv.Val, elem, keyPath); err != nil {
+				return err
+			}
+			m.SetMapIndex(key, elem)
+		}
+		rv.Set(m)
+	case reflect.Struct:
+		if val.Kind != MapValue && val.Kind != RecordValue {
+			return cannotConvert(path, val, t)
+		}
+		fields := map[string]structField{}
+		for _, field := range structFields(t) {
+			fields[field.name] = field
+		}
+		for _, kv := range mapKeyVals(recordFields(val).Data.(*PMap)) {
+			if kv.Key.Kind != StringValue {
+				return convError(path, "key %s is not string", kv.Key)
+			}
+			field, found := fields[kv.Key.Data.(string)]
+			if !found {
+				continue
+			}
+			if err := fromValue(kv.Val, rv.FieldByIndex(field.index), path+"."+field.name); err != nil {
+				return err
+			}
+		}
+	default:
+		return convError(path, "unsupported type: %s", t)
+	}
+	return nil
+}
+
+func bytesFromValue(val Value, rv reflect.Value, path string) error {
+	var data []byte
+	switch val.Kind {
+	case StringValue:
+		data = []byte(val.Data.(string))
+	case OpaqueValue:
+		bo, ok := val.Data.(BytesOpaque)
+		if !ok {
+			return cannotConvert(path, val, rv.Type())
+		}
+		data = append([]byte{}, bo.GetBytes()...)
+	default:
+		values, ok := sequenceValues(val)
+		if !ok {
+			return cannotConvert(path, val, rv.Type())
+		}
+		data = make([]byte, len(values))
+		for i, v := range values {
+			if err := fromValue(v, reflect.ValueOf(&data[i]).Elem(), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+	rv.SetBytes(data)
+	return nil
+}
+
+// sequenceValues returns items of list, vector or set
+func sequenceValues(val Value) ([]Value, bool) {
+	switch val.Kind {
+	case ListValue:
+		values := []Value{}
+		it := NewListIterator(val)
+		for next := it.Next(); next != nil; next = it.Next() {
+			values = append(values, *next)
+		}
+		return values, true
+	case VectorValue:
+		return VectorValues(val), true
+	case SetValue:
+		return val.Data.(*Set).values(), true
+	}
+	return nil, false
+}
+
+// toInterface converts value to Go value for empty interface
+func toInterface(val Value) interface{} {
+	switch val.Kind {
+	case BoolValue, IntValue, FloatValue, StringValue:
+		return val.Data
+	case BigIntValue:
+		return ToBigInt(val)
+	case DecimalValue:
+		return new(big.Rat).Set(ToRat(val))
+	case ListValue, VectorValue, SetValue:
+		values, _ := sequenceValues(val)
+		items := make([]interface{}, 0, len(values))
+		for _, v := range values {
+			items = append(items, toInterface(v))
+		}
+		return items
+	case MapValue, RecordValue:
+		kvs := mapKeyVals(recordFields(val).Data.(*PMap))
+		strKeys := true
+		for _, kv := range kvs {
+			strKeys = strKeys && kv.Key.Kind == StringValue
+		}
+		if strKeys {
+			m := make(map[string]interface{}, len(kvs))
+			for _, kv := range kvs {
+				m[kv.Key.Data.(string)] = toInterface(kv.Val)
+			}
+			return m
+		}
+		m := make(map[interface{}]interface{}, len(kvs))
+		for _, kv := range kvs {
+			key := toInterface(kv.Key)
+			if k := reflect.ValueOf(key); !k.Type().Comparable() {
+				key = kv.Key
+			}
+			m[key] = toInterface(kv.Val)
+		}
+		return m
+	}
+	return val
+}
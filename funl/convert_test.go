@@ -0,0 +1,161 @@
+package funl
+
+import (
+	"math"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type convItem struct {
+	Name  string  `funl:"name"`
+	Price float64 `funl:"price"`
+	Tags  []string
+}
+
+type convOrder struct {
+	ID       int                `funl:"id"`
+	Count    uint8              `funl:"count"`
+	Big      *big.Int           `funl:"big"`
+	Amount   *big.Rat           `funl:"amount"`
+	Paid     bool               `funl:"paid"`
+	Created  time.Time          `funl:"created"`
+	Raw      []byte             `funl:"raw"`
+	Items    []convItem         `funl:"items"`
+	ByName   map[string]float64 `funl:"by-name"`
+	Matrix   [2][2]int          `funl:"matrix"`
+	Note     *string            `funl:"note,omitempty"`
+	Any      interface{}        `funl:"any"`
+	Skipped  int                `funl:"-"`
+	internal int
+}
+
+func TestConvertRoundTrip(t *testing.T) {
+	note := "fragile"
+	bigVal, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	orig := convOrder{
+		ID:      -7,
+		Count:   255,
+		Big:     bigVal,
+		Amount:  big.NewRat(1, 4),
+		Paid:    true,
+		Created: time.Date(2021, 3, 4, 5, 6, 7, 8, time.UTC),
+		Raw:     []byte{0, 1, 255},
+		Items: []convItem{
+			{Name: "a", Price: 1.5, Tags: []string{"x", "y"}},
+			{Name: "b", Price: 0, Tags: []string{}},
+		},
+		ByName:  map[string]float64{"a": 1.5, "b": 0},
+		Matrix:  [2][2]int{{1, 2}, {3, 4}},
+		Note:    &note,
+		Any:     map[string]interface{}{"k": []interface{}{true, 1, "s", 0.5}},
+		Skipped: 5,
+	}
+	val, err := ToValue(orig)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, expected := range []string{"'id' : -7", "'big' : 123456789012345678901234567890", "'by-name' : map(", "'Tags' : list('x', 'y')", "'created' : '2021-03-04T05:06:07.000000008Z'"} {
+		if !strings.Contains(val.String(), expected) {
+			t.Errorf("%s not found in %s", expected, val)
+		}
+	}
+	if strings.Contains(val.String(), "Skipped") || strings.Contains(val.String(), "internal") {
+		t.Errorf("field should be skipped: %s", val)
+	}
+
+	var conv convOrder
+	if err := FromValue(val, &conv); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	orig.Skipped = 0
+	if !reflect.DeepEqual(orig, conv) {
+		t.Errorf("round-trip failed:\n%#v\n%#v", orig, conv)
+	}
+
+	// omitempty
+	orig.Note = nil
+	val, _ = ToValue(orig)
+	if strings.Contains(val.String(), "note") {
+		t.Errorf("note should be omitted: %s", val)
+	}
+}
+
+func TestConvertScalars(t *testing.T) {
+	for _, v := range []interface{}{true, 1, int8(-8), int64(math.MinInt64), uint64(math.MaxUint64), 0.25, float32(0.5), "text"} {
+		val, err := ToValue(v)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		target := reflect.New(reflect.TypeOf(v))
+		if err := FromValue(val, target.Interface()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if back := target.Elem().Interface(); back != v {
+			t.Errorf("round-trip failed: %v -> %s -> %v", v, val, back)
+		}
+	}
+
+	var anyVal interface{}
+	if err := FromValue(MakeListOfValues(nil, []Value{{Kind: IntValue, Data: 1}, MakeDecimalValue(big.NewRat(1, 2))}), &anyVal); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(anyVal, []interface{}{1, big.NewRat(1, 2)}) {
+		t.Errorf("unexpected value: %#v", anyVal)
+	}
+	var val Value
+	fv := Value{Kind: ExtProcValue, Data: ExtProcType{}}
+	if err := FromValue(fv, &val); err != nil || val.Kind != ExtProcValue {
+		t.Errorf("unexpected value: %v (%v)", val, err)
+	}
+
+	var tm time.Time
+	if err := FromValue(Value{Kind: IntValue, Data: 1000000000}, &tm); err != nil || tm.Unix() != 1 {
+		t.Errorf("unexpected time: %v (%v)", tm, err)
+	}
+	var floats []float64
+	if err := FromValue(MakeVectorOfValues([]Value{{Kind: IntValue, Data: 1}, {Kind: FloatValue, Data: 0.5}}), &floats); err != nil || !reflect.DeepEqual(floats, []float64{1, 0.5}) {
+		t.Errorf("unexpected value: %v (%v)", floats, err)
+	}
+}
+
+func TestConvertErrors(t *testing.T) {
+	var order convOrder
+	items, _ := ToValue(map[string]interface{}{"items": []interface{}{map[string]interface{}{"name": 1}}})
+	overflow, _ := ToValue(map[string]int{"count": 256})
+	negative, _ := ToValue(-1)
+	badTime, _ := ToValue(map[string]string{"created": "yesterday"})
+	badKey, _ := ToValue(map[int]int{1: 2})
+	badMatrix, _ := ToValue(map[string][]int{"matrix": {1, 2, 3}})
+
+	var u uint
+	var ch chan int
+	cases := []struct {
+		val      Value
+		target   interface{}
+		expected string
+	}{
+		{items, &order, "at .items[0].name: cannot convert int to string"},
+		{overflow, &order, "at .count: 256 overflows uint8"},
+		{negative, &u, "-1 overflows uint"},
+		{badTime, &order, "at .created: invalid time"},
+		{badKey, &order, "key 1 is not string"},
+		{badMatrix, &order, "at .matrix: length 3 does not match [2][2]int"},
+		{negative, order, "target should be non-nil pointer"},
+		{negative, &ch, "unsupported type: chan int"},
+	}
+	for _, c := range cases {
+		err := FromValue(c.val, c.target)
+		if _, ok := err.(*ConversionError); !ok || !strings.Contains(err.Error(), c.expected) {
+			t.Errorf("unexpected error: %v (assumed %s)", err, c.expected)
+		}
+	}
+
+	for _, v := range []interface{}{nil, make(chan int), []interface{}{1, (*int)(nil)}, map[interface{}]int{1: 1, int8(1): 2}} {
+		if _, err := ToValue(v); err == nil {
+			t.Errorf("should fail: %#v", v)
+		}
+	}
+}
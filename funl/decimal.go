@@ -0,0 +1,163 @@
+package funl
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+)
+
+// DecimalValue is exact number (Data is *big.Rat), it's created
+// by conv(x 'decimal') and promoted from int in arithmetic with decimals.
+
+// MakeDecimalValue returns decimal value
+func MakeDecimalValue(r *big.Rat) Value {
+	return Value{Kind: DecimalValue, Data: r}
+}
+
+// isExactNumber returns true for int, big int and decimal values
+func isExactNumber(val Value) bool {
+	return val.Kind == IntValue || val.Kind == BigIntValue || val.Kind == DecimalValue
+}
+
+// ToRat returns int or decimal value as new big.Rat
+func ToRat(val Value) *big.Rat {
+	switch val.Kind {
+	case DecimalValue:
+		return new(big.Rat).Set(val.Data.(*big.Rat))
+	case BigIntValue:
+		return new(big.Rat).SetInt(val.Data.(*big.Int))
+	}
+	return new(big.Rat).SetInt64(int64(val.Data.(int)))
+}
+
+// compareExactNumbers compares int/decimal values, returns -1, 0 or 1
+func compareExactNumbers(val1, val2 Value) int {
+	if val1.Kind == DecimalValue || val2.Kind == DecimalValue {
+		return ToRat(val1).Cmp(ToRat(val2))
+	}
+	return ToBigInt(val1).Cmp(ToBigInt(val2))
+}
+
+// ParseDecimal parses decimal from string ('12.50', '-3', '1/3')
+func ParseDecimal(s string) (*big.Rat, bool) {
+	return new(big.Rat).SetString(s)
+}
+
+// FloatToDecimal converts float to decimal by using shortest
+// decimal representation of float (so 0.1 is 0.1 exactly)
+func FloatToDecimal(f float64) (*big.Rat, bool) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, false
+	}
+	return ParseDecimal(strconv.FormatFloat(f, 'g', -1, 64))
+}
+
+// DecimalString returns decimal as exact decimal number text,
+// if decimal representation is not finite it is returned as fraction (1/3)
+func DecimalString(r *big.Rat) string {
+	if places, ok := decimalPlaces(r); ok {
+		return r.FloatString(places)
+	}
+	return r.String()
+}
+
+// decimalPlaces returns number of fractional digits needed for exact
+// decimal representation (denominator needs to have only factors 2 and 5)
+func decimalPlaces(r *big.Rat) (int, bool) {
+	den := new(big.Int).Set(r.Denom())
+	two, five := big.NewInt(2), big.NewInt(5)
+	var twos, fives int
+	mod := new(big.Int)
+	for {
+		q, m := new(big.Int).QuoRem(den, two, mod)
+		if m.Sign() != 0 {
+			break
+		}
+		den, twos = q, twos+1
+	}
+	for {
+		q, m := new(big.Int).QuoRem(den, five, mod)
+		if m.Sign() != 0 {
+			break
+		}
+		den, fives = q, fives+1
+	}
+	if den.Cmp(big.NewInt(1)) != 0 {
+		return 0, false
+	}
+	if twos > fives {
+		return twos, true
+	}
+	return fives, true
+}
+
+// remDecimal returns remainder of truncated division (sign follows dividend
+// like with int), divisor is assumed to be non-zero
+func remDecimal(dividend, divisor *big.Rat) *big.Rat {
+	quo := new(big.Rat).Quo(dividend, divisor)
+	trunc := new(big.Int).Quo(quo.Num(), quo.Denom())
+	prod := new(big.Rat).Mul(divisor, new(big.Rat).SetInt(trunc))
+	return prod.Sub(dividend, prod)
+}
+
+// numberToFloat converts int or decimal value to float64
+func numberToFloat(val Value) float64 {
+	if val.Kind == DecimalValue {
+		f, _ := val.Data.(*big.Rat).Float64()
+		return f
+	}
+	return intToFloat(val)
+}
+
+// RoundDecimal rounds decimal to given number of fractional digits,
+// mode is one of: 'half-even', 'half-up', 'half-down', 'up', 'down', 'ceiling', 'floor'
+func RoundDecimal(r *big.Rat, places int, mode string) (*big.Rat, error) {
+	if places < 0 {
+		return nil, fmt.Errorf("negative number of places (%d)", places)
+	}
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(places)), nil)
+	scaled := new(big.Rat).Mul(r, new(big.Rat).SetInt(scale))
+
+	num, den := scaled.Num(), scaled.Denom()
+	q, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if rem.Sign() != 0 {
+		away := false
+		half := new(big.Int).Abs(rem)
+		half.Mul(half, big.NewInt(2))
+		switch mode {
+		case "down":
+		case "up":
+			away = true
+		case "floor":
+			away = num.Sign() < 0
+		case "ceiling":
+			away = num.Sign() > 0
+		case "half-up":
+			away = half.Cmp(den) >= 0
+		case "half-down":
+			away = half.Cmp(den) > 0
+		case "half-even":
+			cmp := half.Cmp(den)
+			away = cmp > 0 || (cmp == 0 && q.Bit(0) == 1)
+		default:
+			return nil, fmt.Errorf("unknown rounding mode (%s)", mode)
+		}
+		if away {
+			q.Add(q, big.NewInt(int64(num.Sign())))
+		}
+	}
+	return new(big.Rat).SetFrac(q, scale), nil
+}
+
+// getHashedDecimal returns same hash for integral decimal as for
+// equal integer (as eq is true for those)
+func getHashedDecimal(from *big.Rat) int {
+	if from.IsInt() {
+		if intVal := MakeIntValue(from.Num()); intVal.Kind == IntValue {
+			return getHashedInt(intVal.Data.(int))
+		}
+		return getHashedBigInt(from.Num())
+	}
+	return getHashedString(from.RatString())
+}
@@ -0,0 +1,78 @@
+package funl
+
+import (
+	"testing"
+)
+
+func TestDecimalString(t *testing.T) {
+	cases := map[string]string{
+		"0.1":     "0.1",
+		"12.50":   "12.5",
+		"-3":      "-3",
+		"1/3":     "1/3",
+		"1/8":     "0.125",
+		"1/40":    "0.025",
+		"1234e-5": "0.01234",
+	}
+	for src, expected := range cases {
+		r, ok := ParseDecimal(src)
+		if !ok {
+			t.Fatalf("parse failed: %s", src)
+		}
+		if s := DecimalString(r); s != expected {
+			t.Errorf("%s: unexpected string %s (assumed %s)", src, s, expected)
+		}
+	}
+}
+
+func TestRoundDecimal(t *testing.T) {
+	cases := []struct {
+		value    string
+		places   int
+		mode     string
+		expected string
+	}{
+		{"2.5", 0, "half-even", "2"},
+		{"3.5", 0, "half-even", "4"},
+		{"-2.5", 0, "half-even", "-2"},
+		{"2.5", 0, "half-up", "3"},
+		{"-2.5", 0, "half-up", "-3"},
+		{"2.5", 0, "half-down", "2"},
+		{"2.51", 0, "half-down", "3"},
+		{"1.001", 2, "up", "1.01"},
+		{"-1.001", 2, "up", "-1.01"},
+		{"1.009", 2, "down", "1"},
+		{"-1.001", 2, "floor", "-1.01"},
+		{"1.001", 2, "ceiling", "1.01"},
+		{"-1.009", 2, "ceiling", "-1"},
+		{"1/3", 3, "half-even", "0.333"},
+		{"2/3", 3, "half-even", "0.667"},
+	}
+	for _, c := range cases {
+		r, _ := ParseDecimal(c.value)
+		result, err := RoundDecimal(r, c.places, c.mode)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if s := DecimalString(result); s != c.expected {
+			t.Errorf("%s (%d %s): unexpected result %s (assumed %s)", c.value, c.places, c.mode, s, c.expected)
+		}
+	}
+
+	r, _ := ParseDecimal("1.5")
+	if _, err := RoundDecimal(r, 0, "sideways"); err == nil {
+		t.Errorf("error assumed for unknown mode")
+	}
+}
+
+func TestDecimalHashMatchesInt(t *testing.T) {
+	two, _ := ParseDecimal("2")
+	if getHashedDecimal(two) != getHashedInt(2) {
+		t.Errorf("hash of decimal 2 differs from int 2")
+	}
+	big, _ := ParseDecimal("123456789012345678901234567890")
+	bigInt, _ := parseIntValue("123456789012345678901234567890")
+	if h, err := hashOfValue(nil, bigInt); err != nil || h != getHashedDecimal(big) {
+		t.Errorf("hash of decimal differs from big int")
+	}
+}
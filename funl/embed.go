@@ -0,0 +1,91 @@
+package funl
+
+import (
+	"fmt"
+)
+
+// Embedding API: modules can be loaded to interpreter and functions
+// called from Go code so that runtime errors are returned as errors
+// (*RuntimeError) instead of panics. Interpreter needs to be initialized
+// once with Init before modules which import std-lib are loaded.
+
+// LoadModule parses module source and adds it to interpreter,
+// name needs to be same as namespace name in source
+func (interpreter *Interpreter) LoadModule(name, src string) error {
+	return interpreter.protect(func() error {
+		_, err := commonAddFunModToNamespace(true, name, name, []byte(src), interpreter)
+		return err
+	})
+}
+
+// Call calls function or procedure of module with given arguments
+func (interpreter *Interpreter) Call(mod, fn string, args ...Value) (retVal Value, err error) {
+	// unknown names are not added to symbol ids
+	var topFrame *Frame
+	modSid, found := interpreter.SymIDs.Get(mod)
+	if found {
+		topFrame, found = interpreter.NsDir.GetTopFrameBySID(modSid)
+	}
+	if !found {
+		err = fmt.Errorf("module not found: %s", mod)
+		return
+	}
+	funcItem, found := topFrame.Syms.GetByName(fn)
+	if !found || funcItem.Type != ValueItem {
+		err = fmt.Errorf("symbol not found: %s.%s", mod, fn)
+		return
+	}
+	switch funcItem.Data.(Value).Kind {
+	case FunctionValue, ExtProcValue:
+	default:
+		err = fmt.Errorf("%s.%s is not function or procedure", mod, fn)
+		return
+	}
+
+	operands := []*Item{funcItem}
+	for _, arg := range args {
+		operands = append(operands, &Item{Type: ValueItem, Data: arg})
+	}
+	callFrame := &Frame{
+		Syms:       NewSymtWithConverter(interpreter.SymIDs),
+		OtherNS:    make(map[SymID]ImportInfo),
+		Imported:   make(map[SymID]*Frame),
+		AccessLink: topFrame,
+		inProcCall: true,
+	}
+	err = interpreter.protect(func() error {
+		retVal = handleCallOP(callFrame, operands)
+		return nil
+	})
+	return
+}
+
+// Eval evaluates expression, all modules loaded to interpreter
+// can be referred in expression by module name
+func (interpreter *Interpreter) Eval(expr string) (retVal Value, err error) {
+	parser := interpreter.NewParser(nil)
+	parser.SetErrorHandler(&evalErrHandler{})
+	item, err := parser.ParseOneExpression(expr)
+	if err != nil {
+		err = fmt.Errorf("error in parsing expression (%v)", err)
+		return
+	}
+	frame := NewTopFrameWithInterpreter(interpreter)
+	frame.Imported = interpreter.NsDir.topFrames()
+	frame.inProcCall = true
+	err = interpreter.protect(func() error {
+		retVal = EvalItem(item, frame)
+		return nil
+	})
+	return
+}
+
+// protect calls f and converts runtime error panic to returned error
+func (interpreter *Interpreter) protect(f func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = asRuntimeError(r)
+		}
+	}()
+	return f()
+}
@@ -0,0 +1,205 @@
+package funl
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const embedTestSrc = `
+ns calc
+
+import hostmod
+
+add = func(a b) plus(a b) end
+
+div = func(a b)
+	call(check b)
+	div(a b)
+end
+
+check = func(b)
+	if( eq(b 0) error('division by zero') true)
+end
+
+scaled = proc(x) call(hostmod.scale x) end
+
+endns
+`
+
+func newEmbedTestInterpreter(t *testing.T, initCount *int) *Interpreter {
+	interpreter := NewInterpreter()
+	interpreter.Importer = &fileImporter{}
+	initHost := func(interpreter *Interpreter) error {
+		*initCount++
+		topFrame := NewTopFrameWithInterpreter(interpreter)
+		scale := ExtProcType{
+			Impl: func(frame *Frame, arguments []Value) Value {
+				return Value{Kind: IntValue, Data: 10 * arguments[0].Data.(int)}
+			},
+		}
+		topFrame.Syms.Add("scale", &Item{Type: ValueItem, Data: Value{Kind: ExtProcValue, Data: scale}})
+		interpreter.NsDir.Put(interpreter.SymIDs.Add("hostmod"), topFrame)
+		return nil
+	}
+	// fun source std-lib is not needed here
+	stdfunMapSaved := stdfunMap
+	stdfunMap = map[string]string{}
+	defer func() { stdfunMap = stdfunMapSaved }()
+	for _, name := range []string{"stdfu", "stdset", "stddbc", "stdfilu", "stdser", "stdmeta", "stdpp", "stdpr", "stdsort", "stdcsv2"} {
+		stdfunMap[name] = "ns " + name + " endns"
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := interpreter.Init(initHost); err != nil {
+			t.Fatalf("init failed: %v", err)
+		}
+	}
+	if err := interpreter.LoadModule("calc", embedTestSrc); err != nil {
+		t.Fatalf("loading failed: %v", err)
+	}
+	return interpreter
+}
+
+func TestEmbedCall(t *testing.T) {
+	var initCount int
+	interpreter := newEmbedTestInterpreter(t, &initCount)
+	if initCount != 1 {
+		t.Errorf("init should be done once (%d)", initCount)
+	}
+
+	for i := 0; i < 3; i++ {
+		v, err := interpreter.Call("calc", "add", Value{Kind: IntValue, Data: i}, Value{Kind: IntValue, Data: 2})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if v.Kind != IntValue || v.Data.(int) != i+2 {
+			t.Errorf("unexpected result: %s", v)
+		}
+	}
+	v, err := interpreter.Call("calc", "scaled", Value{Kind: IntValue, Data: 3})
+	if err != nil || v.Data.(int) != 30 {
+		t.Errorf("unexpected result: %s (%v)", v, err)
+	}
+
+	for _, fn := range []string{"nosuch", "nomod"} {
+		if _, err := interpreter.Call("calc", fn); err == nil {
+			t.Errorf("should fail: %s", fn)
+		}
+	}
+	if _, err := interpreter.Call("nomod", "add"); err == nil {
+		t.Errorf("should fail")
+	}
+}
+
+func TestEmbedRuntimeError(t *testing.T) {
+	var initCount int
+	interpreter := newEmbedTestInterpreter(t, &initCount)
+	_, err := interpreter.Call("calc", "div", Value{Kind: IntValue, Data: 1}, Value{Kind: IntValue, Data: 0})
+	var rte *RuntimeError
+	if !errors.As(err, &rte) {
+		t.Fatalf("should be runtime error: %v", err)
+	}
+	if rte.Kind != ErrorKind || rte.Message != "division by zero" {
+		t.Errorf("unexpected error: %#v", rte)
+	}
+	if rte.Location == nil || rte.Location.SrcFileName != "calc" || rte.Location.Lineno != 14 {
+		t.Errorf("unexpected location: %v", rte.Location)
+	}
+	backtrace := rte.Backtrace()
+	if len(backtrace) < 2 {
+		t.Fatalf("unexpected backtrace: %v", backtrace)
+	}
+	if call := backtrace[0]; call.Lineno != 13 || call.CallSite == nil || call.CallSite.Lineno != 9 {
+		t.Errorf("unexpected call info: %#v", call)
+	}
+	if call := backtrace[1]; call.Lineno != 8 || len(call.Args) != 2 {
+		t.Errorf("unexpected call info: %#v", call)
+	}
+
+	// interpreter can be used after error
+	if _, err := interpreter.Call("calc", "div", Value{Kind: IntValue, Data: 4}, Value{Kind: IntValue, Data: 2}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := interpreter.Call("calc", "add", Value{Kind: StringValue, Data: "x"}, Value{Kind: IntValue, Data: 1}); err == nil {
+		t.Errorf("should fail")
+	}
+}
+
+func TestEmbedEvalAndLoad(t *testing.T) {
+	var initCount int
+	interpreter := newEmbedTestInterpreter(t, &initCount)
+	v, err := interpreter.Eval("call(calc.add call(hostmod.scale 2) 1)")
+	if err != nil || v.Data.(int) != 21 {
+		t.Errorf("unexpected result: %s (%v)", v, err)
+	}
+	if _, err := interpreter.Eval("call(calc.div 1 0)"); err == nil || !strings.Contains(err.Error(), "division by zero") {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := interpreter.Eval("plus(1"); err == nil {
+		t.Errorf("should fail")
+	}
+
+	if err := interpreter.LoadModule("other", "ns calc endns"); err == nil {
+		t.Errorf("should fail")
+	}
+	if err := interpreter.LoadModule("bad", "ns bad x = call(nosuch) endns"); err == nil {
+		t.Errorf("should fail")
+	}
+	if err := interpreter.LoadModule("calc2", "ns calc2 import calc inc = func(x) call(calc.add x 1) end endns"); err != nil {
+		t.Fatalf("loading failed: %v", err)
+	}
+	if v, err := interpreter.Call("calc2", "inc", Value{Kind: IntValue, Data: 1}); err != nil || v.Data.(int) != 2 {
+		t.Errorf("unexpected result: %s (%v)", v, err)
+	}
+}
+
+func TestEmbedInitError(t *testing.T) {
+	interpreter := NewInterpreter()
+	failing := func(interpreter *Interpreter) error {
+		RunTimeError("init failed")
+		return nil
+	}
+	if err := interpreter.Init(failing); err == nil {
+		t.Errorf("should fail")
+	}
+	if err := interpreter.Init(failing); err == nil {
+		t.Errorf("should fail also later")
+	}
+}
+
+func TestEmbedInitWithOtherFunction(t *testing.T) {
+	interpreter := NewInterpreter()
+	var otherCalled bool
+	initFirst := func(*Interpreter) error { return errors.New("first") }
+	initOther := func(*Interpreter) error { otherCalled = true; return nil }
+	if interpreter.Initialized() {
+		t.Errorf("should not be initialized")
+	}
+	if err := interpreter.Init(initFirst); err == nil || !strings.Contains(err.Error(), "first") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !interpreter.Initialized() {
+		t.Errorf("should be initialized")
+	}
+	if err := interpreter.Init(initFirst); err == nil || !strings.Contains(err.Error(), "first") {
+		t.Errorf("unexpected error in repeated init: %v", err)
+	}
+	if err := interpreter.Init(initOther); err == nil || !strings.Contains(err.Error(), "first") {
+		t.Errorf("unexpected error in init with other function: %v", err)
+	}
+	if otherCalled {
+		t.Errorf("init function should not be called after first init")
+	}
+}
+
+func TestEmbedCallUnknownModule(t *testing.T) {
+	interpreter := NewInterpreter()
+	symCount := interpreter.SymIDs.SymbolCount()
+	if _, err := interpreter.Call("no-such-module-xyz", "f"); err == nil || !strings.Contains(err.Error(), "module not found") {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if count := interpreter.SymIDs.SymbolCount(); count != symCount {
+		t.Errorf("symbol added (%d -> %d)", symCount, count)
+	}
+}
@@ -0,0 +1,154 @@
+package funl
+
+import (
+	"fmt"
+)
+
+// asRuntimeError converts recovered value to runtime error
+func asRuntimeError(r interface{}) *RuntimeError {
+	switch err := r.(type) {
+	case *RuntimeError:
+		return err
+	case error:
+		return &RuntimeError{Kind: RTEKind, Message: err.Error(), located: true}
+	}
+	return &RuntimeError{Kind: RTEKind, Message: fmt.Sprint(r), located: true}
+}
+
+// ErrorValue returns runtime error as map value which has keys:
+// kind, message, file, line, col, backtrace and data (if given)
+func (e *RuntimeError) ErrorValue(frame *Frame) Value {
+	file, line, col := "", 0, 0
+	if e.Location != nil {
+		file, line, col = e.Location.SrcFileName, e.Location.Lineno, e.Location.Col
+	}
+	var backtrace Value
+	if e.frame != nil {
+		backtrace = makeBacktrace(frame, e.frame)
+	} else {
+		backtrace = MakeListOfValues(frame, []Value{})
+	}
+	keyvals := []interface{}{
+		"kind", Value{Kind: StringValue, Data: e.Kind},
+		"message", Value{Kind: StringValue, Data: e.Message},
+		"file", Value{Kind: StringValue, Data: file},
+		"line", Value{Kind: IntValue, Data: line},
+		"col", Value{Kind: IntValue, Data: col},
+		"backtrace", backtrace,
+	}
+	if e.Data != nil {
+		keyvals = append(keyvals, "data", *e.Data)
+	}
+	return makeStrKeyMap(frame, keyvals...)
+}
+
+// errorFromMap makes runtime error from map value (kind, message and data),
+// if there's no message then text of map is message (as in earlier versions)
+func errorFromMap(frame *Frame, opName string, mapVal Value) *RuntimeError {
+	rte := &RuntimeError{Kind: ErrorKind, Message: mapVal.String(), userText: true}
+	if kindVal, found := getFromStrKeyMap(frame, mapVal, "kind"); found {
+		if kindVal.Kind != StringValue {
+			runTimeError2(frame, "%s: kind should be string", opName)
+		}
+		rte.Kind = kindVal.Data.(string)
+	}
+	if msgVal, found := getFromStrKeyMap(frame, mapVal, "message"); found {
+		if msgVal.Kind == StringValue {
+			rte.Message = msgVal.Data.(string)
+		} else {
+			rte.Message = msgVal.String()
+		}
+	}
+	if dataVal, found := getFromStrKeyMap(frame, mapVal, "data"); found {
+		rte.Data = &dataVal
+	}
+	return rte
+}
+
+// MakeBacktrace returns list of call information (map for each call)
+// starting from given frame
+func MakeBacktrace(frame *Frame) Value {
+	return makeBacktrace(frame, frame)
+}
+
+func makeBacktrace(frame *Frame, fromFrame *Frame) Value {
+	stack := []Value{}
+	for _, call := range backtraceOf(fromFrame) {
+		keyvals := []interface{}{
+			"line", Value{Kind: IntValue, Data: call.Lineno},
+			"file", Value{Kind: StringValue, Data: call.SrcFileName},
+			"args", MakeListOfValues(frame, call.Args),
+		}
+		// position of call from which frame was created
+		if callSite := call.CallSite; callSite != nil {
+			keyvals = append(keyvals,
+				"call-line", Value{Kind: IntValue, Data: callSite.Lineno},
+				"call-col", Value{Kind: IntValue, Data: callSite.Col},
+				"call-file", Value{Kind: StringValue, Data: callSite.SrcFileName},
+			)
+		} else {
+			keyvals = append(keyvals,
+				"call-line", Value{Kind: IntValue, Data: 0},
+				"call-col", Value{Kind: IntValue, Data: 0},
+				"call-file", Value{Kind: StringValue, Data: "-"},
+			)
+		}
+		stack = append(stack, makeStrKeyMap(frame, keyvals...))
+	}
+	return MakeListOfValues(frame, stack)
+}
+
+// CallInfo is information about one call in backtrace
+type CallInfo struct {
+	SrcFileName string  // file of called function ("-" if not known)
+	Lineno      int     // line of called function (0 if not known)
+	Args        []Value // argument values of call
+	CallSite    *SrcPos // position of call (nil if not known)
+}
+
+// Backtrace returns calls which were active when error was raised,
+// innermost call first
+func (e *RuntimeError) Backtrace() []CallInfo {
+	return backtraceOf(e.frame)
+}
+
+func backtraceOf(fromFrame *Frame) (calls []CallInfo) {
+	for prevFrame := fromFrame; prevFrame != nil; prevFrame = prevFrame.Previous {
+		call := CallInfo{SrcFileName: "-", Args: []Value{}, CallSite: prevFrame.CallSite}
+		if prevFrame.FuncProto != nil {
+			call.SrcFileName = prevFrame.FuncProto.SrcFileName
+			call.Lineno = prevFrame.FuncProto.Lineno
+			call.Args = prevFrame.EvaluatedArgs
+		}
+		calls = append(calls, call)
+	}
+	return
+}
+
+// makeStrKeyMap makes map value from string keys and values given in pairs
+func makeStrKeyMap(frame *Frame, keyvals ...interface{}) Value {
+	var operands []*Item
+	for i := 0; i < len(keyvals); i += 2 {
+		operands = append(operands,
+			&Item{Type: ValueItem, Data: Value{Kind: StringValue, Data: keyvals[i].(string)}},
+			&Item{Type: ValueItem, Data: keyvals[i+1].(Value)},
+		)
+	}
+	return handleMapOP(frame, operands)
+}
+
+func getFromStrKeyMap(frame *Frame, mapVal Value, key string) (Value, bool) {
+	return getFromMap(frame, mapVal, Value{Kind: StringValue, Data: key})
+}
+
+// getFromMap gets value by key from map value
+func getFromMap(frame *Frame, mapVal Value, key Value) (Value, bool) {
+	operands := []*Item{
+		&Item{Type: ValueItem, Data: mapVal},
+		&Item{Type: ValueItem, Data: key},
+	}
+	lit := NewListIterator(handleGetlOP(frame, operands))
+	found := lit.Next()
+	val := lit.Next()
+	return *val, found.Data.(bool)
+}
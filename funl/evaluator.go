@@ -76,6 +76,12 @@ func init() {
 	operTbl[RecwithOP] = handleRecwithOP
 	operTbl[DeferOP] = handleDeferOP
 	operTbl[ForceOP] = handleForceOP
+	operTbl[TryeOP] = handleTryeOP
+	operTbl[MatchOP] = handleMatchOP
+	operTbl[RectypeOP] = handleRectypeOP
+	operTbl[VectorOP] = handleVectorOP
+	operTbl[SetindOP] = handleSetindOP
+	operTbl[SetOP] = handleSetOP
 }
 
 func RunTimeError(format string, args ...interface{}) {
@@ -90,13 +96,57 @@ func RunTimeError2(frame *Frame, format string, args ...interface{}) {
 	runTimeError2(frame, format, args...)
 }
 
+// PrintingRTElocationAndScopeEnabled is default for interpreter setting
 var PrintingRTElocationAndScopeEnabled bool
 
+// Kinds of runtime errors
+const (
+	RTEKind   = "rte"   // error raised by interpreter or operators
+	ErrorKind = "error" // error raised by error -operator (default kind)
+)
+
+// RuntimeError is error raised in evaluation, location is source position
+// of innermost operator call (or symbol) in which error happened
+type RuntimeError struct {
+	Kind     string
+	Message  string
+	Data     *Value // data given by user (nil if not given)
+	Location *SrcPos
+	located  bool   // location is not set anymore
+	userText bool   // location is not added to error text
+	frame    *Frame // frame in which error was raised (for backtrace)
+}
+
+// Error returns error text without position (as try and tryl give it)
+func (e *RuntimeError) Error() string {
+	return e.Message
+}
+
+// LocatedText returns error text with source position (if known)
+func (e *RuntimeError) LocatedText() string {
+	if e.Location == nil || e.userText {
+		return e.Message
+	}
+	return fmt.Sprintf("%s at %s", e.Message, e.Location)
+}
+
 func runTimeError2(frame *Frame, format string, args ...interface{}) {
-	if false {
-		fmt.Printf("\nruntime error: "+format+"\n", args...)
+	raiseRTE(frame, &RuntimeError{Kind: RTEKind, Message: fmt.Sprintf(format, args...)})
+}
+
+// runTimeErrorAt raises runtime error which has known source position
+func runTimeErrorAt(frame *Frame, pos *SrcPos, format string, args ...interface{}) {
+	raiseRTE(frame, &RuntimeError{Kind: RTEKind, Message: fmt.Sprintf(format, args...), Location: pos, located: pos != nil})
+}
+
+func raiseRTE(frame *Frame, rte *RuntimeError) {
+	rte.frame = frame
+	// error gets position of operator call which is evaluated in frame
+	if !rte.located && frame != nil && frame.pos != nil {
+		rte.Location = frame.pos
+		rte.located = true
 	}
-	if PrintingRTElocationAndScopeEnabled {
+	if frame.Settings().PrintingRTElocationAndScopeEnabled {
 		if frame != nil {
 			fmt.Printf("call scope (RTE):\n")
 			fDebugData := frame.GetFuncDebugInfos([]fdebugInfo{})
@@ -118,7 +168,7 @@ func runTimeError2(frame *Frame, format string, args ...interface{}) {
 						if v.Type == ValueItem {
 							valAsStr = fmt.Sprintf("%s", v.Data.(Value))
 						}
-						fmt.Printf("       %s: %s\n", SymIDMap.AsString(k), valAsStr)
+						fmt.Printf("       %s: %s\n", frame.SymIDs().AsString(k), valAsStr)
 					}
 				} else {
 					fmt.Printf("  %d: -\n", idx)
@@ -126,12 +176,17 @@ func runTimeError2(frame *Frame, format string, args ...interface{}) {
 			}
 		}
 	}
-	panic(fmt.Errorf(format, args...))
+	panic(rte)
 }
 
 type Frame struct {
 	FuncProto     *Function
-	Syms          *Symt
+	Syms          *Symt   // top frame symbols (and ones added by let -operator)
+	slots         []Value // arguments and let definitions of function
+	slotNames     []SymID // names of slots if frame is for match clause
+	importSlots   []Value // imported symbols bound in import
+	checkDups     bool    // duplicate names need to be checked in runtime
+	hasLetSyms    bool    // let -operator has added symbols to frame
 	OtherNS       map[SymID]ImportInfo
 	AccessLink    *Frame // nil if root
 	Imported      map[SymID]*Frame
@@ -139,6 +194,15 @@ type Frame struct {
 	EvaluatedArgs []Value
 	Interpreter   *Interpreter
 	Previous      *Frame
+	CallSite      *SrcPos // position of call which created frame (nil if not known)
+	pos           *SrcPos // position of operator call being evaluated in frame
+}
+
+// forOtherGoroutine returns copy of frame for evaluation in other
+// goroutine (position of evaluation is kept in frame)
+func (fr *Frame) forOtherGoroutine() *Frame {
+	nFrame := *fr
+	return &nFrame
 }
 
 type fdebugInfo struct {
@@ -150,7 +214,7 @@ type fdebugInfo struct {
 // NewTopFrameWithInterpreter returns new top level frame
 func NewTopFrameWithInterpreter(interpreter *Interpreter) *Frame {
 	return &Frame{
-		Syms:        NewSymt(),
+		Syms:        NewSymtWithConverter(interpreter.SymIDs),
 		OtherNS:     make(map[SymID]ImportInfo),
 		Imported:    make(map[SymID]*Frame),
 		Interpreter: interpreter,
@@ -170,12 +234,17 @@ func (fr *Frame) SetInProcCall(v bool) {
 	fr.inProcCall = v
 }
 
+// IsInProcCall returns true if frame is in procedure call
+func (fr *Frame) IsInProcCall() bool {
+	return fr.inProcCall
+}
+
 // GetFuncDebugInfos gets function infos for backtrace
 func (fr *Frame) GetFuncDebugInfos(prev []fdebugInfo) []fdebugInfo {
 	fdeb := fdebugInfo{
 		function:  fr.FuncProto,
 		argvalues: fr.EvaluatedArgs,
-		syms:      fr.Syms,
+		syms:      fr.symsForDebug(),
 	}
 	if fr.AccessLink == nil {
 		return append(prev, fdeb)
@@ -195,6 +264,114 @@ func (fr *Frame) GetSymItemsOfImportedModule(modSid SymID) (*Symt, bool) {
 	return frame.Syms, true
 }
 
+// symsForDebug returns symbols of frame as symbol table
+func (fr *Frame) symsForDebug() *Symt {
+	syms := NewSymtWithConverter(fr.SymIDs())
+	fr.forEachSlot(func(sid SymID, v Value) bool {
+		syms.AddBySIDByOverwriteIfNeeded(sid, &Item{Type: ValueItem, Data: v})
+		return false
+	})
+	if fr.Syms != nil {
+		for sid, item := range fr.Syms.AsMap() {
+			syms.AddBySIDByOverwriteIfNeeded(sid, item)
+		}
+	}
+	return syms
+}
+
+// forEachSlot calls handler for argument and let definition slots
+// which have value (until handler returns true)
+func (fr *Frame) forEachSlot(handler func(SymID, Value) bool) {
+	if fr.slotNames != nil {
+		for index, sid := range fr.slotNames {
+			if handler(sid, fr.slots[index]) {
+				return
+			}
+		}
+		return
+	}
+	if fr.FuncProto == nil || len(fr.slots) == 0 {
+		return
+	}
+	for index, sid := range fr.FuncProto.ArgNames {
+		if sid == AnySymSid {
+			continue
+		}
+		if index < len(fr.slots) && fr.slots[index].Data != nil && handler(sid, fr.slots[index]) {
+			return
+		}
+	}
+	if fr.FuncProto.NSpace.Syms == nil {
+		return
+	}
+	base := len(fr.FuncProto.ArgNames)
+	for index, sid := range fr.FuncProto.NSpace.Syms.Keys() {
+		if base+index < len(fr.slots) && fr.slots[base+index].Data != nil && handler(sid, fr.slots[base+index]) {
+			return
+		}
+	}
+}
+
+// getSlotIndexes returns slot index of each argument and let definition
+// (first one if same name is used several times)
+func (f *Function) getSlotIndexes() map[SymID]int {
+	if indexes, ok := f.slotIndex.Load().(map[SymID]int); ok {
+		return indexes
+	}
+	indexes := make(map[SymID]int)
+	for index, sid := range f.ArgNames {
+		if _, found := indexes[sid]; !found && sid != AnySymSid {
+			indexes[sid] = index
+		}
+	}
+	if f.NSpace.Syms != nil {
+		base := len(f.ArgNames)
+		for index, sid := range f.NSpace.Syms.Keys() {
+			if _, found := indexes[sid]; !found {
+				indexes[sid] = base + index
+			}
+		}
+	}
+	f.slotIndex.Store(indexes)
+	return indexes
+}
+
+// getSlotValue finds symbol from arguments and let definitions of frame
+func (fr *Frame) getSlotValue(sid SymID) (Value, bool) {
+	if fr.slotNames != nil {
+		for index, slotSid := range fr.slotNames {
+			if slotSid == sid {
+				return fr.slots[index], true
+			}
+		}
+		return Value{}, false
+	}
+	if fr.FuncProto == nil || len(fr.slots) == 0 {
+		return Value{}, false
+	}
+	index, found := fr.FuncProto.getSlotIndexes()[sid]
+	if !found || index >= len(fr.slots) || fr.slots[index].Data == nil {
+		return Value{}, false
+	}
+	return fr.slots[index], true
+}
+
+// lookupSym finds symbol from scope, value is returned if it's found
+// from slots, otherwise item from symbol table
+func (fr *Frame) lookupSym(sid SymID) (Value, *Item, bool) {
+	for f := fr; f != nil; f = f.AccessLink {
+		if v, found := f.getSlotValue(sid); found {
+			return v, nil, true
+		}
+		if f.Syms != nil {
+			if item, found := f.Syms.GetBySID(sid); found {
+				return Value{}, item, true
+			}
+		}
+	}
+	return Value{}, nil, false
+}
+
 // GetImportedSymItem gets item from imported namespaces
 func (fr *Frame) GetImportedSymItem(modSid SymID, rest SymbolPath) (*Item, bool) {
 	frame, found := fr.Imported[modSid]
@@ -215,21 +392,29 @@ func (fr *Frame) GetImportedSymItem(modSid SymID, rest SymbolPath) (*Item, bool)
 
 // GetSymItem gets item ralated to symbol
 func (fr *Frame) GetSymItem(sid SymID) (*Item, bool) {
-	item, found := fr.Syms.GetBySID(sid)
-	if found {
-		return item, true
+	v, item, found := fr.lookupSym(sid)
+	if found && item == nil {
+		item = &Item{Type: ValueItem, Data: v}
 	}
-	if fr.AccessLink != nil {
-		return fr.AccessLink.GetSymItem(sid)
-	}
-	return nil, false
+	return item, found
 }
 
 func (fr *Frame) FindFuncSID(fptr *Function) (sid SymID, found bool) {
-	sid, found = fr.Syms.FindFuncSID(fptr)
+	fr.forEachSlot(func(slotSid SymID, v Value) bool {
+		if v.Kind == FunctionValue && v.Data.(FuncValue).FuncProto == fptr {
+			sid, found = slotSid, true
+		}
+		return found
+	})
 	if found {
 		return
 	}
+	if fr.Syms != nil {
+		sid, found = fr.Syms.FindFuncSID(fptr)
+		if found {
+			return
+		}
+	}
 	if fr.AccessLink != nil {
 		return fr.AccessLink.FindFuncSID(fptr)
 	}
@@ -248,17 +433,7 @@ func handleWhileOP(frame *Frame, operands []*Item) (retVal Value) {
 		runTimeError2(frame, "Wrong amount of arguments for %s (%d given)", opName, l)
 	}
 
-	// create new frame, which is copy of previous one
-	nFrame := Frame{}
-	nFrame.FuncProto = frame.FuncProto
-	nFrame.AccessLink = frame.AccessLink
-	nFrame.Syms = frame.Syms.MakeCopy()
-	nFrame.inProcCall = frame.inProcCall
-	nFrame.Imported = frame.Imported
-	// nFrame.Interpreter = frame.Interpreter , only in top frame
-	nFrame.Previous = frame.Previous
-
-	nextFrame := &nFrame
+	nextFrame := frame
 	for {
 		var argval Value
 		switch cond := operands[0]; cond.Type {
@@ -289,64 +464,153 @@ func handleWhileOP(frame *Frame, operands []*Item) (retVal Value) {
 			}
 		}
 
-		// then add arguments to symbol table
-		if len(evaluatedArgs) < len(nextFrame.FuncProto.ArgNames) {
-			runTimeError2(frame, "Not enough arguments for function call, need: %d, got:%d", len(evaluatedArgs), len(nextFrame.FuncProto.ArgNames))
-		}
-		for index, sid := range frame.FuncProto.ArgNames {
-			if (index + 0) >= len(evaluatedArgs) {
-				runTimeError2(frame, "Too short argument list in function call")
-			}
-			argitem := &Item{Type: ValueItem, Data: evaluatedArgs[index+0]}
-			if !nextFrame.Syms.AddBySIDByOverwriteIfNeeded(sid, argitem) {
-				runTimeError2(frame, "Argument add failed")
-			}
-			index++
-		}
+		// then add arguments to new frame (previous one may be
+		// referred by closures or fibers so it is not changed)
+		nextFrame = nextFrame.copyForWhile()
 		// put args also to separate slice so that those can be accessed by argslist -operator
 		nextFrame.EvaluatedArgs = evaluatedArgs
+		nextFrame.bindArgs(frame, evaluatedArgs)
 
 		// NOTE. Imports should remain
 
-		// lets fill let defintions to frame symbol table
+		// lets fill let defintions to frame
+		base := len(nextFrame.FuncProto.ArgNames)
 		symbolMap := nextFrame.FuncProto.NSpace.Syms.AsMap()
-		for _, sid := range nextFrame.FuncProto.NSpace.Syms.Keys() {
-			letitem := symbolMap[sid]
-			letvalitem := &Item{Type: ValueItem, Data: EvalItem(letitem, nextFrame)}
-			if !nextFrame.Syms.AddBySIDByOverwriteIfNeeded(sid, letvalitem) {
-				runTimeError2(frame, "Symbol add failed")
-			}
+		for index, sid := range nextFrame.FuncProto.NSpace.Syms.Keys() {
+			nextFrame.slots[base+index] = EvalItem(symbolMap[sid], nextFrame)
 		}
 	}
 }
 
+// copyForWhile returns copy of function frame for while round
+func (fr *Frame) copyForWhile() *Frame {
+	nFrame := *fr
+	nFrame.slots = make([]Value, len(fr.slots))
+	copy(nFrame.slots, fr.slots)
+	nFrame.Interpreter = nil // only in top frame
+	if fr.Syms != nil {
+		nFrame.Syms = fr.Syms.MakeCopy()
+	}
+	return &nFrame
+}
+
 //HandleCallOP for std lib usage
 func HandleCallOP(frame *Frame, operands []*Item) (retVal Value) {
 	return handleCallOP(frame, operands)
 }
 
 func handleCallOP(frame *Frame, operands []*Item) (retVal Value) {
+	return handleCallOPAt(frame, operands, nil)
+}
+
+// handleCallOPAt is call -operator with call site position
+func handleCallOPAt(frame *Frame, operands []*Item, callSite *SrcPos) (retVal Value) {
 	if l := len(operands); l == 0 {
 		runTimeError2(frame, "Empty argument list for call operator")
 	}
 	// lets first evaluate arguments
-	var evaluatedArgs []Value
+	evaluatedArgs := evalCallArgs(frame, operands)
+	retVal = callWithArgs(frame, evaluatedArgs, callSite)
+	return
+}
+
+// tailCall is returned instead of value when call -operator is
+// in tail position so that caller can reuse its Go stack frame
+type tailCall struct {
+	frame         *Frame
+	evaluatedArgs []Value
+	callSite      *SrcPos
+}
+
+func evalCallArgs(frame *Frame, operands []*Item) (evaluatedArgs []Value) {
 	for _, argitem := range operands {
 		if argitem.Type == ValueItem {
-			evaluatedArgs = append(evaluatedArgs, argitem.Data.(Value))
+			argval := argitem.Data.(Value)
+			if argval.Kind == FuncProtoValue {
+				fv := FuncValue{FuncProto: argval.Data.(*Function), AccessLink: frame}
+				argval = Value{Kind: FunctionValue, Data: fv}
+			}
+			evaluatedArgs = append(evaluatedArgs, argval)
 		} else {
 			evaluatedArgs = append(evaluatedArgs, EvalItem(argitem, frame))
 		}
 	}
+	return
+}
+
+// callWithArgs is trampoline for function calls: calls in tail position
+// of function body are not evaluated recursively but returned back here
+// and then called in loop (so that Go stack does not grow)
+func callWithArgs(frame *Frame, evaluatedArgs []Value, callSite *SrcPos) (retVal Value) {
+	if !frame.Settings().UseTreeWalker {
+		return vmCall(frame, evaluatedArgs, callSite)
+	}
+	callerFrame := frame
+	for {
+		nextFrame, extp, isExtProcCall := newCallFrame(callerFrame, evaluatedArgs, callSite)
+		if isExtProcCall {
+			retVal = extp.Impl(callerFrame, evaluatedArgs[1:])
+			return
+		}
+		evalLetDefs(callerFrame, nextFrame)
+		// backtrace continues from original caller (tail calls replace frames)
+		nextFrame.Previous = frame
+
+		var tc *tailCall
+		retVal, tc = evalTailItem(nextFrame.FuncProto.Body, nextFrame, true)
+		if tc == nil {
+			return
+		}
+		callerFrame, evaluatedArgs, callSite = tc.frame, tc.evaluatedArgs, tc.callSite
+	}
+}
+
+// evalTailItem evaluates item which is in tail position, call -operator
+// is not evaluated but returned as tailCall (if, case, cond and match
+// pass tail position to selected branch)
+func evalTailItem(item *Item, frame *Frame, evaluatingBody bool) (retVal Value, tc *tailCall) {
+	if item.Type != OperCallItem || item.Expand {
+		retVal = EvalItemV2(item, frame, &AddInfo{evaluatingBody: evaluatingBody})
+		return
+	}
+	opcall := item.Data.(OpCall)
+	if opcall.Src != nil {
+		frame.pos = opcall.Src
+	}
+	switch opcall.OperID {
+	case CallOP:
+		if l := len(opcall.Operands); l == 0 {
+			runTimeError2(frame, "Empty argument list for call operator")
+		}
+		tc = &tailCall{frame: frame, evaluatedArgs: evalCallArgs(frame, opcall.Operands), callSite: opcall.Src}
+		return
+	case IfOP:
+		return evalTailItem(selectIfBranch(frame, opcall.Operands), frame, false)
+	case CaseOP:
+		return evalTailItem(selectCaseBranch(frame, opcall.Operands), frame, false)
+	case CondOP:
+		return evalTailItem(selectCondBranch(frame, opcall.Operands), frame, false)
+	case MatchOP:
+		clauseFrame, resultItem := selectMatchClause(frame, opcall.Operands)
+		return evalTailItem(resultItem, clauseFrame, false)
+	}
+	retVal = EvalItemV2(item, frame, &AddInfo{evaluatingBody: evaluatingBody})
+	return
+}
+
+// newCallFrame creates frame for function call and adds arguments
+// to it (or returns external proc)
+func newCallFrame(frame *Frame, evaluatedArgs []Value, callSite *SrcPos) (*Frame, ExtProcType, bool) {
 	// create new frame
 	nextFrame := Frame{
-		Syms:     NewSymt(),
-		OtherNS:  nil, // TODO: needs to be something more...
-		Imported: make(map[SymID]*Frame),
+		OtherNS: nil, // TODO: needs to be something more...
+		// Imported is created when needed
 		// Interpreter: frame.Interpreter, only in top frame
 		Previous: frame,
+		CallSite: callSite,
 	}
 	isExtProcCall := false
+	var extProc ExtProcType
 	// lets take function from first argument
 	funcitem := evaluatedArgs[0]
 	switch funcitem.Kind {
@@ -358,6 +622,16 @@ func handleCallOP(frame *Frame, operands []*Item) (retVal Value) {
 		nextFrame.AccessLink = funcitem.Data.(FuncValue).AccessLink
 	case ExtProcValue:
 		isExtProcCall = true
+		extProc = funcitem.Data.(ExtProcType)
+		nextFrame.FuncProto = nil
+		nextFrame.AccessLink = frame
+	case OpaqueValue:
+		caller, ok := funcitem.Data.(OpaqueCaller)
+		if !ok {
+			runTimeError2(frame, "First argument for call is not function (%v)", funcitem)
+		}
+		isExtProcCall = true
+		extProc = caller.Callable()
 		nextFrame.FuncProto = nil
 		nextFrame.AccessLink = frame
 	default:
@@ -374,7 +648,7 @@ func handleCallOP(frame *Frame, operands []*Item) (retVal Value) {
 		}
 	} else {
 		if isExtProcCall {
-			if !funcitem.Data.(ExtProcType).IsFunction {
+			if !extProc.IsFunction {
 				runTimeError2(frame, "external proc call not allowed from function")
 			}
 		} else if nextFrame.FuncProto.IsProc {
@@ -383,54 +657,188 @@ func handleCallOP(frame *Frame, operands []*Item) (retVal Value) {
 	}
 
 	if isExtProcCall {
-		extp := funcitem.Data.(ExtProcType)
-		retVal = extp.Impl(frame, evaluatedArgs[1:])
+		return nil, extProc, true
+	}
+
+	// then add arguments to frame
+	argNames := nextFrame.FuncProto.ArgNames
+	nextFrame.slots = make([]Value, len(argNames)+len(nextFrame.FuncProto.NSpace.Syms.Keys()))
+	nextFrame.checkDups = nextFrame.needsDuplicateCheck()
+	if nextFrame.checkDups {
+		for index, sid := range argNames {
+			if sid == AnySymSid {
+				continue
+			}
+			// Overlapping symbol names not allowed (might cause variable -like effect)
+			_, symfound := nextFrame.GetSymItem(sid)
+			for _, prevSid := range argNames[:index] {
+				symfound = symfound || (prevSid == sid)
+			}
+			if symfound {
+				runTimeError2(frame, "Duplicate symbol name in scope not allowed (%s)", frame.SymIDs().AsString(sid))
+			}
+		}
+	}
+	// put args also to separate slice so that those can be accessed by argslist -operator
+	nextFrame.EvaluatedArgs = evaluatedArgs[1:]
+	// lets handle local imports
+	if len(nextFrame.FuncProto.NSpace.OtherNS) > 0 {
+		interpreter := frame.GetTopFrame().Interpreter
+		AddImportsToNamespace(&nextFrame.FuncProto.NSpace, &nextFrame, interpreter)
+		nextFrame.bindImports(&nextFrame.FuncProto.NSpace)
+	}
+	nextFrame.bindArgs(frame, evaluatedArgs[1:])
+	return &nextFrame, ExtProcType{}, false
+}
+
+// bindArgs puts argument values to argument slots of frame,
+// default values are evaluated in frame (preceding arguments are visible)
+func (fr *Frame) bindArgs(caller *Frame, args []Value) {
+	f := fr.FuncProto
+	params := f.params
+	if params == nil {
+		if len(args) < len(f.ArgNames) {
+			runTimeError2(caller, "Not enough arguments for function call, need: %d, got:%d", len(f.ArgNames), len(args))
+		}
+		if len(args) > len(f.ArgNames) && !f.variadic {
+			runTimeError2(caller, "Too many arguments for function call, need: %d, got:%d", len(f.ArgNames), len(args))
+		}
+		copy(fr.slots, args[:len(f.ArgNames)])
 		return
 	}
 
-	// then add arguments to symbol table
-	if len(evaluatedArgs) < len(nextFrame.FuncProto.ArgNames) {
-		runTimeError2(frame, "Not enough arguments for function call, need: %d, got:%d", len(evaluatedArgs), len(nextFrame.FuncProto.ArgNames))
+	// keyword arguments are given as map in last argument
+	// (only after all positional and optional arguments are given)
+	var kwMap *Value
+	if params.kwCount > 0 && len(args) > params.posCount && args[len(args)-1].Kind == MapValue {
+		kwMap = &args[len(args)-1]
+		args = args[:len(args)-1]
+	}
+	if len(args) < params.required {
+		runTimeError2(caller, "Not enough arguments for function call, need: %d, got:%d", params.required, len(args))
+	}
+	if len(args) > params.posCount && !params.hasRest && !f.variadic {
+		runTimeError2(caller, "Too many arguments for function call, need: %d, got:%d", params.posCount, len(args))
+	}
+	for index := 0; index < params.posCount; index++ {
+		if index < len(args) {
+			fr.slots[index] = args[index]
+		} else {
+			fr.slots[index] = EvalItem(params.defaults[index], fr)
+		}
 	}
-	for index, sid := range nextFrame.FuncProto.ArgNames {
-		if (index + 1) >= len(evaluatedArgs) {
-			runTimeError2(frame, "Too short argument list in function call")
+	kwIndex := params.posCount
+	if params.hasRest {
+		var restValues []Value
+		if len(args) > params.posCount {
+			restValues = args[params.posCount:]
 		}
-		argitem := &Item{Type: ValueItem, Data: evaluatedArgs[index+1]}
+		fr.slots[kwIndex] = MakeListOfValues(caller, restValues)
+		kwIndex++
+	}
+	if params.kwCount == 0 {
+		return
+	}
 
-		// Overlapping symbol names not allowed (might cause variable -like effect)
-		if _, symfound := nextFrame.GetSymItem(sid); symfound {
-			runTimeError2(frame, "Duplicate symbol name in scope not allowed (%s)", SymIDMap.AsString(sid))
+	for index := kwIndex; index < len(f.ArgNames); index++ {
+		kwName := f.symIDs().AsString(f.ArgNames[index])
+		if kwMap != nil {
+			if v, found := getFromStrKeyMap(caller, *kwMap, kwName); found {
+				fr.slots[index] = v
+				continue
+			}
+		}
+		if params.defaults[index] == nil {
+			runTimeError2(caller, "Keyword argument missing in function call (%s)", kwName)
+		}
+		fr.slots[index] = EvalItem(params.defaults[index], fr)
+	}
+	if kwMap == nil {
+		return
+	}
+	keys := NewListIterator(handleKeysOP(caller, []*Item{&Item{Type: ValueItem, Data: *kwMap}}))
+	for key := keys.Next(); key != nil; key = keys.Next() {
+		if !f.isKeywordArg(*key) {
+			runTimeError2(caller, "Unknown keyword argument in function call (%s)", *key)
 		}
+	}
+}
+
+// symIDs returns converter with which function was parsed
+func (f *Function) symIDs() *SymbolToIDConverter {
+	if f.NSpace.Syms == nil {
+		return SymIDMap
+	}
+	return f.NSpace.Syms.sidc
+}
 
-		if !nextFrame.Syms.AddBySID(sid, argitem) {
-			runTimeError2(frame, "Argument add failed")
+// isKeywordArg is true if given key is name of keyword argument
+func (f *Function) isKeywordArg(key Value) bool {
+	if key.Kind != StringValue {
+		return false
+	}
+	for _, sid := range f.ArgNames[len(f.ArgNames)-f.params.kwCount:] {
+		if f.symIDs().AsString(sid) == key.Data.(string) {
+			return true
 		}
-		index++
 	}
-	// put args also to separate slice so that those can be accessed by argslist -operator
-	nextFrame.EvaluatedArgs = evaluatedArgs[1:]
-	// lets handle local imports
-	interpreter := frame.GetTopFrame().Interpreter
-	AddImportsToNamespace(&nextFrame.FuncProto.NSpace, &nextFrame, interpreter)
+	return false
+}
 
-	// lets fill let defintions to frame symbol table
+// needsDuplicateCheck is false if resolver has checked that arguments
+// and let definitions of function do not overlap with symbols in scope
+// (and there are no symbols added by let -operator)
+func (fr *Frame) needsDuplicateCheck() bool {
+	if !fr.FuncProto.resolved || fr.FuncProto.hasDuplicates {
+		return true
+	}
+	return hasLetSymsInScope(fr.AccessLink)
+}
+
+// hasLetSymsInScope is true if let -operator has added symbols
+// to some frame in scope
+func hasLetSymsInScope(frame *Frame) bool {
+	for f := frame; f != nil; f = f.AccessLink {
+		if f.hasLetSyms {
+			return true
+		}
+	}
+	return false
+}
+
+// evalLetDefs fills let defintions to frame
+func evalLetDefs(frame *Frame, nextFrame *Frame) {
+	base := len(nextFrame.FuncProto.ArgNames)
 	symbolMap := nextFrame.FuncProto.NSpace.Syms.AsMap()
-	for _, sid := range nextFrame.FuncProto.NSpace.Syms.Keys() {
+	for index, sid := range nextFrame.FuncProto.NSpace.Syms.Keys() {
 		letitem := symbolMap[sid]
 
 		// Overlapping symbol names not allowed (might cause variable -like effect)
-		if _, symfound := nextFrame.GetSymItem(sid); symfound {
-			runTimeError2(frame, "Duplicate symbol name in scope not allowed (%s)", SymIDMap.AsString(sid))
+		if nextFrame.checkDups {
+			if _, symfound := nextFrame.GetSymItem(sid); symfound {
+				runTimeError2(frame, "Duplicate symbol name in scope not allowed (%s)", frame.SymIDs().AsString(sid))
+			}
 		}
 
 		// lets evaluate let def. to value first, using new frame already (as arguments are there)
-		letvalitem := &Item{Type: ValueItem, Data: EvalItem(letitem, &nextFrame)}
-		if !nextFrame.Syms.AddBySID(sid, letvalitem) {
-			runTimeError2(frame, "Symbol add failed")
-		}
+		nextFrame.slots[base+index] = EvalItem(letitem, nextFrame)
+	}
+}
+
+// callOperator calls operator implementation, runtime error raised
+// in it gets source position of operator call (kept in frame during call)
+func callOperator(frame *Frame, opcall OpCall, operands []*Item) (retVal Value) {
+	if opcall.Src == nil || frame == nil {
+		return operTbl[opcall.OperID](frame, operands)
 	}
-	retVal = EvalItemV2(nextFrame.FuncProto.Body, &nextFrame, &AddInfo{evaluatingBody: true})
+	prevPos := frame.pos
+	frame.pos = opcall.Src
+	if opcall.OperID == CallOP {
+		retVal = handleCallOPAt(frame, operands, opcall.Src)
+	} else {
+		retVal = operTbl[opcall.OperID](frame, operands)
+	}
+	frame.pos = prevPos
 	return
 }
 
@@ -453,19 +861,25 @@ func EvalItemV2(item *Item, frame *Frame, addInfo *AddInfo) (retVal Value) {
 			retVal = Value{Kind: FunctionValue, Data: fv}
 		}
 	case SymbolPathItem:
+		if item.ref != nil {
+			if v, found := item.ref.lookup(frame); found {
+				return v
+			}
+		}
 		sp := item.Data.(SymbolPath)
 		var symItem *Item
 		var symfound bool
 
 		if len(sp) == 1 {
-			symItem, symfound = frame.GetSymItem(sp[0])
+			var v Value
+			if v, symItem, symfound = frame.lookupSym(sp[0]); symfound && symItem == nil {
+				return v
+			}
 		} else if len(sp) > 1 {
 			symItem, symfound = frame.GetImportedSymItem(sp[0], sp[1:])
-		} else {
-			runTimeError2(frame, "symbol not found: %s", sp.ToString())
 		}
 		if !symfound {
-			runTimeError2(frame, "symbol not found: %s", sp.ToString())
+			runTimeErrorAt(frame, item.Src, "symbol not found: %s", sp.ToStringWith(frame.SymIDs()))
 		}
 		return EvalItem(symItem, frame)
 	case OperCallItem:
@@ -501,7 +915,7 @@ func EvalItemV2(item *Item, frame *Frame, addInfo *AddInfo) (retVal Value) {
 		}
 
 		if !item.Expand {
-			return operTbl[opcall.OperID](frame, newOperands)
+			return callOperator(frame, opcall, newOperands)
 		}
 		var expandedOperands []*Item
 		for idx, v := range newOperands {
@@ -522,7 +936,7 @@ func EvalItemV2(item *Item, frame *Frame, addInfo *AddInfo) (retVal Value) {
 				expandedOperands = append(expandedOperands, &Item{Type: ValueItem, Data: *nextv})
 			}
 		}
-		return operTbl[opcall.OperID](frame, expandedOperands)
+		return callOperator(frame, opcall, expandedOperands)
 	default:
 		runTimeError2(frame, "Data corrupted")
 	}
@@ -543,19 +957,25 @@ func EvalItem(item *Item, frame *Frame) (retVal Value) {
 			retVal = Value{Kind: FunctionValue, Data: fv}
 		}
 	case SymbolPathItem:
+		if item.ref != nil {
+			if v, found := item.ref.lookup(frame); found {
+				return v
+			}
+		}
 		sp := item.Data.(SymbolPath)
 		var symItem *Item
 		var symfound bool
 
 		if len(sp) == 1 {
-			symItem, symfound = frame.GetSymItem(sp[0])
+			var v Value
+			if v, symItem, symfound = frame.lookupSym(sp[0]); symfound && symItem == nil {
+				return v
+			}
 		} else if len(sp) > 1 {
 			symItem, symfound = frame.GetImportedSymItem(sp[0], sp[1:])
-		} else {
-			runTimeError2(frame, "symbol not found: %s", sp.ToString())
 		}
 		if !symfound {
-			runTimeError2(frame, "symbol not found: %s", sp.ToString())
+			runTimeErrorAt(frame, item.Src, "symbol not found: %s", sp.ToStringWith(frame.SymIDs()))
 		}
 		return EvalItem(symItem, frame)
 	case OperCallItem:
@@ -589,7 +1009,7 @@ func EvalItem(item *Item, frame *Frame) (retVal Value) {
 		}
 
 		if !item.Expand {
-			return operTbl[opcall.OperID](frame, newOperands)
+			return callOperator(frame, opcall, newOperands)
 		}
 		var expandedOperands []*Item
 		for idx, v := range newOperands {
@@ -610,7 +1030,7 @@ func EvalItem(item *Item, frame *Frame) (retVal Value) {
 				expandedOperands = append(expandedOperands, &Item{Type: ValueItem, Data: *nextv})
 			}
 		}
-		return operTbl[opcall.OperID](frame, expandedOperands)
+		return callOperator(frame, opcall, expandedOperands)
 	default:
 		runTimeError2(frame, "Data corrupted")
 	}
@@ -0,0 +1,223 @@
+package funl
+
+import (
+	"runtime/debug"
+	"strings"
+	"testing"
+)
+
+// evalTestSource parses source as module and calls given func/proc in it
+func evalTestSource(t *testing.T, source string, name string, args ...Value) Value {
+	return evalTestSourceWith(t, NewInterpreter(), source, name, args...)
+}
+
+// evalTestSourceWith evaluates function from source with given interpreter
+func evalTestSourceWith(t *testing.T, interpreter *Interpreter, source string, name string, args ...Value) Value {
+	interpreter.Importer = &fileImporter{}
+	return callInFrame(t, loadToInterpreter(t, interpreter, source), name, args...)
+}
+
+// interpreterWithMode returns interpreter which uses either tree walker or VM
+func interpreterWithMode(treeWalker bool) *Interpreter {
+	interpreter := NewInterpreter()
+	interpreter.Settings.UseTreeWalker = treeWalker
+	return interpreter
+}
+
+// limits Go stack so that recursion consuming stack would fail
+func withSmallStack(t *testing.T, testf func()) {
+	prevMax := debug.SetMaxStack(2 * 1024 * 1024)
+	defer debug.SetMaxStack(prevMax)
+	testf()
+}
+
+const tailCallTestSrc = `
+ns tctest
+
+count-down = func(n acc)
+	if( eq(n 0)
+		acc
+		call(count-down minus(n 1) plus(acc 1))
+	)
+end
+
+count-down-proc = proc(n acc)
+	next = minus(n 1)
+	case( n
+		0 acc
+		call(count-down-proc next plus(acc 2))
+	)
+end
+
+is-even = func(n)
+	cond(
+		eq(n 0) true
+		call(is-odd minus(n 1))
+	)
+end
+
+is-odd = func(n)
+	cond(
+		eq(n 0) false
+		call(is-even minus(n 1))
+	)
+end
+
+mutual = func(n)
+	ev = func(x) if(eq(x 0) true call(od minus(x 1))) end
+	od = func(x) if(eq(x 0) false call(ev minus(x 1))) end
+	call(ev n)
+end
+
+body-call = proc(n)
+	call(proc(x) if(eq(x 0) 'done' call(body-call x)) end minus(n 1))
+end
+
+endns
+`
+
+func TestTailCallInFunc(t *testing.T) {
+	withSmallStack(t, func() {
+		result := evalTestSource(t, tailCallTestSrc, "count-down", Value{Kind: IntValue, Data: 1000000}, Value{Kind: IntValue, Data: 0})
+		if result.Kind != IntValue || result.Data.(int) != 1000000 {
+			t.Fatalf("unexpected result: %v", result)
+		}
+	})
+}
+
+func TestTailCallInProc(t *testing.T) {
+	withSmallStack(t, func() {
+		result := evalTestSource(t, tailCallTestSrc, "count-down-proc", Value{Kind: IntValue, Data: 1000000}, Value{Kind: IntValue, Data: 0})
+		if result.Kind != IntValue || result.Data.(int) != 2000000 {
+			t.Fatalf("unexpected result: %v", result)
+		}
+	})
+}
+
+func TestTailCallMutualRecursion(t *testing.T) {
+	withSmallStack(t, func() {
+		result := evalTestSource(t, tailCallTestSrc, "is-even", Value{Kind: IntValue, Data: 1000001})
+		if result.Kind != BoolValue || result.Data.(bool) != false {
+			t.Fatalf("unexpected result: %v", result)
+		}
+		result = evalTestSource(t, tailCallTestSrc, "mutual", Value{Kind: IntValue, Data: 1000000})
+		if result.Kind != BoolValue || result.Data.(bool) != true {
+			t.Fatalf("unexpected result: %v", result)
+		}
+	})
+}
+
+func TestTailCallAsBodyCall(t *testing.T) {
+	withSmallStack(t, func() {
+		result := evalTestSource(t, tailCallTestSrc, "body-call", Value{Kind: IntValue, Data: 1000000})
+		if result.Kind != StringValue || result.Data.(string) != "done" {
+			t.Fatalf("unexpected result: %v", result)
+		}
+	})
+}
+
+func TestTailCallProcFromFunc(t *testing.T) {
+	src := `
+ns tctest
+pr = proc() 'proc' end
+fu = func() call(pr) end
+endns
+`
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatalf("proc call from func should fail")
+		}
+	}()
+	evalTestSource(t, src, "fu")
+}
+
+const rteLocationTestSrc = `
+ns rtetest
+
+getter = func(m)
+	get(m 'nokey')
+end
+
+not-bool = func(x)
+	if(x 1 2)
+end
+
+nested = func()
+	plus(1 call(getter map()))
+end
+
+tail = func(x)
+	call(not-bool x)
+end
+
+unknown = func()
+	list(1 nosuchsym)
+end
+
+user-error = func()
+	error('user defined')
+end
+
+endns
+`
+
+func TestRuntimeErrorLocation(t *testing.T) {
+	var treeWalker bool
+	errorText := func(name string, args ...Value) (text string) {
+		defer func() {
+			if r := recover(); r != nil {
+				text = r.(*RuntimeError).LocatedText()
+			}
+		}()
+		evalTestSourceWith(t, interpreterWithMode(treeWalker), rteLocationTestSrc, name, args...)
+		return
+	}
+
+	for _, treeWalker = range []bool{false, true} {
+		cases := []struct {
+			text     string
+			expected string
+		}{
+			{errorText("nested"), "get: key not found (nokey) at 5:2"},
+			{errorText("tail", Value{Kind: IntValue, Data: 1}), "if: condition should be boolean expression at 9:2"},
+			{errorText("unknown"), "symbol not found: nosuchsym at 21:9"},
+			{errorText("user-error"), "user defined"},
+		}
+		for _, c := range cases {
+			if c.text != c.expected {
+				t.Fatalf("unexpected error (tree walker: %v): %s", treeWalker, c.text)
+			}
+		}
+	}
+}
+
+func panickingExtProc(frame *Frame, arguments []Value) Value {
+	panic("not runtime error")
+}
+
+func TestGoPanicIsNotRecoveredInCalls(t *testing.T) {
+	src := `
+ns panictest
+caller = func(f)
+	plus(1 call(f))
+end
+endns
+`
+	extProc := Value{Kind: ExtProcValue, Data: ExtProcType{Impl: panickingExtProc, IsFunction: true}}
+	for _, treeWalker := range []bool{false, true} {
+		func() {
+			defer func() {
+				r := recover()
+				if r != "not runtime error" {
+					t.Fatalf("unexpected panic value: %v", r)
+				}
+				// panic is not recovered and raised again on the way
+				stack := string(debug.Stack())
+				if !strings.Contains(stack, "panickingExtProc") || strings.Count(stack, "\npanic(") != 1 {
+					t.Errorf("panic re-raised (tree walker: %v): %s", treeWalker, stack)
+				}
+			}()
+			evalTestSourceWith(t, interpreterWithMode(treeWalker), src, "caller", extProc)
+		}()
+	}
+}
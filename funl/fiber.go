@@ -123,6 +123,64 @@ func handleTryOP(frame *Frame, operands []*Item) (retVal Value) {
 	return
 }
 
+func handleTryeOP(frame *Frame, operands []*Item) (retVal Value) {
+	opName := "trye"
+
+	if !frame.inProcCall {
+		runTimeError2(frame, "%s not allowed in function", opName)
+	}
+	if len(operands) == 0 {
+		runTimeError2(frame, "Wrong amount of arguments for %s (need at least one)", opName)
+	}
+
+	// error kinds to be caught (all if none given)
+	var kinds []string
+	for _, v := range operands[1:] {
+		kindVal := EvalItem(v, frame)
+		if kindVal.Kind != StringValue {
+			runTimeError2(frame, "%s: error kind should be string", opName)
+		}
+		kinds = append(kinds, kindVal.Data.(string))
+	}
+	isCaught := func(rte *RuntimeError) bool {
+		if len(kinds) == 0 {
+			return true
+		}
+		for _, kind := range kinds {
+			if rte.Kind == kind {
+				return true
+			}
+		}
+		return false
+	}
+
+	var rte *RuntimeError
+	val := func() (val Value) {
+		defer func() {
+			if r := recover(); r != nil {
+				rte = asRuntimeError(r)
+				if !isCaught(rte) {
+					panic(r)
+				}
+			}
+		}()
+		return EvalItem(operands[0], frame)
+	}()
+
+	values := []Value{
+		{Kind: BoolValue, Data: true},
+		{Kind: StringValue, Data: ""},
+		val,
+	}
+	if rte != nil {
+		values[0] = Value{Kind: BoolValue, Data: false}
+		values[1] = rte.ErrorValue(frame)
+		values[2] = Value{Kind: StringValue, Data: ""}
+	}
+	retVal = MakeListOfValues(frame, values)
+	return
+}
+
 func handleSpawnOP(frame *Frame, operands []*Item) (retVal Value) {
 	opName := "spawn"
 
@@ -131,13 +189,14 @@ func handleSpawnOP(frame *Frame, operands []*Item) (retVal Value) {
 	}
 
 	for _, operand := range operands {
+		fiberFrame := frame.forOtherGoroutine()
 		go func(it *Item) {
 			isFailure := false
 			defer func() {
 				if isFailure {
 					var rteText string
 					if r := recover(); r != nil {
-						rteText = r.(error).Error()
+						rteText = asRuntimeError(r).LocatedText()
 					}
 					fmt.Println()
 					fmt.Println("Fiber died, Runtime error: ", rteText)
@@ -147,7 +206,7 @@ func handleSpawnOP(frame *Frame, operands []*Item) (retVal Value) {
 				}
 			}()
 			isFailure = true
-			EvalItem(it, frame)
+			EvalItem(it, fiberFrame)
 			isFailure = false
 		}(operand)
 	}
@@ -14,7 +14,7 @@ type FNIHandler struct {
 }
 
 func (fni *FNIHandler) RegExtProc(extProc ExtProcType, extProcName string) (err error) {
-	nsSid := SymIDMap.Add(extProcName)
+	nsSid := fni.topFrame.Interpreter.SymIDs.Add(extProcName)
 	fni.topFrame.Interpreter.NsDir.Put(nsSid, fni.topFrame)
 
 	epVal := Value{Kind: ExtProcValue, Data: extProc}
@@ -40,7 +40,7 @@ func SetupExtModule(targetPath string, interpreter *Interpreter) (topFrame *Fram
 	setupHandler := v.(func(FNIApi) error)
 
 	topFrame = &Frame{
-		Syms:        NewSymt(),
+		Syms:        NewSymtWithConverter(interpreter.SymIDs),
 		OtherNS:     make(map[SymID]ImportInfo),
 		Imported:    make(map[SymID]*Frame),
 		Interpreter: interpreter,
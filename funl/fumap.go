@@ -5,23 +5,75 @@ import (
 	"encoding/binary"
 	"fmt"
 	"hash/fnv"
+	"math/big"
 
 	"github.com/anssihalmeaho/funl/pmap"
+	"github.com/anssihalmeaho/funl/pvec"
 )
 
-//PMap is persistent map
+// UseHAMTMaps selects HAMT instead of red-black tree as backend for new maps
+// (default for interpreter setting)
+var UseHAMTMaps bool
+
+//PMap is persistent map, either Rbm or Hamt is used as backend
 type PMap struct {
 	Rbm       *pmap.RBMap
+	Hamt      *pmap.HAMT
 	itemCount int
-	delCount  int
+	sorted    bool // keys are iterated in sorted order (setting of interpreter)
 }
 
-func areEqualMaps(frame *Frame, m1, m2 *PMap) bool {
-	/*	NOTE. this check is removed as deletion marking makes it invalid
-		if m1.Rbm == m2.Rbm {
-			return true
+// newPMap returns empty map, backend and iteration order are taken
+// from settings of interpreter to which frame belongs
+func newPMap(frame *Frame) *PMap {
+	settings := frame.Settings()
+	if settings.UseHAMTMaps {
+		return &PMap{Hamt: pmap.NewHAMTWithHandler(&NodeHandler{}), sorted: settings.SortedMapIteration}
+	}
+	return &PMap{Rbm: pmap.NewRBMapWithHandler(&NodeHandler{}), sorted: settings.SortedMapIteration}
+}
+
+func (pm *PMap) get(key pmap.MKey) (pmap.MValue, bool) {
+	if pm.Hamt != nil {
+		return pm.Hamt.Get(key)
+	}
+	return pm.Rbm.Get(key)
+}
+
+func (pm *PMap) put(key pmap.MKey, val pmap.MValue) *PMap {
+	if pm.Hamt != nil {
+		return &PMap{Hamt: pm.Hamt.Put(key, val), itemCount: pm.itemCount + 1, sorted: pm.sorted}
+	}
+	return &PMap{Rbm: pm.Rbm.Put(key, val), itemCount: pm.itemCount + 1, sorted: pm.sorted}
+}
+
+func (pm *PMap) del(key pmap.MKey, val pmap.MValue) (*PMap, bool) {
+	if pm.Hamt != nil {
+		newmap, found := pm.Hamt.Delete(key, val)
+		if !found {
+			return pm, false
 		}
-	*/
+		return &PMap{Hamt: newmap, itemCount: pm.itemCount - 1, sorted: pm.sorted}, true
+	}
+	newmap, found := pm.Rbm.Delete(key, val)
+	if !found {
+		return pm, false
+	}
+	return &PMap{Rbm: newmap, itemCount: pm.itemCount - 1, sorted: pm.sorted}, true
+}
+
+func (pm *PMap) visitAll(visitor func(*pmap.Node)) {
+	if pm.Hamt != nil {
+		pm.Hamt.VisitAll(visitor)
+		return
+	}
+	pm.Rbm.VisitAll(visitor)
+}
+
+func areEqualMaps(frame *Frame, m1, m2 *PMap) bool {
+	if m1.Rbm == m2.Rbm && m1.Hamt == m2.Hamt {
+		return true
+	}
 	v1 := &Item{Type: ValueItem, Data: Value{Kind: MapValue, Data: m1}}
 	v2 := &Item{Type: ValueItem, Data: Value{Kind: MapValue, Data: m2}}
 	vl1 := handleLenOP(nil, []*Item{v1})
@@ -43,14 +95,11 @@ func areEqualMaps(frame *Frame, m1, m2 *PMap) bool {
 	kvm2 := make(map[pmap.MKey]pmap.MValue)
 	getVisitor := func(kvm *map[pmap.MKey]pmap.MValue) func(node *pmap.Node) {
 		return func(node *pmap.Node) {
-			if node.Val.(NodeValue).Deleted {
-				return
-			}
 			(*kvm)[node.Key] = node.Val
 		}
 	}
-	m1.Rbm.VisitAll(getVisitor(&kvm1))
-	m2.Rbm.VisitAll(getVisitor(&kvm2))
+	m1.visitAll(getVisitor(&kvm1))
+	m2.visitAll(getVisitor(&kvm2))
 
 	equalValues := func(kval1, kval2 KeyVal) bool {
 		keyitem1 := &Item{Type: ValueItem, Data: kval1.Key}
@@ -112,25 +161,12 @@ func areEqualMaps(frame *Frame, m1, m2 *PMap) bool {
 
 func (pm PMap) String() string {
 	s := "map("
-	first := true
-	visitor := func(node *pmap.Node) {
-		item, ok := node.Val.(NodeValue)
-		if !ok {
-			runTimeError("not abe to convert map item")
-		}
-		if item.Deleted {
-			return
-		}
-		if !first {
+	for i, kv := range mapKeyVals(&pm) {
+		if i > 0 {
 			s += ", "
 		}
-		first = false
-		s += fmt.Sprintf("%#v : %#v", item.Val.Key, item.Val.Val)
-		for _, nextitem := range item.SameKeyValues {
-			s += fmt.Sprintf("%#v : %#v, ", nextitem.Key, nextitem.Val)
-		}
+		s += fmt.Sprintf("%#v : %#v", kv.Key, kv.Val)
 	}
-	pm.Rbm.VisitAll(visitor)
 	return s + ")"
 }
 
@@ -146,7 +182,6 @@ type KeyVal struct {
 type NodeValue struct {
 	Val           KeyVal
 	SameKeyValues []KeyVal
-	Deleted       bool
 }
 
 func NewNodeValue(key, val Value) NodeValue {
@@ -163,52 +198,48 @@ func isEqualItems(item1, item2 *Item) bool {
 	return eqResult.Data.(bool)
 }
 
-func (nh *NodeHandler) MarkDeletion(srcNode *pmap.Node, key pmap.MKey, actualKey pmap.MValue) (trgNode *pmap.Node, keyFound bool) {
+func (nh *NodeHandler) HandleDeletion(srcNode *pmap.Node, key pmap.MKey, actualKey pmap.MValue) (trgNode *pmap.Node, keyFound bool) {
 	nval, convok := srcNode.Val.(NodeValue)
 	if !convok {
 		runTimeError("invalid value (%v)", srcNode.Val)
 	}
 
-	if !nval.Deleted {
-		keyInNode := &Item{Type: ValueItem, Data: srcNode.Val.(NodeValue).Val.Key}
-		actualKeyItem := &Item{Type: ValueItem, Data: actualKey.(Value)}
-		samekeysLen := len(nval.SameKeyValues)
+	keyInNode := &Item{Type: ValueItem, Data: nval.Val.Key}
+	actualKeyItem := &Item{Type: ValueItem, Data: actualKey.(Value)}
+	samekeysLen := len(nval.SameKeyValues)
 
-		// case when there is only one value there -> can be marked as deleted
-		if samekeysLen == 0 {
-			if isEqualItems(keyInNode, actualKeyItem) {
-				nval.Deleted = true
-				keyFound = true
-			}
-		} else {
-			// so there are several values, there remain at least one so it cannot be marked as deleted
-			// NOTE. this should be very rare case
-
-			// lets first check if its .Val
-			// it was .Val, so lets move last of .SameKeyValues to .Val
-			if isEqualItems(keyInNode, actualKeyItem) {
-				// note. we knwo taht slice is not empty, so it wont panic
-				moved := nval.SameKeyValues[samekeysLen-1]
-				nval.SameKeyValues = nval.SameKeyValues[:samekeysLen-1]
-				nval.Val = moved
+	// case when there is only one value there -> whole node can be removed
+	if samekeysLen == 0 {
+		keyFound = isEqualItems(keyInNode, actualKeyItem)
+		return
+	}
+
+	// so there are several values, there remain at least one so node is kept
+	// NOTE. this should be very rare case
+
+	// lets first check if its .Val
+	// it was .Val, so lets move last of .SameKeyValues to .Val
+	if isEqualItems(keyInNode, actualKeyItem) {
+		// note. we know that slice is not empty, so it wont panic
+		moved := nval.SameKeyValues[samekeysLen-1]
+		nval.SameKeyValues = nval.SameKeyValues[:samekeysLen-1:samekeysLen-1]
+		nval.Val = moved
+		keyFound = true
+	} else {
+		// lets loop if any of .SameKeyValues equal to given key
+		var newSameKVs []KeyVal
+		for idx := range nval.SameKeyValues {
+			keyItem := &Item{Type: ValueItem, Data: nval.SameKeyValues[idx].Key}
+			if isEqualItems(keyItem, actualKeyItem) {
 				keyFound = true
 			} else {
-				// lets loop if any of .SameKeyValues equal to given key
-				var newSameKVs []KeyVal
-				for idx := range nval.SameKeyValues {
-					keyItem := &Item{Type: ValueItem, Data: nval.SameKeyValues[idx].Key}
-					if isEqualItems(keyItem, actualKeyItem) {
-						keyFound = true
-						break // assuming key is unique
-					} else {
-						newSameKVs = append(newSameKVs, nval.SameKeyValues[idx])
-					}
-				}
-				if keyFound {
-					nval.SameKeyValues = newSameKVs
-				}
+				newSameKVs = append(newSameKVs, nval.SameKeyValues[idx])
 			}
 		}
+		if !keyFound {
+			return
+		}
+		nval.SameKeyValues = newSameKVs
 	}
 	trgNode = &pmap.Node{
 		Left:  srcNode.Left,
@@ -243,22 +274,6 @@ func (nh *NodeHandler) HandleSameKey(srcNode *pmap.Node, key pmap.MKey, val pmap
 		Data: newKeyVal.Key,
 	}
 
-	// lets first check if this is marked as deleted and could be reused
-	if srcNode.Val.(NodeValue).Deleted {
-		// just sanity checking
-		if l := len(srcNode.Val.(NodeValue).SameKeyValues); l != 0 {
-			runTimeError("same key value list should be empty when marked as deleted (len=%d)", l)
-		}
-
-		newNV := NodeValue{
-			Val:           val.(NodeValue).Val,
-			SameKeyValues: []KeyVal{},
-			Deleted:       false,
-		}
-		trgNode.Val = newNV
-		return
-	}
-
 	// lets check if there is same value by using eq -operator
 	for _, v := range keyvalues {
 		argsForEq := []*Item{
@@ -323,10 +338,9 @@ func HandleMapOP(frame *Frame, operands []*Item) (retVal Value) {
 func handleMapOP(frame *Frame, operands []*Item) (retVal Value) {
 	opName := "map"
 	retVal.Kind = MapValue
-	rbmap := pmap.NewRBMapWithHandler(&NodeHandler{})
 	argCount := len(operands)
 	if argCount == 0 {
-		retVal.Data = &PMap{Rbm: rbmap}
+		retVal.Data = newPMap(frame)
 		return
 	}
 
@@ -334,7 +348,7 @@ func handleMapOP(frame *Frame, operands []*Item) (retVal Value) {
 		runTimeError2(frame, "%s: uneven amount of arguments (%d)", opName, argCount)
 	}
 	var mapval Value
-	mapval = Value{Kind: MapValue, Data: &PMap{Rbm: rbmap}}
+	mapval = Value{Kind: MapValue, Data: newPMap(frame)}
 	for i := 0; i < argCount; i += 2 {
 		// evaluate key
 		v := operands[i]
@@ -425,20 +439,20 @@ func getHashedFloat(from float64) int {
 	return int(hash.Sum64())
 }
 
-func getHashedList(from *List) int {
+func getHashedList(frame *Frame, from *List) int {
 	buf := new(bytes.Buffer)
 	lit := NewListIterator(Value{Kind: ListValue, Data: from})
 	if lit == nil {
-		runTimeError("Unable to hash list")
+		runTimeError2(frame, "Unable to hash list")
 	}
 	for {
 		nextitem := lit.Next()
 		if nextitem == nil {
 			break
 		}
-		hashedKey, err := hashOfValue(*nextitem)
+		hashedKey, err := hashOfValue(frame, *nextitem)
 		if err != nil {
-			runTimeError("illegal type for map key")
+			runTimeError2(frame, "illegal type for map key")
 		}
 
 		int64v := int64(hashedKey)
@@ -452,18 +466,35 @@ func getHashedList(from *List) int {
 	return int(hash.Sum64())
 }
 
-func hashOfValue(keyVal Value) (hashedKey int, err error) {
+func hashOfValue(frame *Frame, keyVal Value) (hashedKey int, err error) {
 	switch keyVal.Kind {
 	case IntValue:
 		hashedKey = getHashedInt(keyVal.Data.(int))
+	case BigIntValue:
+		hashedKey = getHashedBigInt(keyVal.Data.(*big.Int))
+	case DecimalValue:
+		hashedKey = getHashedDecimal(keyVal.Data.(*big.Rat))
+	case RecordValue:
+		hashedKey = getHashedRecord(frame, keyVal.Data.(*Record))
+	case VectorValue:
+		hashedKey = getHashedVector(frame, keyVal.Data.(*pvec.Vector))
+	case SetValue:
+		hashedKey = getHashedSet(frame, keyVal.Data.(*Set))
 	case StringValue:
 		hashedKey = getHashedString(keyVal.Data.(string))
 	case FloatValue:
 		hashedKey = getHashedFloat(keyVal.Data.(float64))
 	case ListValue:
-		hashedKey = getHashedList(keyVal.Data.(*List))
+		hashedKey = getHashedList(frame, keyVal.Data.(*List))
 	case BoolValue:
 		hashedKey = getHashedBool(keyVal.Data.(bool))
+	case OpaqueValue:
+		hasher, ok := keyVal.Data.(OpaqueHasher)
+		if !ok {
+			err = fmt.Errorf("illegal type for map key")
+			return
+		}
+		hashedKey = getHashedInt(hasher.Hash())
 	default:
 		err = fmt.Errorf("illegal type for map key")
 	}
@@ -485,6 +516,10 @@ func handleKeysOP(frame *Frame, operands []*Item) (retVal Value) {
 	default:
 		runTimeError2(frame, "something wrong (%s)", opName)
 	}
+	mapVal = recordFields(mapVal)
+	if mapVal.Kind == SetValue {
+		mapVal = mapVal.Data.(*Set).items
+	}
 
 	if mapVal.Kind != MapValue {
 		runTimeError2(frame, "First argument not map in %s operator", opName)
@@ -495,19 +530,9 @@ func handleKeysOP(frame *Frame, operands []*Item) (retVal Value) {
 	}
 
 	var keyItems []*Item
-	visitor := func(node *pmap.Node) {
-		item, ok := node.Val.(NodeValue)
-		if !ok {
-			runTimeError2(frame, "%s: not able to convert map item", opName)
-		}
-		if !item.Deleted {
-			keyItems = append(keyItems, &Item{Type: ValueItem, Data: item.Val.Key})
-			for _, nextitem := range item.SameKeyValues {
-				keyItems = append(keyItems, &Item{Type: ValueItem, Data: nextitem.Key})
-			}
-		}
+	for _, kv := range mapKeyVals(mapv) {
+		keyItems = append(keyItems, &Item{Type: ValueItem, Data: kv.Key})
 	}
-	mapv.Rbm.VisitAll(visitor)
 	retVal = handleListOP(frame, keyItems)
 	return
 }
@@ -527,6 +552,7 @@ func handleValsOP(frame *Frame, operands []*Item) (retVal Value) {
 	default:
 		runTimeError2(frame, "something wrong (%s)", opName)
 	}
+	mapVal = recordFields(mapVal)
 
 	if mapVal.Kind != MapValue {
 		runTimeError2(frame, "First argument not map in %s operator", opName)
@@ -537,23 +563,35 @@ func handleValsOP(frame *Frame, operands []*Item) (retVal Value) {
 	}
 
 	var keyItems []*Item
-	visitor := func(node *pmap.Node) {
-		item, ok := node.Val.(NodeValue)
-		if !ok {
-			runTimeError2(frame, "%s: not able to convert map item", opName)
-		}
-		if !item.Deleted {
-			keyItems = append(keyItems, &Item{Type: ValueItem, Data: item.Val.Val})
-			for _, nextitem := range item.SameKeyValues {
-				keyItems = append(keyItems, &Item{Type: ValueItem, Data: nextitem.Val})
-			}
-		}
+	for _, kv := range mapKeyVals(mapv) {
+		keyItems = append(keyItems, &Item{Type: ValueItem, Data: kv.Val})
 	}
-	mapv.Rbm.VisitAll(visitor)
 	retVal = handleListOP(frame, keyItems)
 	return
 }
 
+// MapGet returns value for key in map (for std usage)
+func MapGet(frame *Frame, mapVal Value, keyVal Value) (Value, bool) {
+	if mapVal.Kind != MapValue {
+		runTimeError2(frame, "map get requires map")
+	}
+	hashedKey, err := hashOfValue(frame, keyVal)
+	if err != nil {
+		runTimeError2(frame, "illegal type for map key")
+	}
+	nodeval, found := mapVal.Data.(*PMap).get(pmap.MKey(hashedKey))
+	if !found {
+		return Value{}, false
+	}
+	nval := nodeval.(NodeValue)
+	return getMatchingValue(&nval, keyVal)
+}
+
+// MapDel returns map without key and true if key was found (for std usage)
+func MapDel(frame *Frame, mapVal Value, keyVal Value) (Value, bool) {
+	return delCommon(false, "del", frame, []*Item{&Item{Type: ValueItem, Data: mapVal}, &Item{Type: ValueItem, Data: keyVal}})
+}
+
 //HandleKeyvalsOP is for std usage
 func HandleKeyvalsOP(frame *Frame, operands []*Item) (retVal Value) {
 	return handleKeyvalsOP(frame, operands)
@@ -574,6 +612,7 @@ func handleKeyvalsOP(frame *Frame, operands []*Item) (retVal Value) {
 	default:
 		runTimeError2(frame, "something wrong (%s)", opName)
 	}
+	mapVal = recordFields(mapVal)
 
 	if mapVal.Kind != MapValue {
 		runTimeError2(frame, "First argument not map in %s operator", opName)
@@ -584,27 +623,13 @@ func handleKeyvalsOP(frame *Frame, operands []*Item) (retVal Value) {
 	}
 
 	var keyItems []*Item
-	visitor := func(node *pmap.Node) {
-		item, ok := node.Val.(NodeValue)
-		if !ok {
-			runTimeError2(frame, "%s: not able to convert map item", opName)
-		}
-		if !item.Deleted {
-			kvpair := []*Item{
-				&Item{Type: ValueItem, Data: item.Val.Key},
-				&Item{Type: ValueItem, Data: item.Val.Val},
-			}
-			keyItems = append(keyItems, &Item{Type: ValueItem, Data: handleListOP(frame, kvpair)})
-			for _, nextitem := range item.SameKeyValues {
-				kvpair := []*Item{
-					&Item{Type: ValueItem, Data: nextitem.Key},
-					&Item{Type: ValueItem, Data: nextitem.Val},
-				}
-				keyItems = append(keyItems, &Item{Type: ValueItem, Data: handleListOP(frame, kvpair)})
-			}
+	for _, kv := range mapKeyVals(mapv) {
+		kvpair := []*Item{
+			&Item{Type: ValueItem, Data: kv.Key},
+			&Item{Type: ValueItem, Data: kv.Val},
 		}
+		keyItems = append(keyItems, &Item{Type: ValueItem, Data: handleListOP(frame, kvpair)})
 	}
-	mapv.Rbm.VisitAll(visitor)
 	retVal = handleListOP(frame, keyItems)
 	return
 }
@@ -644,6 +669,10 @@ func commonGetOP(opName string, argCount int, isGetl bool, frame *Frame, operand
 	default:
 		runTimeError2(frame, "something wrong (%s)", opName)
 	}
+	mapVal = recordFields(mapVal)
+	if mapVal.Kind == OpaqueValue {
+		return getFromOpaque(opName, argCount, isGetl, frame, mapVal, operands)
+	}
 
 	if mapVal.Kind != MapValue {
 		runTimeError2(frame, "First argument not map in %s operator", opName)
@@ -663,22 +692,12 @@ func commonGetOP(opName string, argCount int, isGetl bool, frame *Frame, operand
 		runTimeError2(frame, "something wrong (%s)", opName)
 	}
 
-	hashedKey, err := hashOfValue(keyVal)
+	hashedKey, err := hashOfValue(frame, keyVal)
 	if err != nil {
 		runTimeError2(frame, "%s: illegal type for map key", opName)
 	}
 
-	nodeval, found := mapv.Rbm.Get(pmap.MKey(hashedKey))
-
-	if found {
-		nval, convok := nodeval.(NodeValue)
-		if !convok {
-			runTimeError2(frame, "%s: invalid value (%v)", opName, keyVal.Data)
-		}
-		if nval.Deleted {
-			found = false
-		}
-	}
+	nodeval, found := mapv.get(pmap.MKey(hashedKey))
 
 	if !found {
 		if isGetl {
@@ -816,9 +835,6 @@ func handleDelOP(frame *Frame, operands []*Item) (retVal Value) {
 	return
 }
 
-// DeletionsLimit is limit for amount of items marked as deleted in map, before making new map
-const DeletionsLimit = 1000
-
 func delCommon(rteIfNotExist bool, opName string, frame *Frame, operands []*Item) (retVal Value, keyFound bool) {
 	retVal.Kind = MapValue
 
@@ -858,58 +874,15 @@ func delCommon(rteIfNotExist bool, opName string, frame *Frame, operands []*Item
 		runTimeError2(frame, "empty map for %s operator", opName)
 	}
 
-	hashedKey, err := hashOfValue(keyVal)
+	hashedKey, err := hashOfValue(frame, keyVal)
 	if err != nil {
 		runTimeError2(frame, "%s: illegal type for map key", opName)
 	}
 
-	newmap, itemFound := mapv.Rbm.Modify(pmap.MKey(hashedKey), keyVal)
-	var newCount int
-	var newDelCount int
-	if itemFound {
-		newCount = mapv.itemCount - 1
-		newDelCount = mapv.delCount + 1
-	} else {
-		if rteIfNotExist {
-			runTimeError2(frame, "%s: key not found", opName)
-		}
-		newCount = mapv.itemCount
-		newDelCount = mapv.delCount
-	}
-
-	newMapVal := &PMap{Rbm: newmap, itemCount: newCount, delCount: newDelCount}
-
-	// lets check if there are so many deleted ones that new map should be created
-	if newMapVal.delCount > DeletionsLimit {
-		var keyItems []*Item
-		var deletedCount int
-		visitor := func(node *pmap.Node) {
-			item, ok := node.Val.(NodeValue)
-			if !ok {
-				runTimeError2(frame, "%s: not able to convert map item", opName)
-			}
-			if !item.Deleted {
-				keyItems = append(keyItems, &Item{Type: ValueItem, Data: item.Val.Key})
-				keyItems = append(keyItems, &Item{Type: ValueItem, Data: item.Val.Val})
-				for _, nextitem := range item.SameKeyValues {
-					keyItems = append(keyItems, &Item{Type: ValueItem, Data: nextitem.Key})
-					keyItems = append(keyItems, &Item{Type: ValueItem, Data: nextitem.Val})
-				}
-			} else {
-				deletedCount++
-			}
-		}
-		newMapVal.Rbm.VisitAll(visitor)
-
-		// check if amount of delted really exceeds the limit
-		if deletedCount > DeletionsLimit {
-			retVal = handleMapOP(frame, keyItems)
-			keyFound = itemFound
-			return
-		}
-		newMapVal.delCount = 0 // lets clear del counter so that traversal is not done all the time
+	newMapVal, itemFound := mapv.del(pmap.MKey(hashedKey), keyVal)
+	if !itemFound && rteIfNotExist {
+		runTimeError2(frame, "%s: key not found", opName)
 	}
-
 	retVal.Data = newMapVal
 	keyFound = itemFound
 	return
@@ -956,7 +929,7 @@ func handlePutOP(frame *Frame, operands []*Item) (retVal Value) {
 		runTimeError2(frame, "something wrong (%s)", opName)
 	}
 
-	hashedKey, err := hashOfValue(keyVal)
+	hashedKey, err := hashOfValue(frame, keyVal)
 	if err != nil {
 		runTimeError2(frame, "%s: illegal type for map key", opName)
 	}
@@ -972,7 +945,6 @@ func handlePutOP(frame *Frame, operands []*Item) (retVal Value) {
 	}
 
 	nodeval := NewNodeValue(keyVal, valueVal)
-	newmap := mapv.Rbm.Put(pmap.MKey(hashedKey), nodeval)
-	retVal.Data = &PMap{Rbm: newmap, itemCount: mapv.itemCount + 1, delCount: mapv.delCount}
+	retVal.Data = mapv.put(pmap.MKey(hashedKey), nodeval)
 	return
 }
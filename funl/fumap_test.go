@@ -3,6 +3,8 @@ package funl
 import (
 	"fmt"
 	"testing"
+
+	"github.com/anssihalmeaho/funl/pmap"
 )
 
 func TestItemsWithCollidingKeys(t *testing.T) {
@@ -367,3 +369,200 @@ func TestMapOKListAsKeys(t *testing.T) {
 		t.Errorf("Unexpected value: %s", s)
 	}
 }
+
+func TestDeleteOneOfCollidingKeys(t *testing.T) {
+	intKey := &Item{Type: ValueItem, Data: Value{Kind: IntValue, Data: 0}}
+	floatKey := &Item{Type: ValueItem, Data: Value{Kind: FloatValue, Data: 0.0}}
+	mapval := handleMapOP(nil, []*Item{})
+	mapval = handlePutOP(nil, []*Item{&Item{Type: ValueItem, Data: mapval}, intKey, &Item{Type: ValueItem, Data: Value{Kind: StringValue, Data: "int-0"}}})
+	mapval = handlePutOP(nil, []*Item{&Item{Type: ValueItem, Data: mapval}, floatKey, &Item{Type: ValueItem, Data: Value{Kind: StringValue, Data: "float-0"}}})
+	mapItem := &Item{Type: ValueItem, Data: mapval}
+
+	afterDel := handleDelOP(nil, []*Item{mapItem, intKey})
+	afterDelItem := &Item{Type: ValueItem, Data: afterDel}
+	if l := handleLenOP(nil, []*Item{afterDelItem}).Data.(int); l != 1 {
+		t.Errorf("Unexpected length: %d", l)
+	}
+	defaultVal := &Item{Type: ValueItem, Data: Value{Kind: StringValue, Data: "not found"}}
+	if v := handleGetOP(nil, []*Item{afterDelItem, floatKey, defaultVal}); v.Data.(string) != "float-0" {
+		t.Errorf("Unexpected value: %v", v)
+	}
+	if v := handleGetOP(nil, []*Item{afterDelItem, intKey, defaultVal}); v.Data.(string) != "not found" {
+		t.Errorf("Unexpected value: %v", v)
+	}
+	if v := handleGetOP(nil, []*Item{mapItem, intKey, defaultVal}); v.Data.(string) != "int-0" {
+		t.Errorf("Unexpected value: %v", v)
+	}
+
+	afterBoth := handleDelOP(nil, []*Item{afterDelItem, floatKey})
+	visited := 0
+	afterBoth.Data.(*PMap).visitAll(func(*pmap.Node) { visited++ })
+	if visited != 0 {
+		t.Errorf("Map should be empty")
+	}
+}
+
+// frameWithMapSettings returns frame of interpreter which has given map settings
+func frameWithMapSettings(useHAMT, sorted bool) *Frame {
+	interpreter := NewInterpreter()
+	interpreter.Settings.UseHAMTMaps = useHAMT
+	interpreter.Settings.SortedMapIteration = sorted
+	return NewTopFrameWithInterpreter(interpreter)
+}
+
+func TestMapBackends(t *testing.T) {
+	for _, useHAMT := range []bool{false, true} {
+		gomap := make(map[int]int)
+		mapval := handleMapOP(frameWithMapSettings(useHAMT, false), []*Item{})
+		for i := 0; i < 3000; i++ {
+			key := (i * 7919) % 1000
+			keyItem := &Item{Type: ValueItem, Data: Value{Kind: IntValue, Data: key}}
+			if _, found := gomap[key]; found {
+				mapval = handleDelOP(nil, []*Item{&Item{Type: ValueItem, Data: mapval}, keyItem})
+				delete(gomap, key)
+			} else {
+				mapval = handlePutOP(nil, []*Item{&Item{Type: ValueItem, Data: mapval}, keyItem, &Item{Type: ValueItem, Data: Value{Kind: IntValue, Data: i}}})
+				gomap[key] = i
+			}
+		}
+		if pm := mapval.Data.(*PMap); (pm.Hamt != nil) != useHAMT {
+			t.Errorf("Wrong backend used (hamt: %v)", useHAMT)
+		}
+		mapItem := &Item{Type: ValueItem, Data: mapval}
+		if l := handleLenOP(nil, []*Item{mapItem}).Data.(int); l != len(gomap) {
+			t.Errorf("Unexpected length: %d (%d)", l, len(gomap))
+		}
+		for k, v := range gomap {
+			keyItem := &Item{Type: ValueItem, Data: Value{Kind: IntValue, Data: k}}
+			if got := handleGetOP(nil, []*Item{mapItem, keyItem}); got.Data.(int) != v {
+				t.Errorf("Unexpected value: %v (%d)", got, v)
+			}
+		}
+	}
+}
+
+func TestMapBuilder(t *testing.T) {
+	for _, useHAMT := range []bool{false, true} {
+		orig := handleMapOP(frameWithMapSettings(useHAMT, false), []*Item{
+			&Item{Type: ValueItem, Data: Value{Kind: StringValue, Data: "a"}},
+			&Item{Type: ValueItem, Data: Value{Kind: IntValue, Data: 1}},
+		})
+		mb := NewMapBuilderFrom(nil, orig)
+		for i := 0; i < 1000; i++ {
+			mb.Put(nil, Value{Kind: IntValue, Data: i}, Value{Kind: IntValue, Data: i * 2})
+		}
+		mb.Set(nil, Value{Kind: StringValue, Data: "a"}, Value{Kind: IntValue, Data: 2})
+		if !mb.Del(nil, Value{Kind: IntValue, Data: 10}) {
+			t.Errorf("Key not found")
+		}
+		if val, found := mb.Get(nil, Value{Kind: IntValue, Data: 500}); !found || val.Data.(int) != 1000 {
+			t.Errorf("Unexpected value: %v", val)
+		}
+		built := mb.Freeze(nil)
+		builtItem := &Item{Type: ValueItem, Data: built}
+		if l := handleLenOP(nil, []*Item{builtItem}).Data.(int); l != 1000 {
+			t.Errorf("Unexpected length: %d", l)
+		}
+		if v := handleGetOP(nil, []*Item{builtItem, &Item{Type: ValueItem, Data: Value{Kind: StringValue, Data: "a"}}}); v.Data.(int) != 2 {
+			t.Errorf("Unexpected value: %v", v)
+		}
+		if l := handleLenOP(nil, []*Item{&Item{Type: ValueItem, Data: orig}}).Data.(int); l != 1 {
+			t.Errorf("Original map changed: %d", l)
+		}
+
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Using frozen builder should fail")
+				}
+			}()
+			mb.Put(nil, Value{Kind: IntValue, Data: -1}, Value{Kind: IntValue, Data: 0})
+		}()
+	}
+}
+
+func TestListBuilder(t *testing.T) {
+	lb := NewListBuilder()
+	lb.Append(nil, Value{Kind: IntValue, Data: 1}, Value{Kind: IntValue, Data: 2})
+	lb.Append(nil, Value{Kind: IntValue, Data: 3})
+	if lb.Len() != 3 {
+		t.Errorf("Unexpected length: %d", lb.Len())
+	}
+	if s := lb.Freeze(nil).String(); s != "list(1, 2, 3)" {
+		t.Errorf("Unexpected list: %s", s)
+	}
+}
+
+// BenchmarkMapBuilder compares building map with put -operator and
+// with MapBuilder for both map backends
+func BenchmarkMapBuilder(b *testing.B) {
+	const size = 1000
+	keys := make([]Value, size)
+	for i := range keys {
+		keys[i] = Value{Kind: IntValue, Data: i}
+	}
+	for _, useHAMT := range []bool{false, true} {
+		backend := "RBMap"
+		if useHAMT {
+			backend = "HAMT"
+		}
+		frame := frameWithMapSettings(useHAMT, false)
+		b.Run(fmt.Sprintf("put-%s", backend), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				mapval := handleMapOP(frame, []*Item{})
+				for _, key := range keys {
+					mapval = handlePutOP(frame, []*Item{{Type: ValueItem, Data: mapval}, {Type: ValueItem, Data: key}, {Type: ValueItem, Data: key}})
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("builder-%s", backend), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				mb := NewMapBuilder(frame)
+				for _, key := range keys {
+					mb.Put(frame, key, key)
+				}
+				mb.Freeze(frame)
+			}
+		})
+	}
+}
+
+const mapPutBenchSrc = `
+ns mapbench
+
+put-items = func(n)
+	looper = func(i m)
+		while( gt(i 0)
+			minus(i 1)
+			put(m i i)
+			len(m)
+		)
+	end
+	call(looper n map())
+end
+
+endns
+`
+
+// BenchmarkFunLMapPut compares map backends with puts done in FunL code
+func BenchmarkFunLMapPut(b *testing.B) {
+	for _, useHAMT := range []bool{false, true} {
+		backend := "RBMap"
+		if useHAMT {
+			backend = "HAMT"
+		}
+		b.Run(backend, func(b *testing.B) {
+			interpreter := NewInterpreter()
+			interpreter.Settings.UseHAMTMaps = useHAMT
+			interpreter.Importer = &fileImporter{}
+			topFrame := loadToInterpreter(b, interpreter, mapPutBenchSrc)
+			arg := Value{Kind: IntValue, Data: 10000}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if result := callInFrame(b, topFrame, "put-items", arg); result.Data != 10000 {
+					b.Fatalf("unexpected result: %s", result)
+				}
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 )
 
 type ExtProcType struct {
@@ -25,6 +26,7 @@ func DebugPrint(format string, args ...interface{}) {
 // NSAccess is access point to namespaces
 type NSAccess struct {
 	nsMap map[SymID]*NSTopInfo
+	sidc  *SymbolToIDConverter
 	sync.RWMutex
 }
 
@@ -38,9 +40,9 @@ func (nsa *NSAccess) Print() (s string) {
 	defer nsa.RUnlock()
 
 	for k, v := range nsa.nsMap {
-		s += fmt.Sprintf("\n  ns: %s, frame: %v, ", SymIDMap.AsString(k), v.TopFrame.Syms.Print(0))
+		s += fmt.Sprintf("\n  ns: %s, frame: %v, ", nsa.sidc.AsString(k), v.TopFrame.Syms.Print(0))
 		for imp := range v.TopFrame.Imported {
-			s += fmt.Sprintf("im: %s, ", SymIDMap.AsString(imp))
+			s += fmt.Sprintf("im: %s, ", nsa.sidc.AsString(imp))
 		}
 	}
 	return
@@ -66,6 +68,18 @@ func (nsa *NSAccess) GetTopFrameBySID(sid SymID) (frame *Frame, found bool) {
 	return
 }
 
+// topFrames returns top frames of all namespaces by namespace symbol id
+func (nsa *NSAccess) topFrames() map[SymID]*Frame {
+	nsa.RLock()
+	defer nsa.RUnlock()
+
+	frames := make(map[SymID]*Frame, len(nsa.nsMap))
+	for sid, topInfo := range nsa.nsMap {
+		frames[sid] = topInfo.TopFrame
+	}
+	return frames
+}
+
 func (nsa *NSAccess) HasNS(sid SymID) bool {
 	nsa.RLock()
 	defer nsa.RUnlock()
@@ -88,7 +102,7 @@ func (nsa *NSAccess) FillFromAstNSpaceAndStore(frame *Frame, sid SymID, nspace *
 
 func newTopFrameForNS(ns *NSpace, interpreter *Interpreter) *Frame {
 	return &Frame{
-		Syms:        NewSymt(),
+		Syms:        NewSymtWithConverter(interpreter.SymIDs),
 		OtherNS:     ns.OtherNS,
 		Imported:    make(map[SymID]*Frame),
 		Interpreter: interpreter,
@@ -100,6 +114,9 @@ func AddImportsToNamespace(nspace *NSpace, frame *Frame, interpreter *Interprete
 }
 
 func AddImportsToNamespaceSub(nspace *NSpace, frame *Frame, interpreter *Interpreter) {
+	if frame.Imported == nil && len(nspace.OtherNS) > 0 {
+		frame.Imported = make(map[SymID]*Frame)
+	}
 	for sid, importInfo := range nspace.OtherNS {
 		importedFrame, found := interpreter.NsDir.GetTopFrameBySID(sid)
 		if !found {
@@ -112,7 +129,7 @@ func AddImportsToNamespaceSub(nspace *NSpace, frame *Frame, interpreter *Interpr
 				runTimeError2(frame, err.Error())
 			}
 			if !found {
-				runTimeError2(frame, "Namespace top frame not found: %s", SymIDMap.AsString(sid))
+				runTimeError2(frame, "Namespace top frame not found: %s", interpreter.SymIDs.AsString(sid))
 			}
 		}
 		frame.Imported[sid] = importedFrame
@@ -124,17 +141,22 @@ func EvalAndAssignValuesForSymbolsInFrameForNS(frame *Frame, ns *NSpace) {
 }
 
 func evalAndAssignValuesForSymbolsInFrameForNS(frame *Frame, ns *NSpace) {
+	// symbols are also put to slots for resolved symbol references
+	frame.bindImports(ns)
+	frame.slots = make([]Value, len(ns.Syms.Keys()))
+
 	symbolMap := ns.Syms.AsMap()
-	for _, sid := range ns.Syms.Keys() {
+	for index, sid := range ns.Syms.Keys() {
 		item := symbolMap[sid]
 		switch item.Type {
 		case ValueItem:
 			vi := item.Data.(Value)
 			switch vi.Kind {
-			case IntValue, FloatValue, StringValue, BoolValue, ListValue, FunctionValue:
+			case IntValue, BigIntValue, FloatValue, StringValue, BoolValue, ListValue, FunctionValue:
 				if !frame.Syms.AddBySID(sid, item) {
 					runTimeError2(frame, "Symbol adding failed")
 				}
+				frame.slots[index] = vi
 			case FuncProtoValue:
 				newVal := Value{
 					Kind: FunctionValue,
@@ -150,6 +172,7 @@ func evalAndAssignValuesForSymbolsInFrameForNS(frame *Frame, ns *NSpace) {
 				if !frame.Syms.AddBySID(sid, newItem) {
 					runTimeError2(frame, "Symbol adding failed")
 				}
+				frame.slots[index] = newVal
 				subframe := &Frame{
 					Syms:     NewSymt(),
 					OtherNS:  make(map[SymID]ImportInfo),
@@ -162,7 +185,7 @@ func evalAndAssignValuesForSymbolsInFrameForNS(frame *Frame, ns *NSpace) {
 	}
 	// new round to evaluate symbols and operator calls
 	symbolMap = ns.Syms.AsMap()
-	for _, sid := range ns.Syms.Keys() {
+	for index, sid := range ns.Syms.Keys() {
 		item := symbolMap[sid]
 		switch item.Type {
 		case ValueItem:
@@ -172,6 +195,7 @@ func evalAndAssignValuesForSymbolsInFrameForNS(frame *Frame, ns *NSpace) {
 			if !frame.Syms.AddBySID(sid, evaluatedItem) {
 				runTimeError2(frame, "Symbol adding failed")
 			}
+			frame.slots[index] = evaluatedItem.Data.(Value)
 		}
 	}
 }
@@ -184,9 +208,9 @@ func fillSymsForFunc(syms *Symt, funcProto *Function) {
 		case ValueItem:
 			vi := item.Data.(Value)
 			switch vi.Kind {
-			case IntValue, FloatValue, StringValue, BoolValue, ListValue:
+			case IntValue, BigIntValue, FloatValue, StringValue, BoolValue, ListValue:
 				if !syms.AddBySID(sid, item) {
-					runTimeError("Symbol adding failed (%s)", SymIDMap.AsString(sid))
+					runTimeError("Symbol adding failed (%s)", syms.sidc.AsString(sid))
 				}
 			case FuncProtoValue:
 				subframe := &Frame{
@@ -221,6 +245,32 @@ func GetArgs(argsAsStr string) (argsItems []*Item, err error) {
 	return
 }
 
+// bindArgsToInterpreter converts symbol ids in arguments parsed by GetArgs
+// (which uses default converter) to symbol ids of interpreter
+func bindArgsToInterpreter(items []*Item, interpreter *Interpreter) []*Item {
+	var bound []*Item
+	for _, item := range items {
+		switch item.Type {
+		case SymbolPathItem:
+			var sp SymbolPath
+			for _, sid := range item.Data.(SymbolPath) {
+				sp = append(sp, interpreter.SymIDs.Add(SymIDMap.AsString(sid)))
+			}
+			copied := *item
+			copied.Data = sp
+			item = &copied
+		case OperCallItem:
+			opc := item.Data.(OpCall)
+			opc.Operands = bindArgsToInterpreter(opc.Operands, interpreter)
+			copied := *item
+			copied.Data = opc
+			item = &copied
+		}
+		bound = append(bound, item)
+	}
+	return bound
+}
+
 var initExtensions []func(*Interpreter) error
 
 // AddExtensionInitializer can be used for registering initializer
@@ -229,18 +279,100 @@ func AddExtensionInitializer(initializer func(*Interpreter) error) {
 	initExtensions = append(initExtensions, initializer)
 }
 
+// Interpreter has state of one interpreter instance: loaded modules,
+// symbol ids and settings. Interpreters are independent of each other
+// and same source needs to be parsed separately for each interpreter.
 type Interpreter struct {
 	NsDir    *NSAccess
 	Importer ModuleImporter
+	SymIDs   *SymbolToIDConverter
+	Settings Settings
+
+	stateMutex  sync.Mutex
+	state       map[interface{}]interface{}
+	initOnce    sync.Once
+	initErr     error
+	initialized int32 // set (atomically) to 1 when first Init call is done
+}
+
+// Settings are interpreter specific settings, defaults are taken
+// from package level variables when interpreter is created
+type Settings struct {
+	PrintingDisabledInFunctions        bool
+	PrintingRTElocationAndScopeEnabled bool
+	UseTreeWalker                      bool
+	UseHAMTMaps                        bool
+	SortedMapIteration                 bool
+}
+
+// DefaultSettings returns settings based on package level variables
+func DefaultSettings() Settings {
+	return Settings{
+		PrintingDisabledInFunctions:        PrintingDisabledInFunctions,
+		PrintingRTElocationAndScopeEnabled: PrintingRTElocationAndScopeEnabled,
+		UseTreeWalker:                      UseTreeWalker,
+		UseHAMTMaps:                        UseHAMTMaps,
+		SortedMapIteration:                 SortedMapIteration,
+	}
 }
 
 func NewInterpreter() *Interpreter {
+	sidc := NewSymbolToIDConverter()
 	interpreter := &Interpreter{
-		NsDir: &NSAccess{nsMap: make(map[SymID]*NSTopInfo)},
+		NsDir:    &NSAccess{nsMap: make(map[SymID]*NSTopInfo), sidc: sidc},
+		SymIDs:   sidc,
+		Settings: DefaultSettings(),
+		state:    make(map[interface{}]interface{}),
 	}
 	return interpreter
 }
 
+// NewParser returns parser which produces namespaces for interpreter
+func (interpreter *Interpreter) NewParser(srcFileName *string) *Parser {
+	parser := NewParser(NewDefaultOperators(), srcFileName)
+	parser.SetSymIDConverter(interpreter.SymIDs)
+	return parser
+}
+
+// State returns interpreter specific state of std-lib or extension module
+// by key, if there's none yet it's created by calling create
+func (interpreter *Interpreter) State(key interface{}, create func() interface{}) interface{} {
+	interpreter.stateMutex.Lock()
+	defer interpreter.stateMutex.Unlock()
+
+	st, found := interpreter.state[key]
+	if !found {
+		st = create()
+		interpreter.state[key] = st
+	}
+	return st
+}
+
+// interpreterOf returns interpreter to which frame belongs (nil if not known)
+func interpreterOf(frame *Frame) *Interpreter {
+	if frame == nil {
+		return nil
+	}
+	return frame.GetTopFrame().Interpreter
+}
+
+// Settings returns settings of interpreter to which frame belongs
+// (defaults if not known)
+func (fr *Frame) Settings() Settings {
+	if interpreter := interpreterOf(fr); interpreter != nil {
+		return interpreter.Settings
+	}
+	return DefaultSettings()
+}
+
+// SymIDs returns symbol converter of interpreter to which frame belongs
+func (fr *Frame) SymIDs() *SymbolToIDConverter {
+	if interpreter := interpreterOf(fr); interpreter != nil {
+		return interpreter.SymIDs
+	}
+	return SymIDMap
+}
+
 func FunlMainWithPackage(argsItems []*Item, name, srcFileName string, initSTD func(*Interpreter) error) (retValue Value, err error) {
 	data := []byte{}
 	data, err = os.ReadFile(srcFileName)
@@ -301,7 +433,7 @@ func FunlMainWithArgs(content string, argsItems []*Item, name, srcFileName strin
 }
 
 func FunlMainWithInterpreter(content string, argsItems []*Item, name, srcFileName string, initSTD func(*Interpreter) error, interpreter *Interpreter) (retValue Value, err error) {
-	parser := NewParser(NewDefaultOperators(), &srcFileName)
+	parser := interpreter.NewParser(&srcFileName)
 	var nsName string
 	var nspace *NSpace
 	nsName, nspace, err = parser.Parse(string(content))
@@ -311,21 +443,11 @@ func FunlMainWithInterpreter(content string, argsItems []*Item, name, srcFileNam
 
 	// first create top frame for namespace and put to nsDir
 	topframe := newTopFrameForNS(nspace, interpreter)
-	nsSid := SymIDMap.Add(nsName)
-
-	if err = initSTD(interpreter); err != nil {
-		runTimeError("Error in std-lib init (%v)", err)
-	}
+	nsSid := interpreter.SymIDs.Add(nsName)
+	argsItems = bindArgsToInterpreter(argsItems, interpreter)
 
-	if err = initFunSourceSTD(interpreter); err != nil {
-		runTimeError("Error in std-lib (fun source) init (%v)", err)
-	}
-
-	// call possible extension inits
-	for _, initializer := range initExtensions {
-		if err := initializer(interpreter); err != nil {
-			runTimeError("Error in extension module init (%v)", err)
-		}
+	if err = interpreter.Init(initSTD); err != nil {
+		runTimeError("%v", err)
 	}
 
 	// then put imports to all namespaces
@@ -335,7 +457,7 @@ func FunlMainWithInterpreter(content string, argsItems []*Item, name, srcFileNam
 	// then evaluate and assign symbols of namespaces
 	interpreter.NsDir.FillFromAstNSpaceAndStore(topframe, nsSid, nspace)
 
-	mainSid, found := SymIDMap.Get("main")
+	mainSid, found := interpreter.SymIDs.Get("main")
 	if !found {
 		runTimeError("Main module not found")
 	}
@@ -377,6 +499,43 @@ func FunlMainWithInterpreter(content string, argsItems []*Item, name, srcFileNam
 	return
 }
 
+// Init initializes std-lib and extension modules for interpreter,
+// it's done only once: later calls don't call given init function
+// but return result of first one
+func (interpreter *Interpreter) Init(initSTD func(*Interpreter) error) error {
+	interpreter.initOnce.Do(func() {
+		interpreter.initErr = interpreter.protect(func() error {
+			return initLibraries(interpreter, initSTD)
+		})
+		atomic.StoreInt32(&interpreter.initialized, 1)
+	})
+	return interpreter.initErr
+}
+
+// Initialized returns true if Init is already called for interpreter
+func (interpreter *Interpreter) Initialized() bool {
+	return atomic.LoadInt32(&interpreter.initialized) == 1
+}
+
+// initLibraries initializes std-lib and extension modules for interpreter
+func initLibraries(interpreter *Interpreter, initSTD func(*Interpreter) error) error {
+	if err := initSTD(interpreter); err != nil {
+		return fmt.Errorf("Error in std-lib init (%v)", err)
+	}
+
+	if err := initFunSourceSTD(interpreter); err != nil {
+		return fmt.Errorf("Error in std-lib (fun source) init (%v)", err)
+	}
+
+	// call possible extension inits
+	for _, initializer := range initExtensions {
+		if err := initializer(interpreter); err != nil {
+			return fmt.Errorf("Error in extension module init (%v)", err)
+		}
+	}
+	return nil
+}
+
 var stdfunMap = map[string]string{}
 
 // GetReplCode return REPL code
@@ -206,8 +206,9 @@ func convertPathToCommonFormat(path string) string {
 	return result
 }
 
-func readExtModuleFromFile(sid SymID, importPath string, interpreter *Interpreter) (topFrame *Frame, found bool, err error) {
-	// TODO: duplicate code...
+// getImportFileName returns file name and extension for imported module,
+// those can be given in import path (like 'file:mymod.fnl')
+func getImportFileName(importModName, importPath, defaultExtension string) (importFileName string, fileExtensionName string) {
 	importSpecs := make(map[string]string)
 	if importPath != "" {
 		for _, onepart := range strings.Split(importPath, ";") {
@@ -219,12 +220,7 @@ func readExtModuleFromFile(sid SymID, importPath string, interpreter *Interprete
 		}
 	}
 
-	fileExtensionName := "so"
-	importModName := SymIDMap.AsString(sid)
-	importFileName := importModName
-	if importModName == "" {
-		return
-	}
+	importFileName, fileExtensionName = importModName, defaultExtension
 	if specModName, nameFound := importSpecs["file"]; nameFound {
 		specFilenameParts := strings.Split(specModName, ".")
 		if len(specFilenameParts) == 2 {
@@ -232,6 +228,15 @@ func readExtModuleFromFile(sid SymID, importPath string, interpreter *Interprete
 			fileExtensionName = specFilenameParts[1]
 		}
 	}
+	return
+}
+
+func readExtModuleFromFile(sid SymID, importPath string, interpreter *Interpreter) (topFrame *Frame, found bool, err error) {
+	importModName := interpreter.SymIDs.AsString(sid)
+	if importModName == "" {
+		return
+	}
+	importFileName, fileExtensionName := getImportFileName(importModName, importPath, "so")
 	importFilePath := os.Getenv("FUNLPATH")
 
 	currentWorkDir, oserr := os.Getwd()
@@ -265,30 +270,11 @@ func readExtModuleFromFile(sid SymID, importPath string, interpreter *Interprete
 }
 
 func readModuleFromFile(inProcCall bool, sid SymID, importPath string, interpreter *Interpreter) (topFrame *Frame, found bool, err error) {
-	importSpecs := make(map[string]string)
-	if importPath != "" {
-		for _, onepart := range strings.Split(importPath, ";") {
-			splits := strings.Split(onepart, ":")
-			if len(splits) != 2 {
-				continue
-			}
-			importSpecs[splits[0]] = splits[1]
-		}
-	}
-
-	fileExtensionName := "fnl"
-	importModName := SymIDMap.AsString(sid)
-	importFileName := importModName
+	importModName := interpreter.SymIDs.AsString(sid)
 	if importModName == "" {
 		return
 	}
-	if specModName, nameFound := importSpecs["file"]; nameFound {
-		specFilenameParts := strings.Split(specModName, ".")
-		if len(specFilenameParts) == 2 {
-			importFileName = specFilenameParts[0]
-			fileExtensionName = specFilenameParts[1]
-		}
-	}
+	importFileName, fileExtensionName := getImportFileName(importModName, importPath, "fnl")
 
 	targetPath, content, err := interpreter.Importer.FindModule(importFileName, fileExtensionName)
 
@@ -301,11 +287,15 @@ func readModuleFromFile(inProcCall bool, sid SymID, importPath string, interpret
 	return
 }
 
-// AddNStoCache is for std usage
+// AddNStoCache is for std usage, namespace needs to be parsed
+// with converter of interpreter (see Interpreter.NewParser)
 func AddNStoCache(inProcCall bool, importModName string, nspace *NSpace, interpreter *Interpreter) *Frame {
+	if nspace.Syms != nil && nspace.Syms.sidc != interpreter.SymIDs {
+		runTimeError("namespace %s is not parsed for this interpreter", importModName)
+	}
 	// first create top frame for namespace and put to nsDir
 	topFrame := newTopFrameForNS(nspace, interpreter)
-	nsSid := SymIDMap.Add(importModName)
+	nsSid := interpreter.SymIDs.Add(importModName)
 
 	// then put imports to namespace
 	AddImportsToNamespaceSub(nspace, topFrame, interpreter)
@@ -319,7 +309,7 @@ func AddNStoCache(inProcCall bool, importModName string, nspace *NSpace, interpr
 }
 
 func commonAddFunModToNamespace(inProcCall bool, targetPath, importModName string, content []byte, interpreter *Interpreter) (topFrame *Frame, err error) {
-	parser := NewParser(NewDefaultOperators(), &targetPath)
+	parser := interpreter.NewParser(&targetPath)
 	var nsName string
 	var nspace *NSpace
 	nsName, nspace, err = parser.Parse(string(content))
@@ -0,0 +1,145 @@
+package funl
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+const isolationTestSrc = `
+ns itest
+
+import stdmod
+
+counter = func(n acc)
+	_ = print('printing is disabled in functions')
+	if( eq(n 0)
+		acc
+		call(counter minus(n 1) plus(acc n))
+	)
+end
+
+main = proc(n)
+	list(call(counter n 0) stdmod.value)
+end
+
+endns
+`
+
+// newTestInterpreter returns interpreter which has module stdmod
+// (value-symbol is given as value)
+func newTestInterpreter(t *testing.T, value string) *Interpreter {
+	interpreter := NewInterpreter()
+	interpreter.Importer = &fileImporter{}
+	parser := interpreter.NewParser(nil)
+	nsName, nspace, err := parser.Parse(fmt.Sprintf("ns stdmod value = '%s' endns", value))
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	AddNStoCache(true, nsName, nspace, interpreter)
+	return interpreter
+}
+
+func loadToInterpreter(t testing.TB, interpreter *Interpreter, source string) *Frame {
+	parser := interpreter.NewParser(nil)
+	nsName, nspace, err := parser.Parse(source)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	return AddNStoCache(true, nsName, nspace, interpreter)
+}
+
+func callInFrame(t testing.TB, topFrame *Frame, name string, args ...Value) Value {
+	funcItem, found := topFrame.Syms.GetByName(name)
+	if !found {
+		t.Fatalf("symbol not found: %s", name)
+	}
+	operands := []*Item{funcItem}
+	for _, arg := range args {
+		operands = append(operands, &Item{Type: ValueItem, Data: arg})
+	}
+	return handleCallOP(topFrame, operands)
+}
+
+func TestConcurrentInterpreters(t *testing.T) {
+	var wg sync.WaitGroup
+	results := make([]string, 8)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			interpreter := newTestInterpreter(t, fmt.Sprintf("v%d", i))
+			interpreter.Settings.UseTreeWalker = i%2 == 0
+			interpreter.Settings.PrintingDisabledInFunctions = true
+			topFrame := loadToInterpreter(t, interpreter, isolationTestSrc)
+			result := callInFrame(t, topFrame, "main", Value{Kind: IntValue, Data: 100 + i})
+			results[i] = result.String()
+		}(i)
+	}
+	wg.Wait()
+	for i, result := range results {
+		n := 100 + i
+		if expected := fmt.Sprintf("list(%d, 'v%d')", n*(n+1)/2, i); result != expected {
+			t.Errorf("unexpected result: %s (assumed %s)", result, expected)
+		}
+	}
+}
+
+func TestInterpreterOwnsSymbols(t *testing.T) {
+	interp1 := newTestInterpreter(t, "a")
+	interp2 := newTestInterpreter(t, "b")
+	topFrame := loadToInterpreter(t, interp1, isolationTestSrc)
+	callInFrame(t, topFrame, "counter", Value{Kind: IntValue, Data: 10}, Value{Kind: IntValue, Data: 0})
+
+	for _, sidc := range []*SymbolToIDConverter{SymIDMap, interp2.SymIDs} {
+		if _, found := sidc.Get("counter"); found {
+			t.Errorf("symbol should be only in interpreter which parsed it")
+		}
+	}
+	if _, found := interp1.SymIDs.Get("counter"); !found {
+		t.Errorf("symbol should be in interpreter")
+	}
+
+	// symbols bound in runtime to _ are not added to converter
+	count := interp1.SymIDs.SymbolCount()
+	callInFrame(t, topFrame, "counter", Value{Kind: IntValue, Data: 1000}, Value{Kind: IntValue, Data: 0})
+	if newCount := interp1.SymIDs.SymbolCount(); newCount != count {
+		t.Errorf("symbol count should not grow (%d -> %d)", count, newCount)
+	}
+}
+
+func TestItemPrintedWithConverterOfInterpreter(t *testing.T) {
+	interpreter := NewInterpreter()
+	sid := interpreter.SymIDs.Add("only-in-interpreter")
+	if _, found := SymIDMap.Get("only-in-interpreter"); found {
+		t.Fatalf("symbol should not be in default converter")
+	}
+	clause := &MatchClause{pattern: &pattern{kind: listPattern, subs: []*pattern{{kind: bindPattern, sid: sid}}}}
+	items := map[*Item]string{
+		{Type: PatternItem, Data: clause}:             "pattern: list(only-in-interpreter)",
+		{Type: SymbolPathItem, Data: SymbolPath{sid}}: "only-in-interpreter",
+	}
+	for item, expected := range items {
+		if s := item.PrintWith(0, interpreter.SymIDs); s != expected {
+			t.Errorf("unexpected: %s (assumed: %s)", s, expected)
+		}
+	}
+	if s := sid.StringWith(interpreter.SymIDs); s != "only-in-interpreter" {
+		t.Errorf("unexpected: %s", s)
+	}
+}
+
+func TestNamespaceOfOtherInterpreterRejected(t *testing.T) {
+	interp1 := newTestInterpreter(t, "a")
+	interp2 := newTestInterpreter(t, "b")
+	_, nspace, err := interp1.NewParser(nil).Parse("ns other x = 1 endns")
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("should fail")
+		}
+	}()
+	AddNStoCache(true, "other", nspace, interp2)
+}
@@ -482,6 +482,7 @@ func (s *tokenizer) scan(srcText string) ([]token, error) {
 	sourceText := string(bytes.ReplaceAll([]byte(srcText), []byte{10}, []byte{13, 10}))
 
 	var currentState = s.stateLinks[unknown]
+	var state = unknown
 	var newState lexState
 	var prevC rune
 	var makeSureItsStarNext bool
@@ -496,6 +497,11 @@ func (s *tokenizer) scan(srcText string) ([]token, error) {
 		}
 		makeSureItsStarNext = false
 
+		// token starts from first character (or from opening string limiter)
+		if len(s.buffer) == 0 && state != receivingCharacters {
+			s.startPos = s.pos
+		}
+
 		if c == '/' {
 			if prevC != 0 && prevC == '*' {
 				newState = currentState.processMultilineEnd()
@@ -551,6 +557,7 @@ func (s *tokenizer) scan(srcText string) ([]token, error) {
 		}
 	nextPlease:
 		currentState = s.stateLinks[newState]
+		state = newState
 		prevC = c
 	}
 	err := currentState.endingOfProcess()
@@ -562,6 +569,7 @@ type token struct {
 	Value  string
 	Lineno int
 	Pos    int
+	Col    int // column where token starts
 }
 
 func isAlNum(ch rune) bool {
@@ -640,6 +648,7 @@ type tokenizer struct {
 	operators  Operators
 	lineno     int
 	pos        int
+	startPos   int
 	err        error
 }
 
@@ -705,9 +714,11 @@ func (s *tokenizer) getTokenFromBuf(tokenKind tokenType) {
 		Value:  string(s.buffer),
 		Lineno: s.lineno,
 		Pos:    s.pos,
+		Col:    s.startPos,
 	}
 	s.tokens = append(s.tokens, token)
 	s.buffer = []byte{}
+	s.startPos = s.pos
 }
 
 // NewTokenizer exposes tokenizer API
@@ -475,3 +475,27 @@ func TestMultiLineComments(t *testing.T) {
 	}
 	t.Logf("Tokens: %#v", tokens)
 }
+
+func TestTokenColumns(t *testing.T) {
+	lexer := newTokenizer(NewDefaultOperators())
+	tokens, err := lexer.scan("a = get(m 'k')\n\tb = '' ")
+	if err != nil {
+		t.Fatalf("error : %v", err)
+	}
+	expected := []struct {
+		value  string
+		lineno int
+		col    int
+	}{
+		{"a", 1, 1}, {"=", 1, 3}, {"get", 1, 5}, {"(", 1, 8}, {"m", 1, 9}, {"k", 1, 11}, {")", 1, 14},
+		{"b", 2, 2}, {"=", 2, 4}, {"", 2, 6},
+	}
+	if len(tokens) != len(expected) {
+		t.Fatalf("wrong amount of tokens: %#v", tokens)
+	}
+	for i, exp := range expected {
+		if tok := tokens[i]; tok.Value != exp.value || tok.Lineno != exp.lineno || tok.Col != exp.col {
+			t.Fatalf("unexpected token (%d): %#v", i, tok)
+		}
+	}
+}
@@ -2,6 +2,8 @@ package funl
 
 import (
 	"fmt"
+
+	"github.com/anssihalmeaho/funl/pvec"
 )
 
 type ListObject struct {
@@ -79,21 +81,15 @@ func (lit *ListIterator) Next() *Value {
 func handleExtendOP(frame *Frame, operands []*Item) (retVal Value) {
 	opName := "extend"
 
-	var argvals []Value
-	for _, v := range operands {
-		var argval Value
-		switch v.Type {
-		case ValueItem:
-			argval = v.Data.(Value)
-		case SymbolPathItem, OperCallItem:
-			argval = EvalItem(v, frame)
-		default:
-			runTimeError2(frame, "something wrong (%s)", opName)
-		}
+	argvals := evalOperands(frame, opName, operands)
+	if len(argvals) > 0 && argvals[0].Kind == VectorValue {
+		retVal = vectorExtend(frame, opName, argvals)
+		return
+	}
+	for _, argval := range argvals {
 		if argval.Kind != ListValue {
 			runTimeError2(frame, "%s: arguments assumed to be list type", opName)
 		}
-		argvals = append(argvals, argval)
 	}
 
 	var prevnew *ListObject
@@ -139,6 +135,10 @@ func handleReverseOP(frame *Frame, operands []*Item) (retVal Value) {
 		runTimeError2(frame, "something wrong (%s)", opName)
 	}
 
+	if val.Kind == VectorValue {
+		retVal = vectorReverse(val)
+		return
+	}
 	if val.Kind != ListValue {
 		runTimeError2(frame, "First argument not list in %s operator", opName)
 	}
@@ -203,6 +203,10 @@ func handleRrestOP(frame *Frame, operands []*Item) (retVal Value) {
 		runTimeError2(frame, "something wrong (%s)", opName)
 	}
 
+	if val.Kind == VectorValue {
+		retVal = vectorSlice(frame, opName, val, 0, val.Data.(*pvec.Vector).Len()-1)
+		return
+	}
 	if val.Kind != ListValue {
 		runTimeError2(frame, "First argument not list in %s operator", opName)
 	}
@@ -308,6 +312,10 @@ func handleAppendOP(frame *Frame, operands []*Item) (retVal Value) {
 		runTimeError2(frame, "something wrong (%s)", opName)
 	}
 
+	if val.Kind == VectorValue {
+		retVal = vectorAppend(val, evalOperands(frame, opName, operands[1:]))
+		return
+	}
 	if val.Kind != ListValue {
 		runTimeError2(frame, "First argument not list in %s operator", opName)
 	}
@@ -440,6 +448,10 @@ func handleRestOP(frame *Frame, operands []*Item) (retVal Value) {
 		runTimeError2(frame, "something wrong (%s)", opName)
 	}
 
+	if val.Kind == VectorValue {
+		retVal = vectorSlice(frame, opName, val, 1, val.Data.(*pvec.Vector).Len())
+		return
+	}
 	if val.Kind != ListValue {
 		runTimeError2(frame, "First argument not list in %s operator", opName)
 	}
@@ -483,6 +495,10 @@ func handleLastOP(frame *Frame, operands []*Item) (retVal Value) {
 		runTimeError2(frame, "something wrong (%s)", opName)
 	}
 
+	if val.Kind == VectorValue {
+		retVal = vectorItem(frame, opName, val, val.Data.(*pvec.Vector).Len()-1)
+		return
+	}
 	if val.Kind != ListValue {
 		runTimeError2(frame, "First argument not list in %s operator", opName)
 	}
@@ -527,6 +543,10 @@ func handleHeadOP(frame *Frame, operands []*Item) (retVal Value) {
 		runTimeError2(frame, "something wrong (%s)", opName)
 	}
 
+	if val.Kind == VectorValue {
+		retVal = vectorItem(frame, opName, val, 0)
+		return
+	}
 	if val.Kind != ListValue {
 		runTimeError2(frame, "First argument not list in %s operator", opName)
 	}
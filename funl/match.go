@@ -0,0 +1,195 @@
+package funl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// patternKind is kind of pattern in match -operator
+type patternKind int
+
+const (
+	anyPattern     patternKind = iota // _
+	bindPattern                       // symbol
+	literalPattern                    // int, float, string or bool value
+	listPattern                       // list(<pattern> ... <symbol>:)
+	mapPattern                        // map(<key> <pattern> ...)
+	typePattern                       // type(<type-name> <pattern>)
+)
+
+// pattern is parsed pattern of match -operator
+type pattern struct {
+	kind  patternKind
+	index int        // binding index of symbol (bindPattern)
+	sid   SymID      // bound symbol (bindPattern)
+	value Value      // literal value or type name
+	keys  []Value    // keys of map (mapPattern)
+	subs  []*pattern // list elements, map values or type sub-pattern
+	rest  *pattern   // rest of list (nil if length of list should match)
+}
+
+// MatchClause is pattern with optional guard expression in match -operator
+type MatchClause struct {
+	pattern       *pattern
+	guard         *Item
+	syms          []SymID // symbols bound by pattern (index is slot)
+	resolved      bool
+	hasDuplicates bool
+}
+
+// StringWith returns pattern as string, symbols are converted by using given converter
+func (p *pattern) StringWith(sidc *SymbolToIDConverter) string {
+	subStrs := func(prefix []string) string {
+		strs := prefix
+		for i, sub := range p.subs {
+			if p.kind == mapPattern {
+				strs = append(strs, fmt.Sprintf("%#v", p.keys[i]))
+			}
+			strs = append(strs, sub.StringWith(sidc))
+		}
+		if p.rest != nil {
+			strs = append(strs, p.rest.StringWith(sidc)+":")
+		}
+		return strings.Join(strs, " ")
+	}
+	switch p.kind {
+	case anyPattern:
+		return "_"
+	case bindPattern:
+		return sidc.AsString(p.sid)
+	case literalPattern:
+		return fmt.Sprintf("%#v", p.value)
+	case listPattern:
+		return "list(" + subStrs(nil) + ")"
+	case mapPattern:
+		return "map(" + subStrs(nil) + ")"
+	case typePattern:
+		return "type(" + subStrs([]string{fmt.Sprintf("%#v", p.value)}) + ")"
+	}
+	return "?"
+}
+
+func handleMatchOP(frame *Frame, operands []*Item) (retVal Value) {
+	clauseFrame, resultItem := selectMatchClause(frame, operands)
+	return EvalItem(resultItem, clauseFrame)
+}
+
+// selectMatchClause finds first clause which pattern matches to value,
+// returns result expression and frame in which it's evaluated
+func selectMatchClause(frame *Frame, operands []*Item) (*Frame, *Item) {
+	opName := "match"
+	if l := len(operands); l < 3 || (l%2) == 0 {
+		runTimeError2(frame, "%s: invalid amount of arguments (%d)", opName, l)
+	}
+	val := EvalItem(operands[0], frame)
+	for i := 1; i < len(operands); i += 2 {
+		clause, ok := operands[i].Data.(*MatchClause)
+		if !ok {
+			runTimeError2(frame, "%s: pattern assumed", opName)
+		}
+		if clauseFrame, matched := clause.match(frame, val); matched {
+			return clauseFrame, operands[i+1]
+		}
+	}
+	runTimeError2(frame, "%s: no pattern matched value (%s)", opName, val)
+	return nil, nil
+}
+
+func (clause *MatchClause) match(frame *Frame, val Value) (*Frame, bool) {
+	bindings := make([]Value, len(clause.syms))
+	if !clause.pattern.match(frame, val, bindings) {
+		return nil, false
+	}
+	clauseFrame := frame
+	if len(clause.syms) > 0 {
+		clauseFrame = frame.newClauseFrame(clause, bindings)
+	}
+	if clause.guard != nil {
+		guardVal := EvalItem(clause.guard, clauseFrame)
+		if guardVal.Kind != BoolValue {
+			runTimeError2(frame, "match: guard should be bool expression")
+		}
+		if !guardVal.Data.(bool) {
+			return nil, false
+		}
+	}
+	return clauseFrame, true
+}
+
+// newClauseFrame creates frame for symbols bound by pattern
+func (fr *Frame) newClauseFrame(clause *MatchClause, bindings []Value) *Frame {
+	// Overlapping symbol names not allowed (might cause variable -like effect)
+	if !clause.resolved || clause.hasDuplicates || hasLetSymsInScope(fr) {
+		for _, sid := range clause.syms {
+			if _, symfound := fr.GetSymItem(sid); symfound {
+				runTimeError2(fr, "Duplicate symbol name in scope not allowed (%s)", fr.SymIDs().AsString(sid))
+			}
+		}
+	}
+	return &Frame{
+		FuncProto:     fr.FuncProto,
+		slots:         bindings,
+		slotNames:     clause.syms,
+		AccessLink:    fr,
+		inProcCall:    fr.inProcCall,
+		EvaluatedArgs: fr.EvaluatedArgs,
+		Previous:      fr.Previous,
+		CallSite:      fr.CallSite,
+	}
+}
+
+func (p *pattern) match(frame *Frame, val Value, bindings []Value) bool {
+	switch p.kind {
+	case anyPattern:
+		return true
+	case bindPattern:
+		bindings[p.index] = val
+		return true
+	case literalPattern:
+		if val.Kind != p.value.Kind {
+			return false
+		}
+		argsForEq := []*Item{
+			&Item{Type: ValueItem, Data: p.value},
+			&Item{Type: ValueItem, Data: val},
+		}
+		return handleEqOP(frame, argsForEq).Data.(bool)
+	case typePattern:
+		if valueTypeName(frame, "match", val) != p.value.Data.(string) {
+			return false
+		}
+		return len(p.subs) == 0 || p.subs[0].match(frame, val, bindings)
+	case listPattern:
+		if val.Kind != ListValue {
+			return false
+		}
+		lit := NewListIterator(val)
+		for _, sub := range p.subs {
+			next := lit.Next()
+			if next == nil || !sub.match(frame, *next, bindings) {
+				return false
+			}
+		}
+		if p.rest == nil {
+			return lit.Next() == nil
+		}
+		// rest of list shares items with original list (like rest -operator)
+		restList := &List{Head: lit.NextItem}
+		if !lit.HeadDone {
+			restList.Tail = val.Data.(*List).Tail
+		}
+		return p.rest.match(frame, Value{Kind: ListValue, Data: restList}, bindings)
+	case mapPattern:
+		if val.Kind != MapValue {
+			return false
+		}
+		for i, key := range p.keys {
+			v, found := getFromMap(frame, val, key)
+			if !found || !p.subs[i].match(frame, v, bindings) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
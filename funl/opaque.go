@@ -0,0 +1,96 @@
+package funl
+
+import (
+	"reflect"
+)
+
+// Optional interfaces which opaque values (OpaqueAPI) can implement
+
+// OpaqueHasher makes opaque value usable as map key and set item,
+// values which are equal (by Equals) need to have same hash
+type OpaqueHasher interface {
+	Hash() int
+}
+
+// OpaqueComparer makes opaque values ordered for lt, gt, le and ge
+// (and CompareValues), Compare returns negative, zero or positive number
+// and false if values cannot be compared
+type OpaqueComparer interface {
+	Compare(with OpaqueAPI) (int, bool)
+}
+
+// OpaqueGetter makes get and getl operators work with opaque value
+// (like it would be map)
+type OpaqueGetter interface {
+	Get(frame *Frame, key Value) (Value, bool)
+}
+
+// OpaqueEncoder gives value which represents opaque value
+// in serialization (like stdjson and stdser)
+type OpaqueEncoder interface {
+	Encode(frame *Frame) Value
+}
+
+// OpaqueCaller makes opaque value callable with call -operator
+type OpaqueCaller interface {
+	Callable() ExtProcType
+}
+
+// HashOfPointer returns hash which is based on identity of pointer,
+// it's meant for opaque values which are equal only to themselves
+func HashOfPointer(p interface{}) int {
+	return getHashedInt(int(reflect.ValueOf(p).Pointer()))
+}
+
+// HashOfValue returns hash of value (error if value cannot be map key)
+func HashOfValue(val Value) (int, error) {
+	return hashOfValue(nil, val)
+}
+
+// compareOpaques compares opaque values if first one implements OpaqueComparer
+func compareOpaques(val1, val2 Value) (int, bool) {
+	if val1.Kind != OpaqueValue || val2.Kind != OpaqueValue {
+		return 0, false
+	}
+	comparer, ok := val1.Data.(OpaqueComparer)
+	if !ok {
+		return 0, false
+	}
+	return comparer.Compare(val2.Data.(OpaqueAPI))
+}
+
+// getFromOpaque implements get and getl for opaque value
+func getFromOpaque(opName string, argCount int, isGetl bool, frame *Frame, opaqueVal Value, operands []*Item) (retVal Value) {
+	getter, ok := opaqueVal.Data.(OpaqueGetter)
+	if !ok {
+		runTimeError2(frame, "%s: opaque value %s does not support %s", opName, opaqueVal.Data.(OpaqueAPI).TypeName(), opName)
+	}
+	keyVal := evalOperand(frame, opName, operands[1])
+	val, found := getter.Get(frame, keyVal)
+	switch {
+	case isGetl:
+		if !found {
+			val = Value{Kind: BoolValue, Data: false}
+		}
+		retVal = MakeListOfValues(frame, []Value{{Kind: BoolValue, Data: found}, val})
+	case found:
+		retVal = val
+	case argCount == 3:
+		retVal = evalOperand(frame, opName, operands[2])
+	default:
+		runTimeError2(frame, "%s: key not found (%v)", opName, keyVal.Data)
+	}
+	return
+}
+
+func evalOperand(frame *Frame, opName string, operand *Item) (val Value) {
+	switch operand.Type {
+	case ValueItem:
+		val = operand.Data.(Value)
+	case SymbolPathItem, OperCallItem:
+		val = EvalItem(operand, frame)
+	default:
+		runTimeError2(frame, "something wrong (%s)", opName)
+	}
+	return
+}
@@ -0,0 +1,123 @@
+package funl
+
+import (
+	"strings"
+	"testing"
+)
+
+// testPoint implements all optional opaque interfaces
+type testPoint struct {
+	x, y int
+}
+
+func (p *testPoint) TypeName() string { return "point" }
+func (p *testPoint) Str() string      { return "point" }
+
+func (p *testPoint) Equals(with OpaqueAPI) bool {
+	other, ok := with.(*testPoint)
+	return ok && *p == *other
+}
+
+func (p *testPoint) Hash() int {
+	hash, _ := HashOfValue(Value{Kind: IntValue, Data: p.x*1000 + p.y})
+	return hash
+}
+
+func (p *testPoint) Compare(with OpaqueAPI) (int, bool) {
+	other, ok := with.(*testPoint)
+	if !ok {
+		return 0, false
+	}
+	return compareInts(p.x*1000+p.y, other.x*1000+other.y), true
+}
+
+func (p *testPoint) Get(frame *Frame, key Value) (Value, bool) {
+	switch key.Data {
+	case "x":
+		return Value{Kind: IntValue, Data: p.x}, true
+	case "y":
+		return Value{Kind: IntValue, Data: p.y}, true
+	}
+	return Value{}, false
+}
+
+func (p *testPoint) Callable() ExtProcType {
+	impl := func(frame *Frame, arguments []Value) Value {
+		return Value{Kind: IntValue, Data: p.x + arguments[0].Data.(int)}
+	}
+	return ExtProcType{Impl: impl, IsFunction: true}
+}
+
+// testPlain implements only OpaqueAPI
+type testPlain struct{}
+
+func (p *testPlain) TypeName() string           { return "plain" }
+func (p *testPlain) Str() string                { return "plain" }
+func (p *testPlain) Equals(with OpaqueAPI) bool { return with == OpaqueAPI(p) }
+
+const opaqueTestSrc = `
+ns otest
+
+keyed = func(p1 p2) list(get(map(p1 'found') p2) in(set(p1) p2)) end
+ordered = func(p1 p2) list(lt(p1 p2) le(p1 p2) gt(p1 p2) ge(p1 p2)) end
+fields = func(p) list(get(p 'x') getl(p 'y') getl(p 'z') get(p 'z' 0)) end
+callit = func(p) call(p 10) end
+getx = func(p) get(p 'x') end
+endns
+`
+
+func TestOpaqueInterfaces(t *testing.T) {
+	topFrame := loadToInterpreter(t, newTestInterpreter(t, ""), opaqueTestSrc)
+	point := func(x, y int) Value { return Value{Kind: OpaqueValue, Data: &testPoint{x: x, y: y}} }
+
+	cases := []struct {
+		name     string
+		args     []Value
+		expected string
+	}{
+		{name: "keyed", args: []Value{point(1, 2), point(1, 2)}, expected: "list('found', true)"},
+		{name: "ordered", args: []Value{point(1, 2), point(1, 3)}, expected: "list(true, true, false, false)"},
+		{name: "ordered", args: []Value{point(2, 0), point(1, 3)}, expected: "list(false, false, true, true)"},
+		{name: "fields", args: []Value{point(1, 2)}, expected: "list(1, list(true, 2), list(false, false), 0)"},
+		{name: "callit", args: []Value{point(5, 0)}, expected: "15"},
+	}
+	for _, tc := range cases {
+		if result := callInFrame(t, topFrame, tc.name, tc.args...).String(); result != tc.expected {
+			t.Errorf("%s: unexpected result: %s (assumed %s)", tc.name, result, tc.expected)
+		}
+	}
+
+	if c := CompareValues(point(3, 0), point(2, 9)); c != 1 {
+		t.Errorf("unexpected comparison: %d", c)
+	}
+}
+
+func TestOpaqueWithoutInterfaces(t *testing.T) {
+	topFrame := loadToInterpreter(t, newTestInterpreter(t, ""), opaqueTestSrc)
+	plain := Value{Kind: OpaqueValue, Data: &testPlain{}}
+
+	cases := []struct {
+		name     string
+		args     []Value
+		errorMsg string
+	}{
+		{name: "keyed", args: []Value{plain, plain}, errorMsg: "illegal type for map key"},
+		{name: "getx", args: []Value{plain}, errorMsg: "does not support get"},
+		{name: "callit", args: []Value{plain}, errorMsg: "First argument for call is not function"},
+	}
+	for _, tc := range cases {
+		func() {
+			defer func() {
+				r := recover()
+				if r == nil {
+					t.Errorf("%s: should fail", tc.name)
+					return
+				}
+				if msg := asRuntimeError(r).Error(); !strings.Contains(msg, tc.errorMsg) {
+					t.Errorf("%s: unexpected error: %s", tc.name, msg)
+				}
+			}()
+			callInFrame(t, topFrame, tc.name, tc.args...)
+		}()
+	}
+}
@@ -1,7 +1,14 @@
 package funl
 
 // OperatorInfo contains information about one operator
-type OperatorInfo struct{}
+type OperatorInfo struct {
+	MinArgs  int  // minimum amount of arguments
+	MaxArgs  int  // maximum amount of arguments (AnyArgs if not limited)
+	ProcOnly bool // operator is not allowed in function
+}
+
+// AnyArgs is used as MaxArgs for operators with unlimited amount of arguments
+const AnyArgs = -1
 
 // NewOperatorDocs returns documentation for operators
 func NewOperatorDocs() map[string]string {
@@ -44,6 +51,35 @@ Operator: call
   Return value is return value of function/procedure. 
 
 Usage: call(<func/proc> <arg-1> <arg-2> ...)  
+
+  Function/procedure definition can declare arguments as:
+    <symbol>                : required argument
+    <symbol> = <expr>       : optional argument, <expr> is evaluated if
+                              argument is not given (after required ones)
+    <symbol>:               : rest argument, list of remaining arguments
+                              (after other positional arguments)
+    map(<symbol> ...)       : keyword arguments (last in argument list),
+                              given in map as last argument of call where
+                              key is symbol name (string), default value
+                              can be given as: map(<symbol> = <expr>)
+                              (map is keyword arguments only if all
+                              positional and optional arguments are given,
+                              otherwise map is value of next optional
+                              argument, so optional arguments need to be
+                              given when keyword arguments are used)
+  It's runtime error to give too many arguments unless there's rest
+  argument (or argslist is used in function/procedure).
+
+Example:
+  call(func(a b = 10) plus(a b) end 1) -> 11
+  call(func(a rest:) rest end 1 2 3) -> list(2, 3)
+  call(func(url map(timeout = 5)) timeout end 'x' map('timeout' 20)) -> 20
+  call(func(a b = 0 map(k = 1)) list(b k) end 1 map('k' 2)) -> list(map('k' : 2), 1)
+  call(func(a b = 0 map(k = 1)) list(b k) end 1 0 map('k' 2)) -> list(0, 2)
+
+Note. call in tail position (function/procedure body, or last operand
+      of if, case or cond in tail position) does not consume call stack,
+      so recursion (also mutual recursion) can be used for looping.
 `,
 		"not": `
 Operator: not
@@ -67,6 +103,7 @@ Operator: eq
 Usage: eq(<expr-1> <expr-2> ...)
 
 Note. not all types are comparable (function/procedure values)
+Note. int and decimal values are compared by value (eq(1 conv(1 'decimal')) -> true)
 `,
 		"if": `
 Operator: if
@@ -90,12 +127,17 @@ Operator: plus
   Input arguments can be of type:
     - int: evaluates to arithmetic sum
     - float: evaluates to arithmetic sum
+    - decimal: evaluates to arithmetic sum
     - string: concatenation of argument strings
 
   Number of arguments need to be at least 1.
   Return value is of type int/float/string depending on type
   of input arguments.
 
+Note. int values are not limited in size: if result does not fit
+      to machine integer it is promoted to big integer (type is still int).
+      decimal and int can be mixed, result is then decimal.
+
 Usage: plus(<expr-1> <expr-2> <expr-3> ...)
 `,
 		"minus": `
@@ -106,11 +148,16 @@ Operator: minus
   Input arguments can be of type:
     - int
     - float
+    - decimal
 
   Number of arguments need to be 2.
   Return value is result of subtraction of type int/float
   depending on type of input arguments.
 
+Note. int values are not limited in size: if result does not fit
+      to machine integer it is promoted to big integer (type is still int).
+      decimal and int can be mixed, result is then decimal.
+
 Usage: minus(<expr-1> <expr-2>)
 `,
 		"mul": `
@@ -119,11 +166,16 @@ Operator: mul
   Input arguments can be of type:
     - int
     - float
+    - decimal
 
   Number of arguments need to be at least 1.
   Return value is multiplication result of input arguments.
   If any of arguments is of type float then result value type is float.
 
+Note. int values are not limited in size: if result does not fit
+      to machine integer it is promoted to big integer (type is still int).
+      decimal and int can be mixed, result is then decimal.
+
 Usage: mul(<expr-1> <expr-2> <expr-3> ...)
 `,
 		"div": `
@@ -134,7 +186,9 @@ Operator: div
   Input arguments can be of type:
     - int
     - float
+    - decimal
   If both arguments are of type int then result is of type int,
+  if decimal and int (or decimal) are divided result is exact decimal,
   otherwise result is of type float.
   In case of division of two int's result is quotient of division
   operation.
@@ -153,12 +207,14 @@ Operator: mod
   Performs modulo operation for two input arguments, result is
   remainder of division operation where 1st argument is dividend
   and 2nd argument is divisor.
-  Input arguments need to be of type int.
+  Input arguments need to be of type int or decimal,
+  if either is decimal result is decimal.
 
   Number of arguments need to be 2.
-  Return value is remainder value of division of input arguments.
+  Return value is remainder value of division of input arguments
+  (sign of remainder follows dividend).
 
-Note. If divisor is zero (int or float) runtime error
+Note. If divisor is zero (int or decimal) runtime error
       is generated.
 
 Usage: mod(<expr-1> <expr-2>)
@@ -269,6 +325,7 @@ Operator: type
   depending on argument value type:
     - int: 'int'
     - float: 'float'
+    - decimal: 'decimal'
     - bool: 'bool'
     - string: 'string'
     - function: 'function' (also for procedure)
@@ -309,6 +366,7 @@ Operator: ind
   in location defined by index value given as 2nd argument (int).
   This means that if 1st argument is:
     - list: returns item in list in location defined by index
+    - vector: returns item in vector in location defined by index
     - string: returns character (string) located in location defined by index
 
   Number of arguments need to be 2.
@@ -493,8 +551,13 @@ Operator: conv
   having one of follwong values:
     - 'string' : converts 1st argument to string
     - 'list' : converts string to list containing all string characters as items
-    - 'float' : converts int value or string value to float value
+               (or vector/set to list)
+    - 'vector' : converts list to vector
+    - 'set' : converts list or vector to set
+    - 'float' : converts int value, decimal value or string value to float value
+    - 'decimal' : converts int, float or string value to exact decimal value
     - 'int' : converts float value to int or string value to int
+              (big values are converted to big integer)
     - 'hexint' : converts hexadecimal string value to int
     - 'inthex' : converts int value to hexadecimal string
   Return value is converted value.
@@ -505,6 +568,7 @@ Examples:
   conv('abcd' 'list') -> list('a', 'b', 'c', 'd')
   conv(100 'float') -> 100 (float)
   conv('10.25' 'float') -> 10.25 (float)
+  conv('10.25' 'decimal') -> 10.25 (decimal)
   conv(10.5 'int') -> 10
   conv('100' 'int') -> 100
   conv('abc' 'int') -> 'Not able to convert to int'
@@ -575,9 +639,19 @@ Example:
   error('...some error...' list(1 2 3)) -> Runtime error:  ...some error...list(1, 2, 3)
   error('...some error...' list(1 2 3):) -> Runtime error:  ...some error...123
 
+  If only argument is map then it's raised as error value (see trye -operator),
+  map can contain following keys:
+    'kind'    -> kind of error as string (default is 'error')
+    'message' -> error text (default is map as string, like in earlier versions)
+    'data'    -> any value given with error
+
+Example:
+  error(map('kind' 'not-found' 'message' 'no such user' 'data' 123))
+
 Usage: error()
        error(<expr>)
        error(<expr> <expr> <expr> ...)
+       error(<map-expr>)
 `,
 		"print": `
 Operator: print
@@ -1073,6 +1147,7 @@ Operator: argslist
 
   Requires no arguments. Return value is list type. List contains
   all argument values in same order as given in function/procedure call.
+  Function/procedure which uses argslist accepts any amount of arguments.
 
 Example:
   call(func() argslist() end 1 2 3) -> list(1, 2, 3)
@@ -1120,6 +1195,142 @@ Usage: cond(
          ...
          <default/else-expr>
        )
+`,
+		"match": `
+Operator: match
+  Pattern matching expression. First argument is value which is
+  matched against patterns in order. Expression following first
+  matching pattern is evaluated and returned from match -operator.
+  Symbols bound by pattern are visible in that expression.
+
+  Patterns can be:
+    <literal>                    : int, float, string, true or false,
+                                   matches if value is equal to it
+    _                            : matches any value
+    <symbol>                     : matches any value and binds it to symbol
+    list(<pattern> ...)          : matches list with same amount of items
+    list(<pattern> ... <rest>:)  : matches list with at least given amount
+                                   of items, rest of list is bound to <rest>
+                                   (symbol or _)
+    map(<key> <pattern> ...)     : matches map which has all given keys
+                                   (key is literal)
+    type(<type-name>)            : matches if type of value is <type-name>
+    type(<type-name> <pattern>)  : same as above but value is also matched
+                                   with <pattern>
+    when(<pattern> <guard-expr>) : matches if <pattern> matches and guard
+                                   expression evaluates to true (only at top
+                                   level of pattern)
+
+  Same symbol can be bound only once in pattern and it may not
+  overlap with other symbols visible in scope.
+  If no pattern matches value runtime error is raised.
+
+Example:
+  match(list(1 2 3) list(x y rest:) plus(x y)) -> 3
+  match(map('name' 'Bob') map('name' n) n) -> 'Bob'
+  match(10 type('string' s) s when(n gt(n 5)) 'big' _ 'small') -> 'big'
+
+Usage: match(
+         <value>
+         <pattern> <expr>
+         <pattern> <expr>
+         ...
+       )
+`,
+		"rectype": `
+Operator: rectype
+  Defines record type. 1st argument is name of record type (string) and
+  2nd argument is schema which is map of field names (strings) to list of
+  checks (same as in stdmeta map schema):
+    - list('required') : field is required
+    - list('type' <type-name>) : field value should be of given type
+    - list('in' <value-1> <value-2> ...) : field value should be one of given values
+    - list('map' <map-schema>) : field value should be map matching given schema
+    - list('doc' <string> ...) : documentation
+    - list('default' <value>) : value for field if it's not given
+
+  Return value is constructor function for records of the type.
+  Constructor is called with map of field values (or without arguments),
+  it validates fields and returns record value (runtime error is generated
+  if there are unknown fields or validation fails).
+
+  Type of record (by type -operator) is record type name.
+  Fields are accessed with get, getl, in, keys, vals, keyvals and len operators
+  as with maps. Records are equal if they are of same record type and
+  all fields are equal.
+
+Example:
+  person = rectype('person' map(
+    'name' list(list('required') list('type' 'string'))
+    'age'  list(list('type' 'int') list('default' 0))
+  ))
+  bob = call(person map('name' 'Bob'))
+  type(bob) -> 'person'
+  get(bob 'age') -> 0
+
+Usage: rectype(<name-string> <schema-map>)
+`,
+		"vector": `
+Operator: vector
+  Returns vector which contains given arguments as items.
+  Vector is persistent sequence which has fast (O(log n)) random access,
+  update and append, and fast (O(1)) len, slice, rest and rrest.
+
+  Vector can be used with same operators as list:
+  ind, slice, len, head, last, rest, rrest, append, extend,
+  empty, in, find, reverse, eq and str.
+  Item in given index can be replaced with setind -operator.
+  conv can be used to convert list to vector and vice versa.
+
+Examples:
+  v = vector(10 20 30)
+  ind(v 1) -> 20
+  append(v 40) -> vector(10, 20, 30, 40)
+  slice(v 1) -> vector(20, 30)
+  conv(v 'list') -> list(10, 20, 30)
+  conv(list(1 2) 'vector') -> vector(1, 2)
+  type(v) -> 'vector'
+
+Usage: vector(<expr> <expr> ...)
+`,
+		"setind": `
+Operator: setind
+  Returns new vector (1st argument) where item in given index
+  (2nd argument) is replaced with given value (3rd argument).
+  Original vector is not changed.
+
+  If index is out of range then runtime error is generated.
+
+Example:
+  setind(vector(1 2 3) 0 'x') -> vector('x', 2, 3)
+
+Usage: setind(<vector> <index:int> <expr>)
+`,
+		"set": `
+Operator: set
+  Returns set which contains given arguments as items
+  (duplicate values are included only once).
+  Set items can be any values which can be used as map keys.
+
+  Operators which can be used with sets:
+    - in : checks if value is in set
+    - len : number of items in set
+    - empty : true if set has no items
+    - keys : returns items of set as list
+    - eq : sets are equal if they have same items
+    - str : set as string
+  conv can be used to convert list or vector to set and set to list.
+  More set operations (add, remove, union, intersection, difference, subset)
+  are in stdpset module.
+
+Examples:
+  s = set(1 2 3 2)
+  len(s) -> 3
+  in(s 2) -> true
+  eq(set(1 2) set(2 1)) -> true
+  type(s) -> 'set'
+
+Usage: set(<expr> <expr> ...)
 `,
 		"defer": `
 Operator: defer
@@ -1138,6 +1349,41 @@ Example:
   force(defer(mul(2 3))) -> 6
 
 Usage: defer(<expression>)
+`,
+		"trye": `
+Operator: trye
+  Similar to tryl -operator but error is returned as map value
+  which contains following keys:
+    'kind'      -> kind of error as string ('rte' for runtime errors, 'error' for
+                   ones raised by error -operator unless other kind given)
+    'message'   -> error text (without source position)
+    'file'      -> source file in which error happened
+    'line'      -> line in which error happened (0 if not known)
+    'col'       -> column in which error happened (0 if not known)
+    'backtrace' -> list of calls (same as stdrun.backtrace returns)
+    'data'      -> value given by error -operator (only if given)
+
+  If error kinds are given as additional arguments (strings) then only errors
+  of those kinds are caught, others are raised further.
+
+  Requires one or more arguments, first argument can be of any type, others
+  are assumed to be strings.
+  Return value is list of following values (in this order):
+  1) bool: true if no error happened, false if error happened
+  2) map: error value in case error happened (empty string if no error)
+  3) value: if no error happened then result value of argument evaluated
+     (empty string if error happened)
+
+Note. trye is not allowed to be called from function (only procedure allowed),
+      otherwise runtime error is generated.
+
+Example:
+  trye(error(map('kind' 'my-error' 'data' 10))) -> list(false, map('kind' : 'my-error', 'data' : 10, ...), '')
+  trye(mul(2 3) 'my-error') -> list(true, '', 6)
+  trye(mul(2 'x') 'my-error') -> Runtime error: Invalid type for mul
+
+Usage: trye(<expr>)
+       trye(<expr> <kind-string> <kind-string> ...)
 `,
 		"force": `
 Operator: force
@@ -1190,73 +1436,79 @@ type Operators map[string]OperatorInfo
 // NewDefaultOperators returns default set of operators
 func NewDefaultOperators() Operators {
 	return Operators{
-		"and":      OperatorInfo{},
-		"or":       OperatorInfo{},
-		"call":     OperatorInfo{},
-		"not":      OperatorInfo{},
-		"eq":       OperatorInfo{},
-		"if":       OperatorInfo{},
-		"plus":     OperatorInfo{},
-		"minus":    OperatorInfo{},
-		"mul":      OperatorInfo{},
-		"div":      OperatorInfo{},
-		"mod":      OperatorInfo{},
-		"list":     OperatorInfo{},
-		"empty":    OperatorInfo{},
-		"head":     OperatorInfo{},
-		"last":     OperatorInfo{},
-		"rest":     OperatorInfo{},
-		"append":   OperatorInfo{},
-		"add":      OperatorInfo{},
-		"len":      OperatorInfo{},
-		"type":     OperatorInfo{},
-		"in":       OperatorInfo{},
-		"ind":      OperatorInfo{},
-		"find":     OperatorInfo{},
-		"slice":    OperatorInfo{},
-		"rrest":    OperatorInfo{},
-		"reverse":  OperatorInfo{},
-		"extend":   OperatorInfo{},
-		"split":    OperatorInfo{},
-		"gt":       OperatorInfo{},
-		"lt":       OperatorInfo{},
-		"le":       OperatorInfo{},
-		"ge":       OperatorInfo{},
-		"str":      OperatorInfo{},
-		"conv":     OperatorInfo{},
-		"case":     OperatorInfo{},
-		"name":     OperatorInfo{},
-		"error":    OperatorInfo{},
-		"print":    OperatorInfo{},
-		"spawn":    OperatorInfo{},
-		"chan":     OperatorInfo{},
-		"send":     OperatorInfo{},
-		"recv":     OperatorInfo{},
-		"symval":   OperatorInfo{},
-		"try":      OperatorInfo{},
-		"tryl":     OperatorInfo{},
-		"select":   OperatorInfo{},
-		"eval":     OperatorInfo{},
-		"while":    OperatorInfo{},
-		"float":    OperatorInfo{},
-		"map":      OperatorInfo{},
-		"put":      OperatorInfo{},
-		"get":      OperatorInfo{},
-		"getl":     OperatorInfo{},
-		"keys":     OperatorInfo{},
-		"vals":     OperatorInfo{},
-		"keyvals":  OperatorInfo{},
-		"let":      OperatorInfo{},
-		"imp":      OperatorInfo{},
-		"del":      OperatorInfo{},
-		"dell":     OperatorInfo{},
-		"sprintf":  OperatorInfo{},
-		"argslist": OperatorInfo{},
-		"cond":     OperatorInfo{},
-		"help":     OperatorInfo{},
-		"recwith":  OperatorInfo{},
-		"defer":    OperatorInfo{},
-		"force":    OperatorInfo{},
+		"and":      OperatorInfo{MinArgs: 1, MaxArgs: AnyArgs},
+		"or":       OperatorInfo{MinArgs: 1, MaxArgs: AnyArgs},
+		"call":     OperatorInfo{MinArgs: 1, MaxArgs: AnyArgs},
+		"not":      OperatorInfo{MinArgs: 1, MaxArgs: 1},
+		"eq":       OperatorInfo{MinArgs: 2, MaxArgs: AnyArgs},
+		"if":       OperatorInfo{MinArgs: 3, MaxArgs: 3},
+		"plus":     OperatorInfo{MinArgs: 1, MaxArgs: AnyArgs},
+		"minus":    OperatorInfo{MinArgs: 2, MaxArgs: 2},
+		"mul":      OperatorInfo{MinArgs: 1, MaxArgs: AnyArgs},
+		"div":      OperatorInfo{MinArgs: 2, MaxArgs: 2},
+		"mod":      OperatorInfo{MinArgs: 2, MaxArgs: 2},
+		"list":     OperatorInfo{MinArgs: 0, MaxArgs: AnyArgs},
+		"empty":    OperatorInfo{MinArgs: 1, MaxArgs: 1},
+		"head":     OperatorInfo{MinArgs: 1, MaxArgs: 1},
+		"last":     OperatorInfo{MinArgs: 1, MaxArgs: 1},
+		"rest":     OperatorInfo{MinArgs: 1, MaxArgs: 1},
+		"append":   OperatorInfo{MinArgs: 1, MaxArgs: AnyArgs},
+		"add":      OperatorInfo{MinArgs: 1, MaxArgs: AnyArgs},
+		"len":      OperatorInfo{MinArgs: 1, MaxArgs: 1},
+		"type":     OperatorInfo{MinArgs: 1, MaxArgs: 1},
+		"in":       OperatorInfo{MinArgs: 2, MaxArgs: 2},
+		"ind":      OperatorInfo{MinArgs: 2, MaxArgs: 2},
+		"find":     OperatorInfo{MinArgs: 2, MaxArgs: 2},
+		"slice":    OperatorInfo{MinArgs: 2, MaxArgs: 3},
+		"rrest":    OperatorInfo{MinArgs: 1, MaxArgs: 1},
+		"reverse":  OperatorInfo{MinArgs: 1, MaxArgs: 1},
+		"extend":   OperatorInfo{MinArgs: 0, MaxArgs: AnyArgs},
+		"split":    OperatorInfo{MinArgs: 1, MaxArgs: 2},
+		"gt":       OperatorInfo{MinArgs: 2, MaxArgs: 2},
+		"lt":       OperatorInfo{MinArgs: 2, MaxArgs: 2},
+		"le":       OperatorInfo{MinArgs: 2, MaxArgs: 2},
+		"ge":       OperatorInfo{MinArgs: 2, MaxArgs: 2},
+		"str":      OperatorInfo{MinArgs: 1, MaxArgs: 1},
+		"conv":     OperatorInfo{MinArgs: 2, MaxArgs: 2},
+		"case":     OperatorInfo{MinArgs: 2, MaxArgs: AnyArgs},
+		"name":     OperatorInfo{MinArgs: 1, MaxArgs: 1},
+		"error":    OperatorInfo{MinArgs: 0, MaxArgs: AnyArgs},
+		"print":    OperatorInfo{MinArgs: 0, MaxArgs: AnyArgs},
+		"spawn":    OperatorInfo{MinArgs: 0, MaxArgs: AnyArgs, ProcOnly: true},
+		"chan":     OperatorInfo{MinArgs: 0, MaxArgs: 1},
+		"send":     OperatorInfo{MinArgs: 2, MaxArgs: 3, ProcOnly: true},
+		"recv":     OperatorInfo{MinArgs: 1, MaxArgs: 1},
+		"symval":   OperatorInfo{MinArgs: 1, MaxArgs: 1, ProcOnly: true},
+		"try":      OperatorInfo{MinArgs: 1, MaxArgs: 2, ProcOnly: true},
+		"tryl":     OperatorInfo{MinArgs: 1, MaxArgs: 1, ProcOnly: true},
+		"select":   OperatorInfo{MinArgs: 2, MaxArgs: AnyArgs},
+		"eval":     OperatorInfo{MinArgs: 1, MaxArgs: 1},
+		"while":    OperatorInfo{MinArgs: 2, MaxArgs: AnyArgs},
+		"float":    OperatorInfo{MinArgs: 1, MaxArgs: 1},
+		"map":      OperatorInfo{MinArgs: 0, MaxArgs: AnyArgs},
+		"put":      OperatorInfo{MinArgs: 3, MaxArgs: 3},
+		"get":      OperatorInfo{MinArgs: 2, MaxArgs: 3},
+		"getl":     OperatorInfo{MinArgs: 2, MaxArgs: 2},
+		"keys":     OperatorInfo{MinArgs: 1, MaxArgs: 1},
+		"vals":     OperatorInfo{MinArgs: 1, MaxArgs: 1},
+		"keyvals":  OperatorInfo{MinArgs: 1, MaxArgs: 1},
+		"let":      OperatorInfo{MinArgs: 2, MaxArgs: 2},
+		"imp":      OperatorInfo{MinArgs: 1, MaxArgs: 1},
+		"del":      OperatorInfo{MinArgs: 2, MaxArgs: 2},
+		"dell":     OperatorInfo{MinArgs: 2, MaxArgs: 2},
+		"sprintf":  OperatorInfo{MinArgs: 1, MaxArgs: AnyArgs},
+		"argslist": OperatorInfo{MinArgs: 0, MaxArgs: 0},
+		"cond":     OperatorInfo{MinArgs: 3, MaxArgs: AnyArgs},
+		"help":     OperatorInfo{MinArgs: 0, MaxArgs: 1},
+		"recwith":  OperatorInfo{MinArgs: 2, MaxArgs: 2},
+		"defer":    OperatorInfo{MinArgs: 1, MaxArgs: 1},
+		"force":    OperatorInfo{MinArgs: 1, MaxArgs: 1},
+		"trye":     OperatorInfo{MinArgs: 1, MaxArgs: AnyArgs, ProcOnly: true},
+		"match":    OperatorInfo{MinArgs: 3, MaxArgs: AnyArgs},
+		"rectype":  OperatorInfo{MinArgs: 2, MaxArgs: 2},
+		"vector":   OperatorInfo{MinArgs: 0, MaxArgs: AnyArgs},
+		"setind":   OperatorInfo{MinArgs: 3, MaxArgs: 3},
+		"set":      OperatorInfo{MinArgs: 0, MaxArgs: AnyArgs},
 	}
 }
 
@@ -2,9 +2,12 @@ package funl
 
 import (
 	"fmt"
+	"math/big"
 	"sort"
 	"strconv"
 	"strings"
+
+	"github.com/anssihalmeaho/funl/pvec"
 )
 
 func handleArgslistOP(frame *Frame, operands []*Item) (retVal Value) {
@@ -23,7 +26,7 @@ func handleDeferOP(frame *Frame, operands []*Item) (retVal Value) {
 	}
 	retVal = Value{
 		Kind: ThunkValue,
-		Data: &Thunk{Expr: operands[0], AccessLink: frame},
+		Data: &Thunk{Expr: operands[0], AccessLink: frame.forOtherGoroutine()},
 	}
 	return
 }
@@ -109,6 +112,8 @@ func handleSprintfOP(frame *Frame, operands []*Item) (retVal Value) {
 		switch srcVal.Kind {
 		case IntValue:
 			args = append(args, srcVal.Data.(int))
+		case BigIntValue:
+			args = append(args, srcVal.Data.(*big.Int))
 		case StringValue:
 			args = append(args, srcVal.Data.(string))
 		case FloatValue:
@@ -210,6 +215,8 @@ func handleFloatOP(frame *Frame, operands []*Item) (retVal Value) {
 	case IntValue:
 		intv := srcVal.Data.(int)
 		retVal = Value{Kind: FloatValue, Data: float64(intv)}
+	case BigIntValue, DecimalValue:
+		retVal = Value{Kind: FloatValue, Data: numberToFloat(srcVal)}
 	case FloatValue:
 		retVal = srcVal
 	default:
@@ -252,6 +259,7 @@ func handleEvalOP(frame *Frame, operands []*Item) (retVal Value) {
 	}
 
 	parser := NewParser(NewDefaultOperators(), nil)
+	parser.SetSymIDConverter(frame.SymIDs())
 	parser.SetErrorHandler(&evalErrHandler{})
 	evalItem, err := parser.ParseOneExpression(srcVal.Data.(string))
 	if err != nil {
@@ -278,7 +286,7 @@ func handleImpOP(frame *Frame, operands []*Item) (retVal Value) {
 		modName = srcVal.Data.(string)
 	case SymbolPathItem:
 		sp := v.Data.(SymbolPath)
-		modName = sp.ToString()
+		modName = sp.ToStringWith(frame.SymIDs())
 	default:
 		runTimeError2(frame, "%s: assuming symbol as 1st argument", opName)
 	}
@@ -287,7 +295,7 @@ func handleImpOP(frame *Frame, operands []*Item) (retVal Value) {
 		runTimeError2(frame, "%s: not supported at main level", opName)
 		return
 	}
-	sid := SymIDMap.Add(modName)
+	sid := frame.SymIDs().Add(modName)
 	if _, modFound := frame.FuncProto.NSpace.OtherNS[sid]; !modFound {
 		frame.FuncProto.NSpace.OtherNS[sid] = ImportInfo{} // path added when given
 	}
@@ -298,7 +306,7 @@ func handleImpOP(frame *Frame, operands []*Item) (retVal Value) {
 	var moperands []*Item
 	if found {
 		for k, v := range symt.AsMap() {
-			symNameVal := &Item{Type: ValueItem, Data: Value{Kind: StringValue, Data: SymIDMap.AsString(k)}}
+			symNameVal := &Item{Type: ValueItem, Data: Value{Kind: StringValue, Data: frame.SymIDs().AsString(k)}}
 			moperands = append(moperands, symNameVal, v)
 		}
 	}
@@ -316,25 +324,32 @@ func handleLetOP(frame *Frame, operands []*Item) (retVal Value) {
 	switch v := operands[0]; v.Type {
 	case SymbolPathItem:
 		sp := v.Data.(SymbolPath)
-		symbolName = sp.ToString()
+		symbolName = sp.ToStringWith(frame.SymIDs())
 	default:
 		runTimeError2(frame, "%s: assuming symbol as 1st argument", opName)
 	}
 
-	sid := SymIDMap.Add(symbolName)
+	sid := frame.SymIDs().Add(symbolName)
 	// Overlapping symbol names not allowed (might cause variable -like effect)
 	if _, symfound := frame.GetSymItem(sid); symfound {
-		runTimeError2(frame, "%s: Duplicate symbol name in scope not allowed (%s)", opName, SymIDMap.AsString(sid))
+		runTimeError2(frame, "%s: Duplicate symbol name in scope not allowed (%s)", opName, frame.SymIDs().AsString(sid))
 	}
 
 	// lets evaluate let def. to value first, using new frame already (as arguments are there)
-	letvalitem := &Item{Type: ValueItem, Data: EvalItem(operands[1], frame)}
-	if !frame.Syms.AddBySID(sid, letvalitem) {
+	retVal = EvalItem(operands[1], frame)
+	addLetSym(frame, sid, retVal)
+	return
+}
+
+// addLetSym adds symbol defined by let -operator to frame
+func addLetSym(frame *Frame, sid SymID, val Value) {
+	if frame.Syms == nil {
+		frame.Syms = NewSymtWithConverter(frame.SymIDs())
+	}
+	frame.hasLetSyms = true
+	if !frame.Syms.AddBySID(sid, &Item{Type: ValueItem, Data: val}) {
 		runTimeError2(frame, "Symbol add failed")
 	}
-
-	retVal = letvalitem.Data.(Value)
-	return
 }
 
 func handleSymvalOP(frame *Frame, operands []*Item) (retVal Value) {
@@ -363,7 +378,7 @@ func handleSymvalOP(frame *Frame, operands []*Item) (retVal Value) {
 	}
 	var sp SymbolPath
 	for _, symstr := range strings.Split(srcVal.Data.(string), ".") {
-		symsid, found := SymIDMap.Get(symstr)
+		symsid, found := frame.SymIDs().Get(symstr)
 		if !found {
 			runTimeError2(frame, "%s: symbol not found (%s)", opName, srcVal.Data.(string))
 		}
@@ -377,10 +392,10 @@ func handleSymvalOP(frame *Frame, operands []*Item) (retVal Value) {
 	} else if len(sp) > 1 {
 		symItem, symfound = frame.GetImportedSymItem(sp[0], sp[1:])
 	} else {
-		runTimeError2(frame, "symbol not found: %s", sp.ToString())
+		runTimeError2(frame, "symbol not found: %s", sp.ToStringWith(frame.SymIDs()))
 	}
 	if !symfound {
-		runTimeError2(frame, "symbol not found: %s", sp.ToString())
+		runTimeError2(frame, "symbol not found: %s", sp.ToStringWith(frame.SymIDs()))
 	}
 
 	switch symItem.Type {
@@ -395,12 +410,13 @@ func handleSymvalOP(frame *Frame, operands []*Item) (retVal Value) {
 	return
 }
 
+// PrintingDisabledInFunctions is default for interpreter setting
 var PrintingDisabledInFunctions bool
 
 func handlePrintOP(frame *Frame, operands []*Item) (retVal Value) {
 	opName := "print"
 
-	if PrintingDisabledInFunctions {
+	if frame.Settings().PrintingDisabledInFunctions {
 		if !frame.inProcCall {
 			retVal = Value{Kind: BoolValue, Data: true}
 			return
@@ -433,17 +449,24 @@ func handlePrintOP(frame *Frame, operands []*Item) (retVal Value) {
 func handleErrorOP(frame *Frame, operands []*Item) (retVal Value) {
 	opName := "error"
 
-	errorText := ""
+	var vals []Value
 	for _, v := range operands {
-		var val Value
 		switch v.Type {
 		case ValueItem:
-			val = v.Data.(Value)
+			vals = append(vals, v.Data.(Value))
 		case SymbolPathItem, OperCallItem:
-			val = EvalItem(v, frame)
+			vals = append(vals, EvalItem(v, frame))
 		default:
 			runTimeError2(frame, "something wrong (%s)", opName)
 		}
+	}
+	// map as only argument is raised as error value
+	if len(vals) == 1 && vals[0].Kind == MapValue {
+		raiseRTE(frame, errorFromMap(frame, opName, vals[0]))
+	}
+
+	errorText := ""
+	for _, val := range vals {
 		switch val.Kind {
 		case StringValue:
 			errorText += val.Data.(string)
@@ -451,7 +474,8 @@ func handleErrorOP(frame *Frame, operands []*Item) (retVal Value) {
 			errorText += val.String()
 		}
 	}
-	runTimeError2(frame, "%s", errorText)
+	// error raised by program is given as such (without source position)
+	raiseRTE(frame, &RuntimeError{Kind: ErrorKind, Message: errorText, userText: true})
 	return
 }
 
@@ -465,7 +489,7 @@ func handleNameOP(frame *Frame, operands []*Item) (retVal Value) {
 	switch v.Type {
 	case SymbolPathItem:
 		sp := v.Data.(SymbolPath)
-		retVal = Value{Kind: StringValue, Data: sp.ToString()}
+		retVal = Value{Kind: StringValue, Data: sp.ToStringWith(frame.SymIDs())}
 	case ValueItem:
 		runTimeError2(frame, "%s: symbol assumed as argument, not value", opName)
 	case OperCallItem:
@@ -477,6 +501,20 @@ func handleNameOP(frame *Frame, operands []*Item) (retVal Value) {
 }
 
 func handleCondOP(frame *Frame, operands []*Item) (retVal Value) {
+	v := selectCondBranch(frame, operands)
+	switch v.Type {
+	case ValueItem:
+		retVal = v.Data.(Value)
+	case SymbolPathItem, OperCallItem:
+		retVal = EvalItem(v, frame)
+	default:
+		runTimeError2(frame, "something wrong (%s)", "cond")
+	}
+	return
+}
+
+// selectCondBranch evaluates conditions and returns expression to be evaluated
+func selectCondBranch(frame *Frame, operands []*Item) *Item {
 	opName := "cond"
 	argCount := len(operands)
 	if l := argCount; l < 2 {
@@ -497,37 +535,32 @@ func handleCondOP(frame *Frame, operands []*Item) (retVal Value) {
 		default:
 			runTimeError2(frame, "something wrong (%s)", opName)
 		}
-
 		if condVal.Kind != BoolValue {
 			runTimeError2(frame, "%s: compared value assumed to be bool value (%d)", opName, i)
 		}
 		if condVal.Data.(bool) == true {
-			v = operands[i+1]
-			switch v.Type {
-			case ValueItem:
-				retVal = v.Data.(Value)
-			case SymbolPathItem, OperCallItem:
-				retVal = EvalItem(v, frame)
-			default:
-				runTimeError2(frame, "something wrong (%s)", opName)
-			}
-			return
+			return operands[i+1]
 		}
 	}
 	// no matches so lets return else expression
-	v := operands[argCount-1]
+	return operands[argCount-1]
+}
+
+func handleCaseOP(frame *Frame, operands []*Item) (retVal Value) {
+	v := selectCaseBranch(frame, operands)
 	switch v.Type {
 	case ValueItem:
 		retVal = v.Data.(Value)
 	case SymbolPathItem, OperCallItem:
 		retVal = EvalItem(v, frame)
 	default:
-		runTimeError2(frame, "something wrong (%s)", opName)
+		runTimeError2(frame, "something wrong (%s)", "case")
 	}
 	return
 }
 
-func handleCaseOP(frame *Frame, operands []*Item) (retVal Value) {
+// selectCaseBranch finds matching value and returns expression to be evaluated
+func selectCaseBranch(frame *Frame, operands []*Item) *Item {
 	opName := "case"
 	argCount := len(operands)
 	if l := argCount; l < 2 {
@@ -577,35 +610,16 @@ func handleCaseOP(frame *Frame, operands []*Item) (retVal Value) {
 			runTimeError2(frame, "Invalid result from eq")
 		}
 		if eqResult.Data.(bool) == true {
-			v = operands[i+1]
-			switch v.Type {
-			case ValueItem:
-				retVal = v.Data.(Value)
-			case SymbolPathItem, OperCallItem:
-				retVal = EvalItem(v, frame)
-			default:
-				runTimeError2(frame, "something wrong (%s)", opName)
-			}
-			return
+			return operands[i+1]
 		}
-
 	}
 
 	if hasDefault {
-		v = operands[argCount-1]
-		switch v.Type {
-		case ValueItem:
-			retVal = v.Data.(Value)
-		case SymbolPathItem, OperCallItem:
-			retVal = EvalItem(v, frame)
-		default:
-			runTimeError2(frame, "something wrong (%s)", opName)
-		}
-		return
+		return operands[argCount-1]
 	}
 
 	runTimeError2(frame, "%s: could not evaluate value", opName)
-	return
+	return nil
 }
 
 func handleConvOP(frame *Frame, operands []*Item) (retVal Value) {
@@ -658,6 +672,10 @@ func handleConvOP(frame *Frame, operands []*Item) (retVal Value) {
 		switch srcVal.Kind {
 		case ListValue:
 			retVal = srcVal
+		case VectorValue:
+			retVal = vectorToList(frame, srcVal)
+		case SetValue:
+			retVal = MakeListOfValues(frame, SetValues(frame, srcVal))
 		case StringValue:
 			targetList := &List{}
 			var previtem *ListObject
@@ -676,12 +694,58 @@ func handleConvOP(frame *Frame, operands []*Item) (retVal Value) {
 		default:
 			runTimeError2(frame, "%s: unsupported source type for converting %s", opName, trgType)
 		}
+	case "vector":
+		switch srcVal.Kind {
+		case VectorValue:
+			retVal = srcVal
+		case ListValue:
+			retVal = listToVector(srcVal)
+		default:
+			runTimeError2(frame, "%s: unsupported source type for converting %s", opName, trgType)
+		}
+	case "set":
+		switch srcVal.Kind {
+		case SetValue:
+			retVal = srcVal
+		case ListValue:
+			var values []Value
+			it := NewListIterator(srcVal)
+			for next := it.Next(); next != nil; next = it.Next() {
+				values = append(values, *next)
+			}
+			retVal = MakeSetOfValues(frame, values)
+		case VectorValue:
+			retVal = MakeSetOfValues(frame, VectorValues(srcVal))
+		default:
+			runTimeError2(frame, "%s: unsupported source type for converting %s", opName, trgType)
+		}
+	case "decimal":
+		switch srcVal.Kind {
+		case DecimalValue:
+			retVal = srcVal
+		case IntValue, BigIntValue:
+			retVal = MakeDecimalValue(ToRat(srcVal))
+		case FloatValue:
+			ratv, ok := FloatToDecimal(srcVal.Data.(float64))
+			if !ok {
+				runTimeError2(frame, "%s: cannot convert to decimal (%v)", opName, srcVal.Data)
+			}
+			retVal = MakeDecimalValue(ratv)
+		case StringValue:
+			ratv, ok := ParseDecimal(srcVal.Data.(string))
+			if !ok {
+				runTimeError2(frame, "%s: cannot convert to decimal (%s)", opName, srcVal.Data)
+			}
+			retVal = MakeDecimalValue(ratv)
+		default:
+			runTimeError2(frame, "%s: unsupported source type for converting %s", opName, trgType)
+		}
 	case "float":
 		switch srcVal.Kind {
 		case FloatValue:
 			retVal = srcVal
-		case IntValue:
-			retVal = Value{Kind: FloatValue, Data: float64(srcVal.Data.(int))}
+		case IntValue, BigIntValue, DecimalValue:
+			retVal = Value{Kind: FloatValue, Data: numberToFloat(srcVal)}
 		case StringValue:
 			floatVal, err := strconv.ParseFloat(srcVal.Data.(string), 64)
 			if err != nil {
@@ -693,17 +757,18 @@ func handleConvOP(frame *Frame, operands []*Item) (retVal Value) {
 		}
 	case "int":
 		switch srcVal.Kind {
-		case IntValue:
+		case IntValue, BigIntValue:
 			retVal = srcVal
 		case FloatValue:
-			intv := int(srcVal.Data.(float64))
-			retVal = Value{Kind: IntValue, Data: intv}
+			retVal = floatToIntValue(srcVal.Data.(float64))
+		case DecimalValue:
+			ratv := srcVal.Data.(*big.Rat)
+			retVal = MakeIntValue(new(big.Int).Quo(ratv.Num(), ratv.Denom()))
 		case StringValue:
-			intv, err := strconv.Atoi(srcVal.Data.(string))
-			if err != nil {
+			var ok bool
+			retVal, ok = parseIntValue(srcVal.Data.(string))
+			if !ok {
 				retVal = Value{Kind: StringValue, Data: "Not able to convert to int"}
-			} else {
-				retVal = Value{Kind: IntValue, Data: intv}
 			}
 		default:
 			runTimeError2(frame, "%s: unsupported source type for converting %s", opName, trgType)
@@ -731,7 +796,7 @@ func handleConvOP(frame *Frame, operands []*Item) (retVal Value) {
 		}
 	case "inthex":
 		switch srcVal.Kind {
-		case IntValue:
+		case IntValue, BigIntValue:
 			retVal = Value{Kind: StringValue, Data: fmt.Sprintf("%x", srcVal.Data)}
 		default:
 			runTimeError2(frame, "%s: unsupported source type for converting %s", opName, trgType)
@@ -741,9 +806,14 @@ func handleConvOP(frame *Frame, operands []*Item) (retVal Value) {
 		case StringValue:
 			val64, err := strconv.ParseInt(srcVal.Data.(string), 16, 64)
 			if err != nil {
-				runTimeError2(frame, "%s: conversion failed: %v", opName, err)
+				bigv, ok := new(big.Int).SetString(srcVal.Data.(string), 16)
+				if !ok {
+					runTimeError2(frame, "%s: conversion failed: %v", opName, err)
+				}
+				retVal = MakeIntValue(bigv)
+			} else {
+				retVal = Value{Kind: IntValue, Data: int(val64)}
 			}
-			retVal = Value{Kind: IntValue, Data: int(val64)}
 		default:
 			runTimeError2(frame, "%s: unsupported source type for converting %s", opName, trgType)
 		}
@@ -811,12 +881,16 @@ func handleLtOP(frame *Frame, operands []*Item) (retVal Value) {
 
 	if val1.Kind == IntValue && val2.Kind == IntValue {
 		retVal = Value{Kind: BoolValue, Data: val1.Data.(int) < val2.Data.(int)}
-	} else if val1.Kind == FloatValue && val2.Kind == IntValue {
-		retVal = Value{Kind: BoolValue, Data: val1.Data.(float64) < float64(val2.Data.(int))}
-	} else if val1.Kind == IntValue && val2.Kind == FloatValue {
-		retVal = Value{Kind: BoolValue, Data: float64(val1.Data.(int)) < val2.Data.(float64)}
+	} else if val1.Kind == FloatValue && isExactNumber(val2) {
+		retVal = Value{Kind: BoolValue, Data: val1.Data.(float64) < numberToFloat(val2)}
+	} else if isExactNumber(val1) && val2.Kind == FloatValue {
+		retVal = Value{Kind: BoolValue, Data: numberToFloat(val1) < val2.Data.(float64)}
 	} else if val1.Kind == FloatValue && val2.Kind == FloatValue {
 		retVal = Value{Kind: BoolValue, Data: val1.Data.(float64) < val2.Data.(float64)}
+	} else if isExactNumber(val1) && isExactNumber(val2) {
+		retVal = Value{Kind: BoolValue, Data: compareExactNumbers(val1, val2) < 0}
+	} else if c, ok := compareOpaques(val1, val2); ok {
+		retVal = Value{Kind: BoolValue, Data: c < 0}
 	} else {
 		runTimeError2(frame, "%s: invalid types", opName)
 	}
@@ -853,12 +927,16 @@ func handleLeOP(frame *Frame, operands []*Item) (retVal Value) {
 
 	if val1.Kind == IntValue && val2.Kind == IntValue {
 		retVal = Value{Kind: BoolValue, Data: val1.Data.(int) <= val2.Data.(int)}
-	} else if val1.Kind == FloatValue && val2.Kind == IntValue {
-		retVal = Value{Kind: BoolValue, Data: val1.Data.(float64) <= float64(val2.Data.(int))}
-	} else if val1.Kind == IntValue && val2.Kind == FloatValue {
-		retVal = Value{Kind: BoolValue, Data: float64(val1.Data.(int)) <= val2.Data.(float64)}
+	} else if val1.Kind == FloatValue && isExactNumber(val2) {
+		retVal = Value{Kind: BoolValue, Data: val1.Data.(float64) <= numberToFloat(val2)}
+	} else if isExactNumber(val1) && val2.Kind == FloatValue {
+		retVal = Value{Kind: BoolValue, Data: numberToFloat(val1) <= val2.Data.(float64)}
 	} else if val1.Kind == FloatValue && val2.Kind == FloatValue {
 		retVal = Value{Kind: BoolValue, Data: val1.Data.(float64) <= val2.Data.(float64)}
+	} else if isExactNumber(val1) && isExactNumber(val2) {
+		retVal = Value{Kind: BoolValue, Data: compareExactNumbers(val1, val2) <= 0}
+	} else if c, ok := compareOpaques(val1, val2); ok {
+		retVal = Value{Kind: BoolValue, Data: c <= 0}
 	} else {
 		runTimeError2(frame, "%s: invalid types", opName)
 	}
@@ -895,12 +973,16 @@ func handleGeOP(frame *Frame, operands []*Item) (retVal Value) {
 
 	if val1.Kind == IntValue && val2.Kind == IntValue {
 		retVal = Value{Kind: BoolValue, Data: val1.Data.(int) >= val2.Data.(int)}
-	} else if val1.Kind == FloatValue && val2.Kind == IntValue {
-		retVal = Value{Kind: BoolValue, Data: val1.Data.(float64) >= float64(val2.Data.(int))}
-	} else if val1.Kind == IntValue && val2.Kind == FloatValue {
-		retVal = Value{Kind: BoolValue, Data: float64(val1.Data.(int)) >= val2.Data.(float64)}
+	} else if val1.Kind == FloatValue && isExactNumber(val2) {
+		retVal = Value{Kind: BoolValue, Data: val1.Data.(float64) >= numberToFloat(val2)}
+	} else if isExactNumber(val1) && val2.Kind == FloatValue {
+		retVal = Value{Kind: BoolValue, Data: numberToFloat(val1) >= val2.Data.(float64)}
 	} else if val1.Kind == FloatValue && val2.Kind == FloatValue {
 		retVal = Value{Kind: BoolValue, Data: val1.Data.(float64) >= val2.Data.(float64)}
+	} else if isExactNumber(val1) && isExactNumber(val2) {
+		retVal = Value{Kind: BoolValue, Data: compareExactNumbers(val1, val2) >= 0}
+	} else if c, ok := compareOpaques(val1, val2); ok {
+		retVal = Value{Kind: BoolValue, Data: c >= 0}
 	} else {
 		runTimeError2(frame, "%s: invalid types", opName)
 	}
@@ -937,12 +1019,16 @@ func handleGtOP(frame *Frame, operands []*Item) (retVal Value) {
 
 	if val1.Kind == IntValue && val2.Kind == IntValue {
 		retVal = Value{Kind: BoolValue, Data: val1.Data.(int) > val2.Data.(int)}
-	} else if val1.Kind == FloatValue && val2.Kind == IntValue {
-		retVal = Value{Kind: BoolValue, Data: val1.Data.(float64) > float64(val2.Data.(int))}
-	} else if val1.Kind == IntValue && val2.Kind == FloatValue {
-		retVal = Value{Kind: BoolValue, Data: float64(val1.Data.(int)) > val2.Data.(float64)}
+	} else if val1.Kind == FloatValue && isExactNumber(val2) {
+		retVal = Value{Kind: BoolValue, Data: val1.Data.(float64) > numberToFloat(val2)}
+	} else if isExactNumber(val1) && val2.Kind == FloatValue {
+		retVal = Value{Kind: BoolValue, Data: numberToFloat(val1) > val2.Data.(float64)}
 	} else if val1.Kind == FloatValue && val2.Kind == FloatValue {
 		retVal = Value{Kind: BoolValue, Data: val1.Data.(float64) > val2.Data.(float64)}
+	} else if isExactNumber(val1) && isExactNumber(val2) {
+		retVal = Value{Kind: BoolValue, Data: compareExactNumbers(val1, val2) > 0}
+	} else if c, ok := compareOpaques(val1, val2); ok {
+		retVal = Value{Kind: BoolValue, Data: c > 0}
 	} else {
 		runTimeError2(frame, "%s: invalid types", opName)
 	}
@@ -1067,6 +1153,13 @@ func handleSliceOP(frame *Frame, operands []*Item) (retVal Value) {
 	}
 
 	switch seqval.Kind {
+	case VectorValue:
+		end := seqval.Data.(*pvec.Vector).Len()
+		if argCount > 2 {
+			end = endIndVal.Data.(int) + 1
+		}
+		retVal = vectorSlice(frame, opName, seqval, startIndVal.Data.(int), end)
+
 	case ListValue:
 		it := NewListIterator(seqval)
 		count := 0
@@ -1142,6 +1235,9 @@ func handleFindOP(frame *Frame, operands []*Item) (retVal Value) {
 	}
 
 	switch seqval.Kind {
+	case VectorValue:
+		retVal = handleListOP(frame, vectorFind(frame, seqval, itemval, false))
+
 	case ListValue:
 		it := NewListIterator(seqval)
 		itemAsVal := &Item{Type: ValueItem, Data: itemval}
@@ -1238,6 +1334,9 @@ func handleIndOP(frame *Frame, operands []*Item) (retVal Value) {
 	}
 
 	switch seqval.Kind {
+	case VectorValue:
+		retVal = vectorItem(frame, opName, seqval, itemval.Data.(int))
+
 	case ListValue:
 		it := NewListIterator(seqval)
 		loc := itemval.Data.(int)
@@ -1294,8 +1393,15 @@ func handleInOP(frame *Frame, operands []*Item) (retVal Value) {
 	default:
 		runTimeError2(frame, "something wrong (%s)", opName)
 	}
+	seqval = recordFields(seqval)
 
 	switch seqval.Kind {
+	case SetValue:
+		retVal = Value{Kind: BoolValue, Data: seqval.Data.(*Set).Contains(frame, itemval)}
+
+	case VectorValue:
+		retVal = Value{Kind: BoolValue, Data: len(vectorFind(frame, seqval, itemval, true)) > 0}
+
 	case ListValue:
 		it := NewListIterator(seqval)
 		itemAsVal := &Item{Type: ValueItem, Data: itemval}
@@ -1368,24 +1474,60 @@ func handleTypeOP(frame *Frame, operands []*Item) (retVal Value) {
 		runTimeError2(frame, "something wrong (%s)", opName)
 	}
 
-	var typeName string
+	retVal = Value{Kind: StringValue, Data: valueTypeName(frame, opName, val)}
+	return
+}
+
+// TypeNameOf returns name of value type as type -operator returns it,
+// opaque values are named as 'opaque:<TypeName()>'
+func TypeNameOf(val Value) string {
+	if val.Kind == OpaqueValue {
+		return "opaque:" + val.Data.(OpaqueAPI).TypeName()
+	}
+	return valueTypeName(nil, "type", val)
+}
+
+// builtinTypeNames maps value kinds to type names (as type -operator),
+// record and opaque values get their name from record type or opaque value
+var builtinTypeNames = map[ValueType]string{
+	IntValue:       "int",
+	BigIntValue:    "int",
+	DecimalValue:   "decimal",
+	RecordValue:    "record",
+	FloatValue:     "float",
+	BoolValue:      "bool",
+	StringValue:    "string",
+	FuncProtoValue: "funcproto",
+	FunctionValue:  "function",
+	ListValue:      "list",
+	VectorValue:    "vector",
+	SetValue:       "set",
+	ChanValue:      "channel",
+	OpaqueValue:    "opaque",
+	MapValue:       "map",
+	ExtProcValue:   "ext-proc",
+	ThunkValue:     "thunk",
+}
+
+// isBuiltinTypeName returns true if name is reserved for built-in type
+func isBuiltinTypeName(name string) bool {
+	switch name {
+	case "", "bytearray":
+		return true
+	}
+	for _, typeName := range builtinTypeNames {
+		if name == typeName {
+			return true
+		}
+	}
+	return false
+}
+
+// valueTypeName returns name of value type (as type -operator)
+func valueTypeName(frame *Frame, opName string, val Value) (typeName string) {
 	switch val.Kind {
-	case IntValue:
-		typeName = "int"
-	case FloatValue:
-		typeName = "float"
-	case BoolValue:
-		typeName = "bool"
-	case StringValue:
-		typeName = "string"
-	case FuncProtoValue:
-		typeName = "funcproto"
-	case FunctionValue:
-		typeName = "function"
-	case ListValue:
-		typeName = "list"
-	case ChanValue:
-		typeName = "channel"
+	case RecordValue:
+		typeName = val.Data.(*Record).Type.Name
 	case OpaqueValue:
 		opaqueTypeName := val.Data.(OpaqueAPI).TypeName()
 		switch opaqueTypeName {
@@ -1397,16 +1539,12 @@ func handleTypeOP(frame *Frame, operands []*Item) (retVal Value) {
 			}
 		}
 		typeName = "opaque:" + opaqueTypeName
-	case MapValue:
-		typeName = "map"
-	case ExtProcValue:
-		typeName = "ext-proc"
-	case ThunkValue:
-		typeName = "thunk"
 	default:
-		runTimeError2(frame, "%s: unknown type (%d)", opName, val.Kind)
+		var found bool
+		if typeName, found = builtinTypeNames[val.Kind]; !found {
+			runTimeError2(frame, "%s: unknown type (%d)", opName, val.Kind)
+		}
 	}
-	retVal = Value{Kind: StringValue, Data: typeName}
 	return
 }
 
@@ -1426,9 +1564,16 @@ func handleLenOP(frame *Frame, operands []*Item) (retVal Value) {
 	default:
 		runTimeError2(frame, "something wrong (%s)", opName)
 	}
+	val = recordFields(val)
 
 	var length int
 	switch val.Kind {
+	case SetValue:
+		length = val.Data.(*Set).Len()
+
+	case VectorValue:
+		length = val.Data.(*pvec.Vector).Len()
+
 	case ListValue:
 		list, convok := val.Data.(*List)
 		if !convok {
@@ -1550,6 +1695,12 @@ func handleNotOP(frame *Frame, operands []*Item) (retVal Value) {
 }
 
 func handleIfOP(frame *Frame, operands []*Item) (retVal Value) {
+	retVal = EvalItem(selectIfBranch(frame, operands), frame)
+	return
+}
+
+// selectIfBranch evaluates condition and returns branch to be evaluated
+func selectIfBranch(frame *Frame, operands []*Item) *Item {
 	opName := "if"
 	if l := len(operands); l != 3 {
 		runTimeError2(frame, "Wrong amount of arguments for %s (%d given)", opName, l)
@@ -1567,17 +1718,21 @@ func handleIfOP(frame *Frame, operands []*Item) (retVal Value) {
 		runTimeError2(frame, "%s: condition should be boolean expression", opName)
 	}
 	if argval.Data.(bool) {
-		retVal = EvalItem(operands[1], frame)
-	} else {
-		retVal = EvalItem(operands[2], frame)
+		return operands[1]
 	}
-	return
+	return operands[2]
+}
+
+// HandleEqOP for std lib usage
+func HandleEqOP(frame *Frame, operands []*Item) (retVal Value) {
+	return handleEqOP(frame, operands)
 }
 
 func handleEqOP(frame *Frame, operands []*Item) (retVal Value) {
 	opName := "eq"
 	var argType ValueType
 	var comparedValue interface{}
+	var firstVal Value
 
 	if l := len(operands); l < 2 {
 		runTimeError2(frame, "Not enough arguments for %s (%d given)", opName, l)
@@ -1595,8 +1750,13 @@ func handleEqOP(frame *Frame, operands []*Item) (retVal Value) {
 		if i == 0 {
 			argType = argval.Kind
 			comparedValue = argval.Data
+			firstVal = argval
 		}
 		if argType != argval.Kind {
+			// int, big int and decimal are equal if they have same value
+			if isExactNumber(firstVal) && isExactNumber(argval) && compareExactNumbers(firstVal, argval) == 0 {
+				continue
+			}
 			retVal = Value{Kind: BoolValue, Data: false}
 			return
 		}
@@ -1611,6 +1771,31 @@ func handleEqOP(frame *Frame, operands []*Item) (retVal Value) {
 				retVal = Value{Kind: BoolValue, Data: false}
 				return
 			}
+		case BigIntValue:
+			if comparedValue.(*big.Int).Cmp(argval.Data.(*big.Int)) != 0 {
+				retVal = Value{Kind: BoolValue, Data: false}
+				return
+			}
+		case DecimalValue:
+			if comparedValue.(*big.Rat).Cmp(argval.Data.(*big.Rat)) != 0 {
+				retVal = Value{Kind: BoolValue, Data: false}
+				return
+			}
+		case SetValue:
+			if !areEqualSets(frame, comparedValue.(*Set), argval.Data.(*Set)) {
+				retVal = Value{Kind: BoolValue, Data: false}
+				return
+			}
+		case VectorValue:
+			if !areEqualVectors(frame, comparedValue.(*pvec.Vector), argval.Data.(*pvec.Vector)) {
+				retVal = Value{Kind: BoolValue, Data: false}
+				return
+			}
+		case RecordValue:
+			if !areEqualRecords(frame, comparedValue.(*Record), argval.Data.(*Record)) {
+				retVal = Value{Kind: BoolValue, Data: false}
+				return
+			}
 		case FloatValue:
 			if comparedValue.(float64) != argval.Data.(float64) {
 				retVal = Value{Kind: BoolValue, Data: false}
@@ -1716,7 +1901,15 @@ func handleMinusOP(frame *Frame, operands []*Item) (retVal Value) {
 	if argval1.Kind == IntValue && argval2.Kind == IntValue {
 		num1 := argval1.Data.(int)
 		num2 := argval2.Data.(int)
-		retVal = Value{Kind: IntValue, Data: num1 - num2}
+		if result, ok := subInts(num1, num2); ok {
+			retVal = Value{Kind: IntValue, Data: result}
+		} else {
+			retVal = MakeIntValue(new(big.Int).Sub(big.NewInt(int64(num1)), big.NewInt(int64(num2))))
+		}
+	} else if IsIntegerValue(argval1) && IsIntegerValue(argval2) {
+		retVal = MakeIntValue(new(big.Int).Sub(ToBigInt(argval1), ToBigInt(argval2)))
+	} else if isExactNumber(argval1) && isExactNumber(argval2) {
+		retVal = MakeDecimalValue(new(big.Rat).Sub(ToRat(argval1), ToRat(argval2)))
 	} else if argval1.Kind == FloatValue && argval2.Kind == FloatValue {
 		num1 := argval1.Data.(float64)
 		num2 := argval2.Data.(float64)
@@ -1754,24 +1947,39 @@ func handleDivOP(frame *Frame, operands []*Item) (retVal Value) {
 		runTimeError2(frame, "something wrong")
 	}
 
-	if dividend.Kind == IntValue && divisor.Kind == FloatValue {
+	if IsIntegerValue(dividend) && divisor.Kind == FloatValue {
 		floatDivisor := divisor.Data.(float64)
 		if floatDivisor == 0 {
 			runTimeError2(frame, "division by zero")
 		}
-		retVal = Value{Kind: FloatValue, Data: float64(dividend.Data.(int)) / floatDivisor}
-	} else if dividend.Kind == FloatValue && divisor.Kind == IntValue {
-		intDivisor := divisor.Data.(int)
-		if intDivisor == 0 {
+		retVal = Value{Kind: FloatValue, Data: intToFloat(dividend) / floatDivisor}
+	} else if dividend.Kind == FloatValue && IsIntegerValue(divisor) {
+		if divisor.Kind == IntValue && divisor.Data.(int) == 0 {
 			runTimeError2(frame, "division by zero")
 		}
-		retVal = Value{Kind: FloatValue, Data: dividend.Data.(float64) / float64(intDivisor)}
+		retVal = Value{Kind: FloatValue, Data: dividend.Data.(float64) / intToFloat(divisor)}
 	} else if dividend.Kind == IntValue && divisor.Kind == IntValue {
 		intDivisor := divisor.Data.(int)
 		if intDivisor == 0 {
 			runTimeError2(frame, "division by zero")
 		}
-		retVal = Value{Kind: IntValue, Data: dividend.Data.(int) / intDivisor}
+		if intDivisor == -1 && dividend.Data.(int) == minInt {
+			// negation of smallest int overflows
+			retVal = MakeIntValue(new(big.Int).Neg(ToBigInt(dividend)))
+		} else {
+			retVal = Value{Kind: IntValue, Data: dividend.Data.(int) / intDivisor}
+		}
+	} else if IsIntegerValue(dividend) && IsIntegerValue(divisor) {
+		if divisor.Kind == IntValue && divisor.Data.(int) == 0 {
+			runTimeError2(frame, "division by zero")
+		}
+		retVal = MakeIntValue(new(big.Int).Quo(ToBigInt(dividend), ToBigInt(divisor)))
+	} else if isExactNumber(dividend) && isExactNumber(divisor) {
+		ratDivisor := ToRat(divisor)
+		if ratDivisor.Sign() == 0 {
+			runTimeError2(frame, "division by zero")
+		}
+		retVal = MakeDecimalValue(new(big.Rat).Quo(ToRat(dividend), ratDivisor))
 	} else if dividend.Kind == FloatValue && divisor.Kind == FloatValue {
 		floatDivisor := divisor.Data.(float64)
 		if floatDivisor == 0 {
@@ -1786,7 +1994,7 @@ func handleDivOP(frame *Frame, operands []*Item) (retVal Value) {
 
 func handleModOP(frame *Frame, operands []*Item) (retVal Value) {
 	opName := "mod"
-	values := []int{}
+	values := []Value{}
 
 	if l := len(operands); l != 2 {
 		runTimeError2(frame, "%s operator should have exactly two arguments (%d given)", opName, l)
@@ -1801,21 +2009,31 @@ func handleModOP(frame *Frame, operands []*Item) (retVal Value) {
 		default:
 			runTimeError2(frame, "something wrong")
 		}
-		if argval.Kind != IntValue {
+		if !isExactNumber(argval) {
 			runTimeError2(frame, "Invalid type for %s", opName)
 		}
-		values = append(values, argval.Data.(int))
+		values = append(values, argval)
 	}
-	if values[1] == 0 {
+	if (values[1].Kind == IntValue && values[1].Data.(int) == 0) || (values[1].Kind == DecimalValue && values[1].Data.(*big.Rat).Sign() == 0) {
 		runTimeError2(frame, "division by zero")
 	}
-	retVal = Value{Kind: IntValue, Data: values[0] % values[1]}
+	if values[0].Kind == DecimalValue || values[1].Kind == DecimalValue {
+		retVal = MakeDecimalValue(remDecimal(ToRat(values[0]), ToRat(values[1])))
+		return
+	}
+	if values[0].Kind == IntValue && values[1].Kind == IntValue {
+		retVal = Value{Kind: IntValue, Data: values[0].Data.(int) % values[1].Data.(int)}
+		return
+	}
+	retVal = MakeIntValue(new(big.Int).Rem(ToBigInt(values[0]), ToBigInt(values[1])))
 	return
 }
 
 func handleMulOP(frame *Frame, operands []*Item) (retVal Value) {
 	opName := "mul"
 	result := 1
+	var bigResult *big.Int
+	var decResult *big.Rat
 	var resultF float64 = 1
 	var isAnyFloat bool
 
@@ -1834,7 +2052,33 @@ func handleMulOP(frame *Frame, operands []*Item) (retVal Value) {
 		}
 		switch argval.Kind {
 		case IntValue:
-			result *= argval.Data.(int)
+			intv := argval.Data.(int)
+			if decResult != nil {
+				decResult.Mul(decResult, ToRat(argval))
+			} else if bigResult != nil {
+				bigResult.Mul(bigResult, big.NewInt(int64(intv)))
+			} else if newResult, ok := mulInts(result, intv); ok {
+				result = newResult
+			} else {
+				bigResult = new(big.Int).Mul(big.NewInt(int64(result)), big.NewInt(int64(intv)))
+			}
+		case BigIntValue:
+			if decResult != nil {
+				decResult.Mul(decResult, ToRat(argval))
+				break
+			}
+			if bigResult == nil {
+				bigResult = big.NewInt(int64(result))
+			}
+			bigResult.Mul(bigResult, argval.Data.(*big.Int))
+		case DecimalValue:
+			if decResult == nil {
+				decResult = ToRat(Value{Kind: IntValue, Data: result})
+				if bigResult != nil {
+					decResult.SetInt(bigResult)
+				}
+			}
+			decResult.Mul(decResult, argval.Data.(*big.Rat))
 		case FloatValue:
 			isAnyFloat = true
 			resultF *= argval.Data.(float64)
@@ -1842,9 +2086,18 @@ func handleMulOP(frame *Frame, operands []*Item) (retVal Value) {
 			runTimeError2(frame, "Invalid type for %s", opName)
 		}
 	}
-	if isAnyFloat {
+	switch {
+	case isAnyFloat && decResult != nil:
+		runTimeError2(frame, "%s: float and decimal cannot be mixed", opName)
+	case decResult != nil:
+		retVal = MakeDecimalValue(decResult)
+	case isAnyFloat && bigResult != nil:
+		retVal = Value{Kind: FloatValue, Data: resultF * bigIntToFloat(bigResult)}
+	case isAnyFloat:
 		retVal = Value{Kind: FloatValue, Data: resultF * float64(result)}
-	} else {
+	case bigResult != nil:
+		retVal = MakeIntValue(bigResult)
+	default:
 		retVal = Value{Kind: IntValue, Data: result}
 	}
 	return
@@ -1853,6 +2106,8 @@ func handleMulOP(frame *Frame, operands []*Item) (retVal Value) {
 func handlePlusOP(frame *Frame, operands []*Item) (retVal Value) {
 	opName := "plus"
 	sum := 0
+	var bigSum *big.Int
+	var decSum *big.Rat
 	var sumF float64 = 0
 	text := ""
 	var argType ValueType
@@ -1871,16 +2126,45 @@ func handlePlusOP(frame *Frame, operands []*Item) (retVal Value) {
 		default:
 			runTimeError2(frame, "something wrong")
 		}
+		argKind := argval.Kind
+		if argKind == BigIntValue || argKind == DecimalValue {
+			argKind = IntValue
+		}
 		if i == 0 {
-			argType = argval.Kind
+			argType = argKind
 		}
-		if argType != argval.Kind {
+		if argType != argKind {
 			runTimeError2(frame, "mismatching types as arguments")
 		}
 		switch argval.Kind {
 		case IntValue:
 			intv := argval.Data.(int)
-			sum += intv
+			if decSum != nil {
+				decSum.Add(decSum, ToRat(argval))
+			} else if bigSum != nil {
+				bigSum.Add(bigSum, big.NewInt(int64(intv)))
+			} else if newSum, ok := addInts(sum, intv); ok {
+				sum = newSum
+			} else {
+				bigSum = new(big.Int).Add(big.NewInt(int64(sum)), big.NewInt(int64(intv)))
+			}
+		case BigIntValue:
+			if decSum != nil {
+				decSum.Add(decSum, ToRat(argval))
+				break
+			}
+			if bigSum == nil {
+				bigSum = big.NewInt(int64(sum))
+			}
+			bigSum.Add(bigSum, argval.Data.(*big.Int))
+		case DecimalValue:
+			if decSum == nil {
+				decSum = ToRat(Value{Kind: IntValue, Data: sum})
+				if bigSum != nil {
+					decSum.SetInt(bigSum)
+				}
+			}
+			decSum.Add(decSum, argval.Data.(*big.Rat))
 		case FloatValue:
 			hasFloats = true
 			floatv := argval.Data.(float64)
@@ -1898,7 +2182,13 @@ func handlePlusOP(frame *Frame, operands []*Item) (retVal Value) {
 	}
 	switch argType {
 	case IntValue:
-		retVal = Value{Kind: IntValue, Data: sum}
+		if decSum != nil {
+			retVal = MakeDecimalValue(decSum)
+		} else if bigSum != nil {
+			retVal = MakeIntValue(bigSum)
+		} else {
+			retVal = Value{Kind: IntValue, Data: sum}
+		}
 	case StringValue:
 		retVal = Value{Kind: StringValue, Data: text}
 	default:
@@ -1925,6 +2215,10 @@ func handleEmptyOP(frame *Frame, operands []*Item) (retVal Value) {
 	}
 
 	switch argval.Kind {
+	case SetValue:
+		retVal = Value{Kind: BoolValue, Data: argval.Data.(*Set).Len() == 0}
+	case VectorValue:
+		retVal = Value{Kind: BoolValue, Data: argval.Data.(*pvec.Vector).Len() == 0}
 	case ListValue:
 		list, convok := argval.Data.(*List)
 		if !convok {
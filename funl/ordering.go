@@ -0,0 +1,225 @@
+package funl
+
+import (
+	"math"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/anssihalmeaho/funl/pmap"
+)
+
+// SortedMapIteration makes keys, vals, keyvals and printing of maps and sets
+// to be in key order (see CompareValues) instead of hash order
+// (default for interpreter setting, maps keep order of interpreter which created them)
+var SortedMapIteration bool
+
+// orderRank gives order between different kinds of values
+func orderRank(kind ValueType) int {
+	switch kind {
+	case BoolValue:
+		return 0
+	case IntValue, BigIntValue, FloatValue, DecimalValue:
+		return 1
+	case StringValue:
+		return 2
+	case ListValue:
+		return 3
+	case VectorValue:
+		return 4
+	case SetValue:
+		return 5
+	case MapValue:
+		return 6
+	case RecordValue:
+		return 7
+	}
+	return 8
+}
+
+func compareInts(i1, i2 int) int {
+	switch {
+	case i1 < i2:
+		return -1
+	case i1 > i2:
+		return 1
+	}
+	return 0
+}
+
+func toRat(val Value) *big.Rat {
+	switch val.Kind {
+	case IntValue:
+		return new(big.Rat).SetInt64(int64(val.Data.(int)))
+	case BigIntValue:
+		return new(big.Rat).SetInt(val.Data.(*big.Int))
+	case DecimalValue:
+		return val.Data.(*big.Rat)
+	}
+	return new(big.Rat).SetFloat64(val.Data.(float64))
+}
+
+func toFloat(val Value) float64 {
+	if val.Kind == FloatValue {
+		return val.Data.(float64)
+	}
+	f, _ := toRat(val).Float64()
+	return f
+}
+
+// compareNumbers compares numbers by value, NaN is smallest
+func compareNumbers(v1, v2 Value) int {
+	if v1.Kind == IntValue && v2.Kind == IntValue {
+		return compareInts(v1.Data.(int), v2.Data.(int))
+	}
+	if v1.Kind == FloatValue || v2.Kind == FloatValue {
+		f1, f2 := toFloat(v1), toFloat(v2)
+		switch {
+		case math.IsNaN(f1) || math.IsNaN(f2):
+			if math.IsNaN(f1) && math.IsNaN(f2) {
+				return 0
+			}
+			if math.IsNaN(f1) {
+				return -1
+			}
+			return 1
+		case math.IsInf(f1, 0) || math.IsInf(f2, 0) || (v1.Kind == FloatValue && v2.Kind == FloatValue):
+			switch {
+			case f1 < f2:
+				return -1
+			case f1 > f2:
+				return 1
+			}
+			return 0
+		}
+	}
+	return toRat(v1).Cmp(toRat(v2))
+}
+
+func compareValueSlices(vals1, vals2 []Value) int {
+	for i := 0; i < len(vals1) && i < len(vals2); i++ {
+		if c := CompareValues(vals1[i], vals2[i]); c != 0 {
+			return c
+		}
+	}
+	return compareInts(len(vals1), len(vals2))
+}
+
+func listValues(val Value) (values []Value) {
+	it := NewListIterator(val)
+	for next := it.Next(); next != nil; next = it.Next() {
+		values = append(values, *next)
+	}
+	return
+}
+
+// CompareValues returns -1, 0 or 1 depending on if v1 is less, equal or greater than v2.
+// Values of different types are ordered as:
+// bool < numbers < string < list < vector < set < map < record < others,
+// numbers (int, bigint, float and decimal) are compared by value.
+// Lists, vectors, sets and maps are compared item by item (sets and maps in sorted order)
+// and others by type name and then by OpaqueComparer or string representation.
+func CompareValues(v1, v2 Value) int {
+	r1, r2 := orderRank(v1.Kind), orderRank(v2.Kind)
+	if r1 != r2 {
+		return compareInts(r1, r2)
+	}
+	switch v1.Kind {
+	case BoolValue:
+		return compareInts(boolToInt(v1.Data.(bool)), boolToInt(v2.Data.(bool)))
+	case IntValue, BigIntValue, FloatValue, DecimalValue:
+		if c := compareNumbers(v1, v2); c != 0 {
+			return c
+		}
+		// lets keep order stable for same numbers of different type
+		return compareInts(int(v1.Kind), int(v2.Kind))
+	case StringValue:
+		return strings.Compare(v1.Data.(string), v2.Data.(string))
+	case ListValue:
+		return compareValueSlices(listValues(v1), listValues(v2))
+	case VectorValue:
+		return compareValueSlices(VectorValues(v1), VectorValues(v2))
+	case SetValue:
+		return compareValueSlices(sortedValues(v1.Data.(*Set).values()), sortedValues(v2.Data.(*Set).values()))
+	case MapValue:
+		return compareKeyVals(SortedKeyVals(v1), SortedKeyVals(v2))
+	case RecordValue:
+		rec1, rec2 := v1.Data.(*Record), v2.Data.(*Record)
+		if c := strings.Compare(rec1.Type.Name, rec2.Type.Name); c != 0 {
+			return c
+		}
+		return CompareValues(rec1.Fields, rec2.Fields)
+	}
+	if c := strings.Compare(TypeNameOf(v1), TypeNameOf(v2)); c != 0 {
+		return c
+	}
+	if c, ok := compareOpaques(v1, v2); ok {
+		return compareInts(c, 0)
+	}
+	return strings.Compare(v1.String(), v2.String())
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func compareKeyVals(kvs1, kvs2 []KeyVal) int {
+	for i := 0; i < len(kvs1) && i < len(kvs2); i++ {
+		if c := CompareValues(kvs1[i].Key, kvs2[i].Key); c != 0 {
+			return c
+		}
+		if c := CompareValues(kvs1[i].Val, kvs2[i].Val); c != 0 {
+			return c
+		}
+	}
+	return compareInts(len(kvs1), len(kvs2))
+}
+
+// SortValues sorts values in place (by CompareValues)
+func SortValues(values []Value) {
+	sort.SliceStable(values, func(i, j int) bool {
+		return CompareValues(values[i], values[j]) < 0
+	})
+}
+
+func sortedValues(values []Value) []Value {
+	SortValues(values)
+	return values
+}
+
+// mapKeyVals returns key-values of map, in hash order or sorted by key if
+// SortedMapIteration was set in interpreter which created map
+func mapKeyVals(pm *PMap) []KeyVal {
+	kvs := make([]KeyVal, 0, pm.itemCount)
+	pm.visitAll(func(node *pmap.Node) {
+		item, ok := node.Val.(NodeValue)
+		if !ok {
+			runTimeError("not able to convert map item")
+		}
+		kvs = append(kvs, item.Val)
+		kvs = append(kvs, item.SameKeyValues...)
+	})
+	if pm.sorted {
+		sortKeyVals(kvs)
+	}
+	return kvs
+}
+
+func sortKeyVals(kvs []KeyVal) {
+	sort.SliceStable(kvs, func(i, j int) bool {
+		return CompareValues(kvs[i].Key, kvs[j].Key) < 0
+	})
+}
+
+// SortedKeyVals returns key-values of map sorted by key
+func SortedKeyVals(mapVal Value) []KeyVal {
+	pm := recordFields(mapVal).Data.(*PMap)
+	kvs := mapKeyVals(pm)
+	if !pm.sorted {
+		sortKeyVals(kvs)
+	}
+	return kvs
+}
@@ -0,0 +1,89 @@
+package funl
+
+import (
+	"math"
+	"math/big"
+	"testing"
+)
+
+func TestCompareValues(t *testing.T) {
+	str := func(s string) Value { return Value{Kind: StringValue, Data: s} }
+	num := func(i int) Value { return Value{Kind: IntValue, Data: i} }
+	flo := func(f float64) Value { return Value{Kind: FloatValue, Data: f} }
+	dec, _ := ParseDecimal("1.5")
+	bigv, _ := new(big.Int).SetString("100000000000000000000", 10)
+
+	ordered := []Value{
+		{Kind: BoolValue, Data: false},
+		{Kind: BoolValue, Data: true},
+		flo(math.NaN()),
+		flo(math.Inf(-1)),
+		num(-5),
+		num(1),
+		MakeDecimalValue(dec),
+		flo(2.5),
+		num(3),
+		MakeIntValue(bigv),
+		flo(math.Inf(1)),
+		str(""),
+		str("a"),
+		str("ab"),
+		str("b"),
+		MakeListOfValues(nil, []Value{num(1)}),
+		MakeListOfValues(nil, []Value{num(1), num(2)}),
+		MakeListOfValues(nil, []Value{num(2)}),
+		MakeSetOfValues(nil, []Value{num(2), num(1)}),
+		MakeSetOfValues(nil, []Value{num(3)}),
+	}
+	for i := range ordered {
+		for j := range ordered {
+			expected := compareInts(i, j)
+			if c := CompareValues(ordered[i], ordered[j]); c != expected {
+				t.Errorf("compare(%v, %v) = %d, assumed %d", ordered[i], ordered[j], c, expected)
+			}
+		}
+	}
+
+	if c := CompareValues(num(1), flo(1.0)); c == 0 {
+		t.Errorf("int and float should be ordered by type when same value")
+	}
+}
+
+func TestSortedMapIteration(t *testing.T) {
+	buildMap := func(frame *Frame) Value {
+		mb := NewMapBuilder(frame)
+		for i := 0; i < 100; i++ {
+			mb.Put(nil, Value{Kind: IntValue, Data: 99 - i}, Value{Kind: IntValue, Data: i})
+		}
+		return mb.Freeze(nil)
+	}
+	isSorted := func(mapVal Value) bool {
+		it := NewListIterator(handleKeysOP(nil, []*Item{{Type: ValueItem, Data: mapVal}}))
+		for i := 0; i < 100; i++ {
+			if key := it.Next(); key == nil || key.Data.(int) != i {
+				return false
+			}
+		}
+		return true
+	}
+
+	hashOrdered := buildMap(frameWithMapSettings(true, false))
+	for i, kv := range SortedKeyVals(hashOrdered) {
+		if kv.Key.Data.(int) != i {
+			t.Fatalf("unexpected key at %d: %v", i, kv.Key)
+		}
+	}
+	if isSorted(hashOrdered) {
+		t.Errorf("keys should be in hash order")
+	}
+
+	// setting of other interpreter does not affect map
+	sorted := buildMap(frameWithMapSettings(false, true))
+	if !isSorted(sorted) {
+		t.Errorf("keys should be sorted")
+	}
+	withNewKey := handlePutOP(nil, []*Item{{Type: ValueItem, Data: sorted}, {Type: ValueItem, Data: Value{Kind: IntValue, Data: 100}}, {Type: ValueItem, Data: Value{Kind: IntValue, Data: 0}}})
+	if !isSorted(withNewKey) {
+		t.Errorf("keys should be sorted after put")
+	}
+}
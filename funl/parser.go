@@ -83,12 +83,20 @@ type Parser struct {
 	canEndWithSymbol bool
 	srcFileName      *string
 	errorHandler     ParseErrorHandler
+	funcStack        []*Function // functions being parsed (innermost last)
+	sidc             *SymbolToIDConverter
 }
 
 type ParseErrorHandler interface {
 	HandleParseError(errorText string)
 }
 
+// SetSymIDConverter sets converter for symbol ids, parsed namespace
+// can be used only in interpreter which has same converter
+func (p *Parser) SetSymIDConverter(sidc *SymbolToIDConverter) {
+	p.sidc = sidc
+}
+
 func (p *Parser) SetErrorHandler(peh ParseErrorHandler) {
 	p.errorHandler = peh
 }
@@ -130,6 +138,7 @@ func (p *Parser) ParseImport() (modName string, importInfo ImportInfo) {
 			p.stopOnError(nextToken.Lineno, "Invalid import, assumed symbol (%s)", nextToken.Value)
 		}
 		modName = nextToken.Value
+		importInfo.src = p.srcPos(nextToken)
 	}
 
 	parseLongImport := func() {
@@ -216,10 +225,110 @@ func (p *Parser) ParseExpandedLet() (symbols []string, item *Item) {
 	}
 }
 
+// isMapLetDef checks if map destructuring let definition follows, like:
+// map(name age = 0) = person:
+func (p *Parser) isMapLetDef() bool {
+	firstToken, anyFound := p.tokenIter.lookAhead()
+	if !anyFound || firstToken.Type != tokenSymbol || firstToken.Value != "map" {
+		return false
+	}
+	var depth int
+	for counter := 1; ; counter++ {
+		nextToken, anyFound := p.tokenIter.lookAhead(counter)
+		if !anyFound {
+			return false
+		}
+		switch nextToken.Type {
+		case tokenOpenBracket:
+			depth++
+		case tokenClosingBracket:
+			depth--
+			if depth == 0 {
+				equalsToken, anyFound := p.tokenIter.lookAhead(counter + 1)
+				return anyFound && equalsToken.Type == tokenEqualsSign
+			}
+		}
+		if depth == 0 {
+			return false
+		}
+	}
+}
+
+// mapSymbol is symbol with optional default value in map let definition
+// (or in keyword arguments of function)
+type mapSymbol struct {
+	tok     token
+	defItem *Item
+}
+
+// parseMapSymbols parses symbols (and default values) of map
+// let definition or keyword arguments (until closing bracket)
+func (p *Parser) parseMapSymbols(what string) (mapSyms []mapSymbol) {
+	p.tokenIter.throwAway() // map
+	p.tokenIter.throwAway() // opening bracket
+	for {
+		nextToken, anyFound := p.tokenIter.next()
+		if !anyFound {
+			p.stopOnError(nil, "Invalid %s, no symbol found", what)
+		}
+		switch nextToken.Type {
+		case tokenClosingBracket:
+			if len(mapSyms) == 0 {
+				p.stopOnError(nextToken.Lineno, "Invalid %s, no symbol found", what)
+			}
+			return
+		case tokenComma:
+		case tokenSymbol:
+			if nextToken.Value == "_" {
+				p.stopOnError(nextToken.Lineno, "Invalid %s, _ not allowed", what)
+			}
+			mapSym := mapSymbol{tok: nextToken}
+			if eqToken, anyFound := p.tokenIter.lookAhead(); anyFound && eqToken.Type == tokenEqualsSign {
+				p.tokenIter.throwAway()
+				mapSym.defItem = p.ParseExpr()
+			}
+			mapSyms = append(mapSyms, mapSym)
+		default:
+			p.stopOnError(nextToken.Lineno, "Invalid %s, symbol assumed (%s)", what, nextToken.Value)
+		}
+	}
+}
+
+// ParseMapLet parses map destructuring let definition and adds symbols
+// to symbol table. Value of each symbol is taken from map by key which
+// is symbol name (as string), default value can be given with = sign
+// and it's used if key is not found from map.
+func (p *Parser) ParseMapLet(syms *Symt) {
+	letSyms := p.parseMapSymbols("map let definition")
+
+	p.tokenIter.throwAway() // equals sign
+	item := p.ParseExpr()
+	wasteName := getWastedName()
+	wasteSymID := p.sidc.Add(wasteName)
+	if err := syms.Add(wasteName, item); err != nil {
+		p.stopOnError(nil, "Failed to add let def. to symbol table (%s)", wasteName)
+	}
+	for _, letSym := range letSyms {
+		if err := syms.Add(letSym.tok.Value, p.newMapLetItem(wasteSymID, letSym.tok, letSym.defItem)); err != nil {
+			p.stopOnError(letSym.tok.Lineno, "Failed to add let def. to symbol table (%s)", letSym.tok.Value)
+		}
+	}
+
+	// read expander token too
+	expToken, hasAny := p.tokenIter.lookAhead()
+	if !hasAny {
+		p.stopOnError(nil, "assuming expander, found nothing")
+	}
+	if expToken.Type != tokenExpander {
+		p.stopOnError(expToken.Lineno, "assuming expander")
+	}
+	p.tokenIter.throwAway()
+}
+
 func (p *Parser) ParseFuncValue(procOrFuncToken token) (funcData *Function) {
 	DebugPrint("func start")
 
-	funcData = &Function{NSpace: NSpace{Syms: NewSymt(), OtherNS: make(map[SymID]ImportInfo)}}
+	funcData = &Function{NSpace: NSpace{Syms: NewSymtWithConverter(p.sidc), OtherNS: make(map[SymID]ImportInfo)}}
 
 	switch procOrFuncToken.Type {
 	case tokenProcBegin:
@@ -238,7 +347,11 @@ func (p *Parser) ParseFuncValue(procOrFuncToken token) (funcData *Function) {
 		p.stopOnError(token.Lineno, "Invalid function value, starting bracket assumed, found: %s", token.Value)
 	}
 
+	p.funcStack = append(p.funcStack, funcData)
+	defer func() { p.funcStack = p.funcStack[:len(p.funcStack)-1] }()
+
 	var argSymbolList []string
+	var params funcParams
 	// lets read arguments:
 	// lets check empty argument list case beforehand
 	if tok, _ := p.tokenIter.lookAhead(); tok.Type == tokenClosingBracket {
@@ -261,6 +374,18 @@ func (p *Parser) ParseFuncValue(procOrFuncToken token) (funcData *Function) {
 			case tokenEndNS:
 				p.stopOnError(token.Lineno, "Namespace end definition not allowed in function")
 			default:
+				if params.kwCount > 0 {
+					p.stopOnError(token.Lineno, "Keyword arguments should be last in argument list")
+				}
+				if nextToken, _ := p.tokenIter.lookAhead(1); token.Type == tokenSymbol && token.Value == "map" && nextToken.Type == tokenOpenBracket {
+					for _, kwSym := range p.parseMapSymbols("keyword arguments") {
+						argSymbolList = append(argSymbolList, kwSym.tok.Value)
+						funcData.ArgNames = append(funcData.ArgNames, p.sidc.Add(kwSym.tok.Value))
+						params.defaults = append(params.defaults, kwSym.defItem)
+						params.kwCount++
+					}
+					continue
+				}
 				argToken, hasArg := p.tokenIter.next()
 				if !hasArg {
 					p.stopOnError(nil, "No function argument found")
@@ -268,13 +393,39 @@ func (p *Parser) ParseFuncValue(procOrFuncToken token) (funcData *Function) {
 				if argToken.Type != tokenSymbol {
 					p.stopOnError(argToken.Lineno, "Invalid functiona argument (%s)", argToken.Value)
 				}
+				if params.hasRest {
+					p.stopOnError(argToken.Lineno, "Argument not allowed after rest argument (%s)", argToken.Value)
+				}
 				argSymbolList = append(argSymbolList, argToken.Value)
 
-				sid := SymIDMap.Add(argToken.Value)
+				// argument may have default value (arg = <expr>) or be rest argument (arg:)
+				var defItem *Item
+				nextToken, _ := p.tokenIter.lookAhead()
+				switch nextToken.Type {
+				case tokenExpander:
+					p.tokenIter.throwAway()
+					params.hasRest = true
+				case tokenEqualsSign:
+					p.tokenIter.throwAway()
+					defItem = p.ParseExpr()
+					params.posCount++
+				default:
+					if params.posCount > params.required {
+						p.stopOnError(argToken.Lineno, "Argument without default value after one with default (%s)", argToken.Value)
+					}
+					params.posCount++
+					params.required++
+				}
+				params.defaults = append(params.defaults, defItem)
+
+				sid := p.sidc.Add(argToken.Value)
 				funcData.ArgNames = append(funcData.ArgNames, sid)
 			}
 		}
 	}
+	if params.hasRest || params.kwCount > 0 || params.posCount > params.required {
+		funcData.params = &params
+	}
 
 	// then parse other parts
 	var bodyFound bool
@@ -301,7 +452,7 @@ BlockLoop:
 			setBodyFound(token.Lineno)
 		case tokenImport:
 			modName, item := p.ParseImport()
-			sid := SymIDMap.Add(modName)
+			sid := p.sidc.Add(modName)
 			if _, modFound := funcData.NSpace.OtherNS[sid]; modFound {
 				p.stopOnError(token.Lineno, "Module already imported (%s)", modName)
 			}
@@ -324,15 +475,12 @@ BlockLoop:
 				if isExpanderCase {
 					p.tokenIter.throwAway()
 					wasteName := getWastedName()
-					wasteSymID := SymIDMap.Add(wasteName)
+					wasteSymID := p.sidc.Add(wasteName)
 					if err := funcData.NSpace.Syms.Add(wasteName, item); err != nil {
 						p.stopOnError(secondToken.Lineno, "Failed to add let def. to symbol table (%s)", wasteName)
 					}
 
-					wasteSymbolItem := &Item{Type: SymbolPathItem, Data: SymbolPath{wasteSymID}}
-					indexItem := &Item{Type: ValueItem, Data: Value{Kind: IntValue, Data: 0}}
-					opc := OpCall{OperID: IndOP, Operands: []*Item{wasteSymbolItem, indexItem}}
-					indOPcallItem := &Item{Type: OperCallItem, Data: opc}
+					indOPcallItem := p.newIndexedLetItem(wasteSymID, 0, token)
 					if err := funcData.NSpace.Syms.Add(letName, indOPcallItem); err != nil {
 						p.stopOnError(secondToken.Lineno, "Failed to add let def. to symbol table (%s)", letName)
 					}
@@ -344,16 +492,13 @@ BlockLoop:
 			} else if p.isExpandedLetDef() {
 				letNames, item := p.ParseExpandedLet()
 				wasteName := getWastedName()
-				wasteSymID := SymIDMap.Add(wasteName)
+				wasteSymID := p.sidc.Add(wasteName)
 				if err := funcData.NSpace.Syms.Add(wasteName, item); err != nil {
 					p.stopOnError(secondToken.Lineno, "Failed to add let def. to symbol table (%s)", wasteName)
 				}
 
 				for letSymIndex, letName := range letNames {
-					wasteSymbolItem := &Item{Type: SymbolPathItem, Data: SymbolPath{wasteSymID}}
-					indexItem := &Item{Type: ValueItem, Data: Value{Kind: IntValue, Data: letSymIndex}}
-					opc := OpCall{OperID: IndOP, Operands: []*Item{wasteSymbolItem, indexItem}}
-					indOPcallItem := &Item{Type: OperCallItem, Data: opc}
+					indOPcallItem := p.newIndexedLetItem(wasteSymID, letSymIndex, token)
 					if err := funcData.NSpace.Syms.Add(letName, indOPcallItem); err != nil {
 						p.stopOnError(secondToken.Lineno, "Failed to add let def. to symbol table (%s)", letName)
 					}
@@ -368,6 +513,8 @@ BlockLoop:
 					p.stopOnError(expToken.Lineno, "assuming expander")
 				}
 				p.tokenIter.throwAway()
+			} else if p.isMapLetDef() {
+				p.ParseMapLet(funcData.NSpace.Syms)
 			} else {
 				expression := p.ParseExpr()
 				if expression.Type == OperCallItem {
@@ -377,7 +524,7 @@ BlockLoop:
 						setBodyFound(token.Lineno)
 					} else {
 						wasteName := getWastedName()
-						SymIDMap.Add(wasteName)
+						p.sidc.Add(wasteName)
 						if err := funcData.NSpace.Syms.Add(wasteName, expression); err != nil {
 							p.stopOnError(nil, "Failed to add _ symbol (%s)", wasteName)
 						}
@@ -429,13 +576,12 @@ func (p *Parser) ParseValue() (item *Item) {
 		}
 		if value.Kind != FloatValue {
 			// it wasnt float, lets assume its int
-			numval, err := strconv.Atoi(token.Value)
-			if err != nil {
+			numval, ok := parseIntValue(token.Value)
+			if !ok {
 				p.stopOnError(token.Lineno, "Invalid numeric value: %s", token.Value)
 			}
-			DebugPrint("num value: %d", numval)
-			value.Kind = IntValue
-			value.Data = numval
+			DebugPrint("num value: %v", numval)
+			value = numval
 		}
 	case tokenTrue:
 		DebugPrint("bool value: true")
@@ -459,7 +605,7 @@ func (p *Parser) ParseValue() (item *Item) {
 		value.Kind = FuncProtoValue
 		value.Data = funcData
 	}
-	item = &Item{Type: ValueItem, Data: value}
+	item = &Item{Type: ValueItem, Data: value, Src: p.srcPos(token)}
 	return
 }
 
@@ -604,6 +750,18 @@ func operNameToID(opName string) (op OperType, ok bool) {
 		op = DeferOP
 	case "force":
 		op = ForceOP
+	case "trye":
+		op = TryeOP
+	case "match":
+		op = MatchOP
+	case "rectype":
+		op = RectypeOP
+	case "vector":
+		op = VectorOP
+	case "setind":
+		op = SetindOP
+	case "set":
+		op = SetOP
 	default:
 		return
 	}
@@ -624,7 +782,8 @@ func (p *Parser) ParseOperCall() (item *Item) {
 	if !ok {
 		p.stopOnError(nil, "Invalid operator call, operator not found (%s)", operName)
 	}
-	opc := OpCall{OperID: opid, Operands: []*Item{}}
+	srcPos := p.srcPos(token)
+	opc := OpCall{OperID: opid, Operands: []*Item{}, Src: srcPos}
 
 	token, hasAny = p.tokenIter.next()
 	if !hasAny {
@@ -633,6 +792,15 @@ func (p *Parser) ParseOperCall() (item *Item) {
 	if token.Type != tokenOpenBracket {
 		p.stopOnError(token.Lineno, "Invalid operator call, starting bracket assumed, found: %s", token.Value)
 	}
+	if opid == ArgslistOP && len(p.funcStack) > 0 {
+		// function which uses argslist accepts any amount of arguments
+		p.funcStack[len(p.funcStack)-1].variadic = true
+	}
+	if opid == MatchOP {
+		opc.Operands = p.parseMatchOperands()
+		item = &Item{Type: OperCallItem, Data: opc, Src: srcPos}
+		return
+	}
 
 	var expandOperands bool
 	var argCounter int
@@ -675,15 +843,206 @@ func (p *Parser) ParseOperCall() (item *Item) {
 		}
 	}
 	DebugPrint("operator-call: %s", operName)
-	item = &Item{Type: OperCallItem, Data: opc, Expand: expandOperands, ExpandArgIndexes: expandIndexes}
+	item = &Item{Type: OperCallItem, Data: opc, Expand: expandOperands, ExpandArgIndexes: expandIndexes, Src: srcPos}
 	return
 }
 
+// parseMatchOperands parses value expression of match -operator
+// and pattern-expression pairs after it (until closing bracket)
+func (p *Parser) parseMatchOperands() (operands []*Item) {
+	nextToken := func() token {
+		for {
+			tok, hasAny := p.tokenIter.lookAhead()
+			if !hasAny {
+				p.stopOnError(nil, "Invalid match, closing bracket assumed")
+			}
+			if tok.Type != tokenComma {
+				return tok
+			}
+			p.tokenIter.throwAway()
+		}
+	}
+
+	if tok := nextToken(); tok.Type == tokenClosingBracket {
+		p.stopOnError(tok.Lineno, "Invalid match, value expression assumed")
+	}
+	operands = append(operands, p.ParseExpr())
+	for {
+		tok := nextToken()
+		if tok.Type == tokenClosingBracket {
+			p.tokenIter.throwAway()
+			break
+		}
+		clause := &MatchClause{}
+		clause.pattern = p.parsePattern(clause, true)
+		operands = append(operands, &Item{Type: PatternItem, Data: clause, Src: p.srcPos(tok)})
+
+		if tok = nextToken(); tok.Type == tokenClosingBracket {
+			p.stopOnError(tok.Lineno, "Invalid match, expression assumed after pattern")
+		}
+		operands = append(operands, p.ParseExpr())
+	}
+	if len(operands) < 3 {
+		p.stopOnError(nil, "Invalid match, at least one pattern assumed")
+	}
+	return
+}
+
+// parsePattern parses pattern of match -operator, symbols bound
+// by pattern are added to clause
+func (p *Parser) parsePattern(clause *MatchClause, isTop bool) *pattern {
+	tok, hasAny := p.tokenIter.lookAhead()
+	if !hasAny {
+		p.stopOnError(nil, "Invalid pattern, nothing found")
+	}
+	switch tok.Type {
+	case tokenNumber, tokenTrue, tokenFalse, tokenString:
+		return &pattern{kind: literalPattern, value: p.ParseValue().Data.(Value)}
+	case tokenSymbol:
+	default:
+		p.stopOnError(tok.Lineno, "Invalid pattern (%s)", tok.Value)
+	}
+	p.tokenIter.throwAway()
+
+	if bracketTok, _ := p.tokenIter.lookAhead(); bracketTok.Type != tokenOpenBracket {
+		if tok.Value == "_" {
+			return &pattern{kind: anyPattern}
+		}
+		sid := p.sidc.Add(tok.Value)
+		for _, boundSid := range clause.syms {
+			if boundSid == sid {
+				p.stopOnError(tok.Lineno, "Symbol bound more than once in pattern (%s)", tok.Value)
+			}
+		}
+		clause.syms = append(clause.syms, sid)
+		return &pattern{kind: bindPattern, sid: sid, index: len(clause.syms) - 1}
+	}
+	p.tokenIter.throwAway()
+
+	// reads sub-patterns until closing bracket
+	isClosing := func() bool {
+		for {
+			next, hasAny := p.tokenIter.lookAhead()
+			if !hasAny {
+				p.stopOnError(tok.Lineno, "Invalid pattern, closing bracket assumed (%s)", tok.Value)
+			}
+			switch next.Type {
+			case tokenComma:
+				p.tokenIter.throwAway()
+			case tokenClosingBracket:
+				p.tokenIter.throwAway()
+				return true
+			default:
+				return false
+			}
+		}
+	}
+
+	switch tok.Value {
+	case "list":
+		pat := &pattern{kind: listPattern}
+		for !isClosing() {
+			sub := p.parsePattern(clause, false)
+			if next, _ := p.tokenIter.lookAhead(); next.Type == tokenExpander {
+				p.tokenIter.throwAway()
+				if sub.kind != bindPattern && sub.kind != anyPattern {
+					p.stopOnError(next.Lineno, "Invalid pattern, symbol assumed for rest of list")
+				}
+				pat.rest = sub
+				if !isClosing() {
+					p.stopOnError(next.Lineno, "Invalid pattern, rest of list should be last")
+				}
+				break
+			}
+			pat.subs = append(pat.subs, sub)
+		}
+		return pat
+	case "map":
+		pat := &pattern{kind: mapPattern}
+		for !isClosing() {
+			keyTok, _ := p.tokenIter.lookAhead()
+			switch keyTok.Type {
+			case tokenNumber, tokenTrue, tokenFalse, tokenString:
+			default:
+				p.stopOnError(keyTok.Lineno, "Invalid pattern, map key should be literal value (%s)", keyTok.Value)
+			}
+			pat.keys = append(pat.keys, p.ParseValue().Data.(Value))
+			if isClosing() {
+				p.stopOnError(keyTok.Lineno, "Invalid pattern, pattern assumed for map key")
+			}
+			pat.subs = append(pat.subs, p.parsePattern(clause, false))
+		}
+		return pat
+	case "type":
+		typeTok, _ := p.tokenIter.lookAhead()
+		if typeTok.Type != tokenString {
+			p.stopOnError(typeTok.Lineno, "Invalid pattern, type name assumed (%s)", typeTok.Value)
+		}
+		pat := &pattern{kind: typePattern, value: p.ParseValue().Data.(Value)}
+		if !isClosing() {
+			pat.subs = append(pat.subs, p.parsePattern(clause, false))
+			if !isClosing() {
+				p.stopOnError(typeTok.Lineno, "Invalid pattern, closing bracket assumed (type)")
+			}
+		}
+		return pat
+	case "when":
+		if !isTop {
+			p.stopOnError(tok.Lineno, "Invalid pattern, guard allowed only for whole pattern")
+		}
+		pat := p.parsePattern(clause, false)
+		if isClosing() {
+			p.stopOnError(tok.Lineno, "Invalid pattern, guard expression assumed")
+		}
+		clause.guard = p.ParseExpr()
+		if !isClosing() {
+			p.stopOnError(tok.Lineno, "Invalid pattern, closing bracket assumed (when)")
+		}
+		return pat
+	}
+	p.stopOnError(tok.Lineno, "Invalid pattern (%s)", tok.Value)
+	return nil
+}
+
+// srcPos returns source position of token
+func (p *Parser) srcPos(tok token) *SrcPos {
+	pos := &SrcPos{Lineno: tok.Lineno, Col: tok.Col}
+	if p.srcFileName != nil {
+		pos.SrcFileName = *(p.srcFileName)
+	}
+	return pos
+}
+
+// newIndexedLetItem makes let definition item which takes value
+// from wasted symbol by index (expanded let definitions)
+func (p *Parser) newIndexedLetItem(wasteSymID SymID, index int, tok token) *Item {
+	srcPos := p.srcPos(tok)
+	wasteSymbolItem := &Item{Type: SymbolPathItem, Data: SymbolPath{wasteSymID}, Src: srcPos}
+	indexItem := &Item{Type: ValueItem, Data: Value{Kind: IntValue, Data: index}, Src: srcPos}
+	opc := OpCall{OperID: IndOP, Operands: []*Item{wasteSymbolItem, indexItem}, Src: srcPos}
+	return &Item{Type: OperCallItem, Data: opc, Src: srcPos}
+}
+
+// newMapLetItem makes let definition item which takes value from
+// map in wasted symbol by key (map let definitions)
+func (p *Parser) newMapLetItem(wasteSymID SymID, tok token, defItem *Item) *Item {
+	srcPos := p.srcPos(tok)
+	wasteSymbolItem := &Item{Type: SymbolPathItem, Data: SymbolPath{wasteSymID}, Src: srcPos}
+	keyItem := &Item{Type: ValueItem, Data: Value{Kind: StringValue, Data: tok.Value}, Src: srcPos}
+	operands := []*Item{wasteSymbolItem, keyItem}
+	if defItem != nil {
+		operands = append(operands, defItem)
+	}
+	opc := OpCall{OperID: GetOP, Operands: operands, Src: srcPos}
+	return &Item{Type: OperCallItem, Data: opc, Src: srcPos}
+}
+
 // ParseSymbolPath
 func (p *Parser) ParseSymbolPath() (item *Item) {
 	var symPath []string
 
 	sym, _ := p.tokenIter.next()
+	srcPos := p.srcPos(sym)
 	symPath = append(symPath, sym.Value)
 	for {
 		token, hasSome := p.tokenIter.lookAhead()
@@ -710,10 +1069,10 @@ func (p *Parser) ParseSymbolPath() (item *Item) {
 	}
 	var symbolIDPath SymbolPath
 	for _, v := range symPath {
-		sid := SymIDMap.Add(v)
+		sid := p.sidc.Add(v)
 		symbolIDPath = append(symbolIDPath, sid)
 	}
-	item = &Item{Type: SymbolPathItem, Data: symbolIDPath}
+	item = &Item{Type: SymbolPathItem, Data: symbolIDPath, Src: srcPos}
 	DebugPrint("symbol path: %v", symPath)
 	return
 }
@@ -779,7 +1138,7 @@ func (p *Parser) ParseNamespace() (nsName string, ns *NSpace) {
 	}
 	nsName = token.Value
 	DebugPrint("namespace start: %s", nsName)
-	ns = &NSpace{OtherNS: make(map[SymID]ImportInfo), Syms: NewSymt()}
+	ns = &NSpace{OtherNS: make(map[SymID]ImportInfo), Syms: NewSymtWithConverter(p.sidc)}
 	for {
 		token, hasAny = p.tokenIter.lookAhead()
 		if !hasAny {
@@ -792,7 +1151,7 @@ func (p *Parser) ParseNamespace() (nsName string, ns *NSpace) {
 		switch token.Type {
 		case tokenImport:
 			modName, importInfo := p.ParseImport()
-			sid := SymIDMap.Add(modName)
+			sid := p.sidc.Add(modName)
 			ns.OtherNS[sid] = importInfo
 		case tokenSymbol:
 			secondToken, hasSecond := p.tokenIter.lookAhead(1)
@@ -812,15 +1171,12 @@ func (p *Parser) ParseNamespace() (nsName string, ns *NSpace) {
 				if isExpanderCase {
 					p.tokenIter.throwAway()
 					wasteName := getWastedName()
-					wasteSymID := SymIDMap.Add(wasteName)
+					wasteSymID := p.sidc.Add(wasteName)
 					if err := ns.Syms.Add(wasteName, item); err != nil {
 						p.stopOnError(secondToken.Lineno, "Failed to add let def. to symbol table (%s)", wasteName)
 					}
 
-					wasteSymbolItem := &Item{Type: SymbolPathItem, Data: SymbolPath{wasteSymID}}
-					indexItem := &Item{Type: ValueItem, Data: Value{Kind: IntValue, Data: 0}}
-					opc := OpCall{OperID: IndOP, Operands: []*Item{wasteSymbolItem, indexItem}}
-					indOPcallItem := &Item{Type: OperCallItem, Data: opc}
+					indOPcallItem := p.newIndexedLetItem(wasteSymID, 0, token)
 					if err := ns.Syms.Add(sym, indOPcallItem); err != nil {
 						p.stopOnError(secondToken.Lineno, "Failed to add let def. to symbol table (%s)", sym)
 					}
@@ -833,16 +1189,13 @@ func (p *Parser) ParseNamespace() (nsName string, ns *NSpace) {
 			} else if p.isExpandedLetDef() {
 				letNames, item := p.ParseExpandedLet()
 				wasteName := getWastedName()
-				wasteSymID := SymIDMap.Add(wasteName)
+				wasteSymID := p.sidc.Add(wasteName)
 				if err := ns.Syms.Add(wasteName, item); err != nil {
 					p.stopOnError(secondToken.Lineno, "Failed to add let def. to symbol table (%s)", wasteName)
 				}
 
 				for letSymIndex, letName := range letNames {
-					wasteSymbolItem := &Item{Type: SymbolPathItem, Data: SymbolPath{wasteSymID}}
-					indexItem := &Item{Type: ValueItem, Data: Value{Kind: IntValue, Data: letSymIndex}}
-					opc := OpCall{OperID: IndOP, Operands: []*Item{wasteSymbolItem, indexItem}}
-					indOPcallItem := &Item{Type: OperCallItem, Data: opc}
+					indOPcallItem := p.newIndexedLetItem(wasteSymID, letSymIndex, token)
 					if err := ns.Syms.Add(letName, indOPcallItem); err != nil {
 						p.stopOnError(secondToken.Lineno, "Failed to add let def. to symbol table (%s)", letName)
 					}
@@ -857,11 +1210,13 @@ func (p *Parser) ParseNamespace() (nsName string, ns *NSpace) {
 					p.stopOnError(expToken.Lineno, "assuming expander")
 				}
 				p.tokenIter.throwAway()
+			} else if p.isMapLetDef() {
+				p.ParseMapLet(ns.Syms)
 			} else {
 				expression := p.ParseExpr()
 				if expression.Type == OperCallItem {
 					wasteName := getWastedName()
-					SymIDMap.Add(wasteName)
+					p.sidc.Add(wasteName)
 					if err := ns.Syms.Add(wasteName, expression); err != nil {
 						p.stopOnError(token.Lineno, "Failed to add _ symbol (%s)", wasteName)
 					}
@@ -918,6 +1273,7 @@ func (p *Parser) Parse(source string) (nsName string, ns *NSpace, err error) {
 		}
 		if token.Type == tokenStartNS {
 			nsName, ns = p.ParseNamespace()
+			resolveNSpace(ns)
 			return
 		}
 		err = fmt.Errorf("%d: No namespace found", token.Lineno)
@@ -1010,5 +1366,5 @@ func NewParser(operators Operators, srcFileName *string) *Parser {
 	if tokenIterator == nil {
 		return nil
 	}
-	return &Parser{tokenIter: tokenIterator, operators: operators, root: Node{NodeType: NodeTypeRoot}, srcFileName: srcFileName}
+	return &Parser{tokenIter: tokenIterator, operators: operators, root: Node{NodeType: NodeTypeRoot}, srcFileName: srcFileName, sidc: SymIDMap}
 }
@@ -0,0 +1,296 @@
+package funl
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+)
+
+// RecordType is user defined record type (created by rectype operator)
+type RecordType struct {
+	Name   string
+	fields []recordField // sorted by field name
+}
+
+type recordField struct {
+	name       string
+	required   bool
+	defaultVal *Value
+	checks     []Value // stdmeta style checks (list values)
+}
+
+// Record is value of record type (RecordValue), fields are in map
+type Record struct {
+	Type   *RecordType
+	Fields Value
+}
+
+func (rec *Record) String() string {
+	return fmt.Sprintf("%s(%s)", rec.Type.Name, rec.Fields.String())
+}
+
+// recordFields returns fields map of record, other values are returned as such
+func recordFields(val Value) Value {
+	if val.Kind == RecordValue {
+		return val.Data.(*Record).Fields
+	}
+	return val
+}
+
+func handleRectypeOP(frame *Frame, operands []*Item) (retVal Value) {
+	opName := "rectype"
+	if l := len(operands); l != 2 {
+		runTimeError2(frame, "%s operator needs two arguments (%d given)", opName, l)
+	}
+	var args []Value
+	for _, v := range operands {
+		switch v.Type {
+		case ValueItem:
+			args = append(args, v.Data.(Value))
+		case SymbolPathItem, OperCallItem:
+			args = append(args, EvalItem(v, frame))
+		default:
+			runTimeError2(frame, "something wrong (%s)", opName)
+		}
+	}
+	if args[0].Kind != StringValue {
+		runTimeError2(frame, "%s: requires string as record name", opName)
+	}
+	if args[1].Kind != MapValue {
+		runTimeError2(frame, "%s: requires map as schema", opName)
+	}
+
+	rtype := &RecordType{Name: args[0].Data.(string)}
+	if isBuiltinTypeName(rtype.Name) {
+		runTimeError2(frame, "%s: invalid record name (%s)", opName, rtype.Name)
+	}
+	kvIter := NewListIterator(handleKeyvalsOP(frame, []*Item{&Item{Type: ValueItem, Data: args[1]}}))
+	for kv := kvIter.Next(); kv != nil; kv = kvIter.Next() {
+		pairIter := NewListIterator(*kv)
+		keyv, checksv := *pairIter.Next(), *pairIter.Next()
+		if keyv.Kind != StringValue {
+			runTimeError2(frame, "%s: field name should be string (%v)", opName, keyv)
+		}
+		if checksv.Kind != ListValue {
+			runTimeError2(frame, "%s: field checks should be list (%s)", opName, keyv.Data)
+		}
+		field := recordField{name: keyv.Data.(string)}
+		checkIter := NewListIterator(checksv)
+		for check := checkIter.Next(); check != nil; check = checkIter.Next() {
+			if check.Kind != ListValue {
+				runTimeError2(frame, "%s: field check should be list (%s)", opName, field.name)
+			}
+			argIter := NewListIterator(*check)
+			tag := argIter.Next()
+			if tag == nil {
+				runTimeError2(frame, "%s: field check should be non-empty list (%s)", opName, field.name)
+			}
+			switch tag.Data {
+			case "required":
+				field.required = true
+			case "default":
+				defVal := argIter.Next()
+				if defVal == nil {
+					runTimeError2(frame, "%s: default value missing (%s)", opName, field.name)
+				}
+				field.defaultVal = defVal
+			case "type", "in", "map", "doc":
+				field.checks = append(field.checks, *check)
+			default:
+				runTimeError2(frame, "%s: unknown validator: %v", opName, tag)
+			}
+		}
+		rtype.fields = append(rtype.fields, field)
+	}
+	sort.Slice(rtype.fields, func(i, j int) bool { return rtype.fields[i].name < rtype.fields[j].name })
+
+	constructor := func(frame *Frame, arguments []Value) Value {
+		return rtype.newRecord(frame, arguments)
+	}
+	retVal = Value{Kind: ExtProcValue, Data: ExtProcType{Impl: constructor, IsFunction: true}}
+	return
+}
+
+// newRecord creates record from (optional) map of field values
+func (rtype *RecordType) newRecord(frame *Frame, arguments []Value) Value {
+	fields := handleMapOP(frame, []*Item{})
+	switch l := len(arguments); l {
+	case 0:
+	case 1:
+		if arguments[0].Kind != MapValue {
+			runTimeError2(frame, "%s: requires map as argument", rtype.Name)
+		}
+		fields = arguments[0]
+	default:
+		runTimeError2(frame, "%s: wrong amount of arguments (%d)", rtype.Name, l)
+	}
+
+	keysIter := NewListIterator(handleKeysOP(frame, []*Item{&Item{Type: ValueItem, Data: fields}}))
+	for key := keysIter.Next(); key != nil; key = keysIter.Next() {
+		if key.Kind != StringValue || rtype.field(key.Data.(string)) == nil {
+			runTimeError2(frame, "%s: unknown field (%v)", rtype.Name, *key)
+		}
+	}
+
+	var msgs []string
+	for _, field := range rtype.fields {
+		keyItem := &Item{Type: ValueItem, Data: Value{Kind: StringValue, Data: field.name}}
+		found, val := getFoundAndValue(handleGetlOP(frame, []*Item{&Item{Type: ValueItem, Data: fields}, keyItem}))
+		if !found && field.defaultVal != nil {
+			found, val = true, *field.defaultVal
+			fields = handlePutOP(frame, []*Item{&Item{Type: ValueItem, Data: fields}, keyItem, &Item{Type: ValueItem, Data: val}})
+		}
+		if !found {
+			if field.required {
+				msgs = append(msgs, fmt.Sprintf("required field %s not found", field.name))
+			}
+			continue
+		}
+		for _, check := range field.checks {
+			msgs = append(msgs, validateField(frame, field.name, val, check, "")...)
+		}
+	}
+	if len(msgs) > 0 {
+		runTimeError2(frame, "%s: %s", rtype.Name, strings.Join(msgs, ", "))
+	}
+	return Value{Kind: RecordValue, Data: &Record{Type: rtype, Fields: fields}}
+}
+
+func (rtype *RecordType) field(name string) *recordField {
+	for i := range rtype.fields {
+		if rtype.fields[i].name == name {
+			return &rtype.fields[i]
+		}
+	}
+	return nil
+}
+
+// getFoundAndValue splits result of getl
+func getFoundAndValue(getlResult Value) (bool, Value) {
+	it := NewListIterator(getlResult)
+	found := *it.Next()
+	return found.Data.(bool), *it.Next()
+}
+
+// validateField checks value against stdmeta style check (type, in, map, doc)
+func validateField(frame *Frame, name interface{}, val Value, check Value, path string) (msgs []string) {
+	it := NewListIterator(check)
+	tag := *it.Next()
+	var checkArgs []Value
+	for arg := it.Next(); arg != nil; arg = it.Next() {
+		checkArgs = append(checkArgs, *arg)
+	}
+	switch tag.Data {
+	case "type":
+		if len(checkArgs) > 0 {
+			if typeName := valueTypeName(frame, "rectype", val); typeName != checkArgs[0].Data {
+				msgs = append(msgs, fmt.Sprintf("field %v is not required type (got: %s, expected: %v)%s", name, typeName, checkArgs[0].Data, path))
+			}
+		}
+	case "in":
+		allowed := handleListOP(frame, valuesToItems(checkArgs))
+		inResult := handleInOP(frame, []*Item{&Item{Type: ValueItem, Data: allowed}, &Item{Type: ValueItem, Data: val}})
+		if !inResult.Data.(bool) {
+			msgs = append(msgs, fmt.Sprintf("field %v is not in allowed set (%v not in: %v)%s", name, val, allowed, path))
+		}
+	case "map":
+		if val.Kind != MapValue {
+			msgs = append(msgs, fmt.Sprintf("field %v is not map%s", name, path))
+			break
+		}
+		if len(checkArgs) == 0 || checkArgs[0].Kind != MapValue {
+			break
+		}
+		subPath := fmt.Sprintf(" (-> %v)", name)
+		kvIter := NewListIterator(handleKeyvalsOP(frame, []*Item{&Item{Type: ValueItem, Data: checkArgs[0]}}))
+		for kv := kvIter.Next(); kv != nil; kv = kvIter.Next() {
+			pairIter := NewListIterator(*kv)
+			subKey, subChecks := *pairIter.Next(), *pairIter.Next()
+			keyItem := &Item{Type: ValueItem, Data: subKey}
+			found, subVal := getFoundAndValue(handleGetlOP(frame, []*Item{&Item{Type: ValueItem, Data: val}, keyItem}))
+			checkIter := NewListIterator(subChecks)
+			for subCheck := checkIter.Next(); subCheck != nil; subCheck = checkIter.Next() {
+				if subTag := NewListIterator(*subCheck).Next(); subTag != nil && subTag.Data == "required" {
+					if !found {
+						msgs = append(msgs, fmt.Sprintf("required field %v not found%s", subKey.Data, subPath))
+					}
+				} else if found {
+					msgs = append(msgs, validateField(frame, subKey.Data, subVal, *subCheck, subPath)...)
+				}
+			}
+		}
+	}
+	return
+}
+
+func valuesToItems(values []Value) (items []*Item) {
+	for _, v := range values {
+		items = append(items, &Item{Type: ValueItem, Data: v})
+	}
+	return
+}
+
+// sameRecordType tells whether record types have same name and schema,
+// rectype evaluated again (for example inside function) creates new
+// RecordType but records made with it are still of same type
+func sameRecordType(frame *Frame, t1, t2 *RecordType) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1.Name != t2.Name || len(t1.fields) != len(t2.fields) {
+		return false
+	}
+	equal := func(v1, v2 Value) bool {
+		retv := handleEqOP(frame, []*Item{&Item{Type: ValueItem, Data: v1}, &Item{Type: ValueItem, Data: v2}})
+		return retv.Data.(bool)
+	}
+	for i := range t1.fields {
+		f1, f2 := t1.fields[i], t2.fields[i]
+		if f1.name != f2.name || f1.required != f2.required || len(f1.checks) != len(f2.checks) {
+			return false
+		}
+		if (f1.defaultVal == nil) != (f2.defaultVal == nil) {
+			return false
+		}
+		if f1.defaultVal != nil && !equal(*f1.defaultVal, *f2.defaultVal) {
+			return false
+		}
+		for j := range f1.checks {
+			if !equal(f1.checks[j], f2.checks[j]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func areEqualRecords(frame *Frame, r1, r2 *Record) bool {
+	if !sameRecordType(frame, r1.Type, r2.Type) {
+		return false
+	}
+	return areEqualMaps(frame, r1.Fields.Data.(*PMap), r2.Fields.Data.(*PMap))
+}
+
+func getHashedRecord(frame *Frame, from *Record) int {
+	buf := new(bytes.Buffer)
+	buf.WriteString(from.Type.Name)
+	for _, field := range from.Type.fields {
+		keyItem := &Item{Type: ValueItem, Data: Value{Kind: StringValue, Data: field.name}}
+		found, val := getFoundAndValue(handleGetlOP(frame, []*Item{&Item{Type: ValueItem, Data: from.Fields}, keyItem}))
+		if !found {
+			continue
+		}
+		hashedVal, err := hashOfValue(frame, val)
+		if err != nil {
+			runTimeError2(frame, "illegal type for map key")
+		}
+		buf.WriteString(field.name)
+		binary.Write(buf, binary.LittleEndian, int64(hashedVal))
+	}
+	hash := fnv.New64a()
+	hash.Write(buf.Bytes())
+	return int(hash.Sum64())
+}
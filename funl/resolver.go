@@ -0,0 +1,210 @@
+package funl
+
+// symRef is symbol reference resolved to frame slot: frame is found
+// by following access links depth times from current frame
+type symRef struct {
+	depth    int
+	index    int
+	imported bool // index is to import slots
+}
+
+// scope is lexical scope (module or function) used in resolving
+type scope struct {
+	parent  *scope
+	names   map[SymID]int // symbol to slot index
+	nspace  *NSpace
+	imports map[string]int // import path to import slot index
+}
+
+func newScope(parent *scope, nspace *NSpace) *scope {
+	return &scope{
+		parent:  parent,
+		names:   make(map[SymID]int),
+		nspace:  nspace,
+		imports: make(map[string]int),
+	}
+}
+
+func (sc *scope) isVisible(sid SymID) bool {
+	for s := sc; s != nil; s = s.parent {
+		if _, found := s.names[sid]; found {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveNSpace resolves symbols of module to frame slots, symbols which
+// cannot be resolved statically are looked up by name in runtime
+func resolveNSpace(ns *NSpace) {
+	moduleScope := newScope(nil, ns)
+	for index, sid := range ns.Syms.Keys() {
+		moduleScope.names[sid] = index
+	}
+	symbolMap := ns.Syms.AsMap()
+	for _, sid := range ns.Syms.Keys() {
+		moduleScope.resolveItem(symbolMap[sid])
+	}
+}
+
+func (sc *scope) resolveFunction(f *Function) {
+	fscope := newScope(sc, &f.NSpace)
+	addName := func(sid SymID, index int) {
+		if sid == AnySymSid {
+			return
+		}
+		if _, found := fscope.names[sid]; found || sc.isVisible(sid) {
+			// duplicate symbol is error which is noticed in runtime
+			f.hasDuplicates = true
+		}
+		fscope.names[sid] = index
+	}
+	for index, sid := range f.ArgNames {
+		addName(sid, index)
+	}
+	letSids := f.NSpace.Syms.Keys()
+	for index, sid := range letSids {
+		addName(sid, len(f.ArgNames)+index)
+	}
+
+	if f.params != nil {
+		for _, defItem := range f.params.defaults {
+			if defItem != nil {
+				fscope.resolveItem(defItem)
+			}
+		}
+	}
+	symbolMap := f.NSpace.Syms.AsMap()
+	for _, sid := range letSids {
+		fscope.resolveItem(symbolMap[sid])
+	}
+	fscope.resolveItem(f.Body)
+	f.resolved = true
+}
+
+func (sc *scope) resolveItem(item *Item) {
+	switch item.Type {
+	case ValueItem:
+		if v := item.Data.(Value); v.Kind == FuncProtoValue {
+			sc.resolveFunction(v.Data.(*Function))
+		}
+	case SymbolPathItem:
+		sp := item.Data.(SymbolPath)
+		if len(sp) == 1 {
+			item.ref = sc.resolveName(sp[0])
+		} else if len(sp) > 1 {
+			item.ref = sc.resolveImport(sp)
+		}
+	case OperCallItem:
+		opcall := item.Data.(OpCall)
+		if opcall.OperID == MatchOP {
+			sc.resolveMatch(opcall.Operands)
+			return
+		}
+		for _, operand := range opcall.Operands {
+			sc.resolveItem(operand)
+		}
+	}
+}
+
+// resolveMatch resolves match -operator, symbols bound by pattern
+// are in own scope (frame) for guard and result expression
+func (sc *scope) resolveMatch(operands []*Item) {
+	if len(operands) == 0 {
+		return
+	}
+	sc.resolveItem(operands[0])
+	for i := 1; i+1 < len(operands); i += 2 {
+		clause, isClause := operands[i].Data.(*MatchClause)
+		if !isClause {
+			continue
+		}
+		clauseScope := sc
+		if len(clause.syms) > 0 {
+			clauseScope = newScope(sc, &NSpace{})
+			for index, sid := range clause.syms {
+				if sc.isVisible(sid) {
+					// duplicate symbol is error which is noticed in runtime
+					clause.hasDuplicates = true
+				}
+				clauseScope.names[sid] = index
+			}
+		}
+		if clause.guard != nil {
+			clauseScope.resolveItem(clause.guard)
+		}
+		clauseScope.resolveItem(operands[i+1])
+		clause.resolved = true
+	}
+}
+
+func (sc *scope) resolveName(sid SymID) *symRef {
+	if sid == AnySymSid {
+		return nil
+	}
+	depth := 0
+	for s := sc; s != nil; s = s.parent {
+		if index, found := s.names[sid]; found {
+			return &symRef{depth: depth, index: index}
+		}
+		depth++
+	}
+	return nil
+}
+
+func (sc *scope) resolveImport(sp SymbolPath) *symRef {
+	depth := 0
+	for s := sc; s != nil; s = s.parent {
+		if _, found := s.nspace.OtherNS[sp[0]]; found {
+			pathStr := sp.ToStringWith(s.nspace.Syms.Converter())
+			index, found := s.imports[pathStr]
+			if !found {
+				index = len(s.nspace.importRefs)
+				s.nspace.importRefs = append(s.nspace.importRefs, sp)
+				s.imports[pathStr] = index
+			}
+			return &symRef{depth: depth, index: index, imported: true}
+		}
+		depth++
+	}
+	return nil
+}
+
+// lookup gets value of resolved symbol, not found if symbol
+// does not have value yet (or frames do not match scopes)
+func (ref *symRef) lookup(frame *Frame) (Value, bool) {
+	fr := frame
+	for i := 0; i < ref.depth && fr != nil; i++ {
+		fr = fr.AccessLink
+	}
+	if fr == nil {
+		return Value{}, false
+	}
+	slots := fr.slots
+	if ref.imported {
+		slots = fr.importSlots
+	}
+	if ref.index >= len(slots) {
+		return Value{}, false
+	}
+	v := slots[ref.index]
+	return v, v.Data != nil
+}
+
+// bindImports gets values for imported symbols used in namespace
+// so that those are not searched in every access
+func (fr *Frame) bindImports(nspace *NSpace) {
+	if len(nspace.importRefs) == 0 {
+		return
+	}
+	fr.importSlots = make([]Value, len(nspace.importRefs))
+	for index, sp := range nspace.importRefs {
+		symItem, found := fr.GetImportedSymItem(sp[0], sp[1:])
+		if !found || symItem.Type != ValueItem {
+			continue
+		}
+		if v := symItem.Data.(Value); v.Kind != FuncProtoValue {
+			fr.importSlots[index] = v
+		}
+	}
+}
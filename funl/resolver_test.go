@@ -0,0 +1,129 @@
+package funl
+
+import (
+	"testing"
+)
+
+const resolverTestSrc = `
+ns rtest
+
+import rmod
+
+modsym = 10
+
+f = func(a)
+	b = plus(a modsym)
+	g = func(c) list(a b c rmod.apply) end
+	call(g eval('a'))
+end
+
+endns
+`
+
+func findSymbolItems(item *Item, found map[string][]*Item) {
+	switch item.Type {
+	case ValueItem:
+		if v := item.Data.(Value); v.Kind == FuncProtoValue {
+			f := v.Data.(*Function)
+			for _, letItem := range f.NSpace.Syms.AsMap() {
+				findSymbolItems(letItem, found)
+			}
+			findSymbolItems(f.Body, found)
+		}
+	case SymbolPathItem:
+		sp := item.Data.(SymbolPath)
+		found[sp.ToString()] = append(found[sp.ToString()], item)
+	case OperCallItem:
+		for _, operand := range item.Data.(OpCall).Operands {
+			findSymbolItems(operand, found)
+		}
+	}
+}
+
+func TestResolveSymbols(t *testing.T) {
+	parser := NewParser(NewDefaultOperators(), nil)
+	_, nspace, err := parser.Parse(resolverTestSrc)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	fItem, _ := nspace.Syms.GetByName("f")
+	found := map[string][]*Item{}
+	findSymbolItems(fItem, found)
+
+	expected := map[string][]symRef{
+		"a":          {{depth: 0, index: 0}, {depth: 1, index: 0}},
+		"b":          {{depth: 1, index: 1}},
+		"c":          {{depth: 0, index: 0}},
+		"modsym":     {{depth: 1, index: 0}},
+		"g":          {{depth: 0, index: 2}},
+		"rmod.apply": {{depth: 2, index: 0, imported: true}},
+	}
+	for name, refs := range expected {
+		for _, item := range found[name] {
+			if item.ref == nil {
+				t.Fatalf("symbol not resolved: %s", name)
+			}
+			matched := false
+			for _, ref := range refs {
+				if *item.ref == ref {
+					matched = true
+				}
+			}
+			if !matched {
+				t.Fatalf("unexpected reference for %s: %#v", name, *item.ref)
+			}
+		}
+	}
+	if len(nspace.importRefs) != 1 || nspace.importRefs[0].ToString() != "rmod.apply" {
+		t.Fatalf("unexpected import references: %v", nspace.importRefs)
+	}
+	if !fItem.Data.(Value).Data.(*Function).resolved {
+		t.Fatalf("function should be resolved")
+	}
+}
+
+func TestResolvedAndDynamicLookup(t *testing.T) {
+	interpreter := NewInterpreter()
+	interpreter.Importer = &fileImporter{}
+	for _, src := range []string{"ns rmod apply = 'imported' endns", resolverTestSrc} {
+		parser := interpreter.NewParser(nil)
+		nsName, nspace, err := parser.Parse(src)
+		if err != nil {
+			t.Fatalf("parse error: %v", err)
+		}
+		topFrame := AddNStoCache(true, nsName, nspace, interpreter)
+		if nsName != "rtest" {
+			continue
+		}
+		funcItem, _ := topFrame.Syms.GetByName("f")
+		operands := []*Item{funcItem, &Item{Type: ValueItem, Data: Value{Kind: IntValue, Data: 1}}}
+		result := handleCallOP(topFrame, operands)
+		if s := result.String(); s != "list(1, 11, 1, 'imported')" {
+			t.Fatalf("unexpected result: %s", s)
+		}
+	}
+}
+
+func TestDynamicSlotLookupDoesNotAllocate(t *testing.T) {
+	parser := NewParser(NewDefaultOperators(), nil)
+	_, nspace, err := parser.Parse(resolverTestSrc)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	fItem, _ := nspace.Syms.GetByName("f")
+	f := fItem.Data.(Value).Data.(*Function)
+	frame := &Frame{FuncProto: f, slots: make([]Value, len(f.ArgNames)+len(f.NSpace.Syms.Keys()))}
+	bSid, _ := f.symIDs().Get("b")
+	frame.slots[1] = Value{Kind: IntValue, Data: 11}
+
+	var v Value
+	allocs := testing.AllocsPerRun(100, func() {
+		v, _, _ = frame.lookupSym(bSid)
+	})
+	if allocs != 0 {
+		t.Errorf("lookup allocated (%v)", allocs)
+	}
+	if v.Data != 11 {
+		t.Errorf("unexpected value: %s", v)
+	}
+}
@@ -0,0 +1,181 @@
+package funl
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/fnv"
+	"sort"
+)
+
+// Set is persistent set (SetValue), items are kept as keys of map
+// so that same hashing and tree (pmap) is used as in maps.
+type Set struct {
+	items Value // map value: item -> true
+}
+
+var setMarker = Value{Kind: BoolValue, Data: true}
+
+func newSet(frame *Frame) *Set {
+	return &Set{items: handleMapOP(frame, []*Item{})}
+}
+
+// MakeSetOfValues offers API for std to create set for values
+func MakeSetOfValues(frame *Frame, values []Value) Value {
+	return Value{Kind: SetValue, Data: newSet(frame).add(frame, values...)}
+}
+
+// SetValues returns items of set in slice
+func SetValues(frame *Frame, val Value) (values []Value) {
+	it := NewListIterator(handleKeysOP(frame, []*Item{&Item{Type: ValueItem, Data: val.Data.(*Set).items}}))
+	for next := it.Next(); next != nil; next = it.Next() {
+		values = append(values, *next)
+	}
+	return
+}
+
+// values returns items of set without operator calls so that it can be used
+// where there is no frame (printing, ordering)
+func (set *Set) values() []Value {
+	kvs := mapKeyVals(set.items.Data.(*PMap))
+	values := make([]Value, 0, len(kvs))
+	for _, kv := range kvs {
+		values = append(values, kv.Key)
+	}
+	return values
+}
+
+// Len returns number of items in set
+func (set *Set) Len() int {
+	return set.items.Data.(*PMap).itemCount
+}
+
+// Contains returns true if value is in set
+func (set *Set) Contains(frame *Frame, val Value) bool {
+	found, _ := getFoundAndValue(handleGetlOP(frame, []*Item{&Item{Type: ValueItem, Data: set.items}, &Item{Type: ValueItem, Data: val}}))
+	return found
+}
+
+func (set *Set) add(frame *Frame, values ...Value) *Set {
+	items := set.items
+	for _, val := range values {
+		if (&Set{items: items}).Contains(frame, val) {
+			continue
+		}
+		items = handlePutOP(frame, []*Item{&Item{Type: ValueItem, Data: items}, &Item{Type: ValueItem, Data: val}, &Item{Type: ValueItem, Data: setMarker}})
+	}
+	return &Set{items: items}
+}
+
+func (set *Set) remove(frame *Frame, values ...Value) *Set {
+	items := set.items
+	for _, val := range values {
+		items, _ = delCommon(false, "remove", frame, []*Item{&Item{Type: ValueItem, Data: items}, &Item{Type: ValueItem, Data: val}})
+	}
+	return &Set{items: items}
+}
+
+// SetAdd returns new set with values added
+func SetAdd(frame *Frame, setVal Value, values ...Value) Value {
+	return Value{Kind: SetValue, Data: setVal.Data.(*Set).add(frame, values...)}
+}
+
+// SetRemove returns new set with values removed
+func SetRemove(frame *Frame, setVal Value, values ...Value) Value {
+	return Value{Kind: SetValue, Data: setVal.Data.(*Set).remove(frame, values...)}
+}
+
+// SetUnion returns union of sets
+func SetUnion(frame *Frame, set1, set2 Value) Value {
+	s1, s2 := set1.Data.(*Set), set2.Data.(*Set)
+	if s1.Len() < s2.Len() {
+		s1, s2 = s2, s1
+	}
+	return Value{Kind: SetValue, Data: s1.add(frame, SetValues(frame, Value{Kind: SetValue, Data: s2})...)}
+}
+
+// SetIntersection returns set of items which are in both sets
+func SetIntersection(frame *Frame, set1, set2 Value) Value {
+	s1, s2 := set1.Data.(*Set), set2.Data.(*Set)
+	if s1.Len() > s2.Len() {
+		s1, s2 = s2, s1
+	}
+	result := newSet(frame)
+	for _, val := range SetValues(frame, Value{Kind: SetValue, Data: s1}) {
+		if s2.Contains(frame, val) {
+			result = result.add(frame, val)
+		}
+	}
+	return Value{Kind: SetValue, Data: result}
+}
+
+// SetDifference returns set of items which are in set1 but not in set2
+func SetDifference(frame *Frame, set1, set2 Value) Value {
+	s1, s2 := set1.Data.(*Set), set2.Data.(*Set)
+	if s1.Len() < s2.Len() {
+		result := newSet(frame)
+		for _, val := range SetValues(frame, set1) {
+			if !s2.Contains(frame, val) {
+				result = result.add(frame, val)
+			}
+		}
+		return Value{Kind: SetValue, Data: result}
+	}
+	return Value{Kind: SetValue, Data: s1.remove(frame, SetValues(frame, set2)...)}
+}
+
+// IsSubset returns true if all items of subset are in set
+func IsSubset(frame *Frame, set, subset Value) bool {
+	s, sub := set.Data.(*Set), subset.Data.(*Set)
+	if sub.Len() > s.Len() {
+		return false
+	}
+	for _, val := range SetValues(frame, subset) {
+		if !s.Contains(frame, val) {
+			return false
+		}
+	}
+	return true
+}
+
+func handleSetOP(frame *Frame, operands []*Item) (retVal Value) {
+	retVal = MakeSetOfValues(frame, evalOperands(frame, "set", operands))
+	return
+}
+
+func setString(set *Set) string {
+	var buf bytes.Buffer
+	buf.WriteString("set(")
+	for i, val := range set.values() {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(val.String())
+	}
+	buf.WriteString(")")
+	return buf.String()
+}
+
+func areEqualSets(frame *Frame, s1, s2 *Set) bool {
+	return s1.Len() == s2.Len() && IsSubset(frame, Value{Kind: SetValue, Data: s1}, Value{Kind: SetValue, Data: s2})
+}
+
+// getHashedSet hashes items in sorted hash order so that it doesn't depend on order of items
+func getHashedSet(frame *Frame, from *Set) int {
+	var hashes []int
+	for _, val := range SetValues(frame, Value{Kind: SetValue, Data: from}) {
+		hashedKey, err := hashOfValue(frame, val)
+		if err != nil {
+			runTimeError2(frame, "illegal type for map key")
+		}
+		hashes = append(hashes, hashedKey)
+	}
+	sort.Ints(hashes)
+	buf := new(bytes.Buffer)
+	buf.WriteString("set")
+	for _, h := range hashes {
+		binary.Write(buf, binary.LittleEndian, int64(h))
+	}
+	hash := fnv.New64a()
+	hash.Write(buf.Bytes())
+	return int(hash.Sum64())
+}
@@ -151,10 +151,13 @@ end
 
 print-citem = func(citem)
 	sprintf(
-		'  file: %s line: %d args: %v\n'
+		'  file: %s line: %d args: %v (called at: %s:%d:%d)\n'
 		get(citem 'file')
 		get(citem 'line')
 		get(citem 'args')
+		get(citem 'call-file' '-')
+		get(citem 'call-line' 0)
+		get(citem 'call-col' 0)
 	)
 end
 
@@ -514,50 +517,29 @@ applies-for-any = func(srclist, condition)
 end
 
 group-by = func(srcdata, grouper)
-	append-to-list = func(resm akey aval)
-		prevl = get(resm akey)
-		newl = append(prevl aval)
-		newm = del(resm akey)
-		put(newm akey newl)
+	import stdtrans
+
+	add-to-group = func(tmap key value)
+		found prevl = call(stdtrans.getl tmap key):
+		call(stdtrans.set tmap key if(found append(prevl value) list(value)))
 	end
-	
+
 	map-group-by = func(src-map)
-		looper = func(kvs result)
-			next-kv = if(not(empty(kvs)) head(kvs) 'whatever')
-	
-			while( not(empty(kvs))
-				rest(kvs)
-				call(func()
-					kv vv = head(kvs):
-					key value = call(grouper kv vv):
-					if( in(result key)
-						call(append-to-list result key value)
-						put(result key list(value))
-					)
-				end)
-				result
-			)
+		adder = func(kv tmap)
+			key value = call(grouper head(kv) last(kv)):
+			call(add-to-group tmap key value)
 		end
-		
-		call(looper, keyvals(src-map), map())
+
+		call(stdtrans.build-map func(tmap) call(loop adder keyvals(src-map) tmap) end)
 	end
-	
+
 	list-group-by = func(srclist)
-		looper = func(kvl result)
-			while( not(empty(kvl))
-				rest(kvl)
-				call(func()
-					key value = call(grouper head(kvl)):
-					if( in(result key)
-						call(append-to-list result key value)
-						put(result key list(value))
-					)
-				end)
-				result
-			)
+		adder = func(item tmap)
+			key value = call(grouper item):
+			call(add-to-group tmap key value)
 		end
-		
-		call(looper srclist map())
+
+		call(stdtrans.build-map func(tmap) call(loop adder srclist tmap) end)
 	end
 
 	case( type(srcdata),
@@ -641,11 +623,13 @@ proc-pipe = func(proc-list)
 end
 
 pairs-to-map = func(kv-list)
-	pair-to-map = func(item result)
-		put(result head(item) last(item))
+	import stdtrans
+
+	pair-to-map = func(item tmap)
+		call(stdtrans.put tmap head(item) last(item))
 	end
 
-	call(loop pair-to-map kv-list map())
+	call(stdtrans.build-map func(tmap) call(loop pair-to-map kv-list tmap) end)
 end
 
 # overwrites key-value if found, ignored if not found
@@ -787,7 +771,7 @@ end
 validate = func(schema srcdata)
 	import stdfu
 
-	validate-map = func(map-schema map-data field-path)
+	validate-map = func(map-schema map-data field-path init-results)
 		map-fields-checker = func(kvitem results)
 			get-field-checker = func(mkey)
 				func(check result-list)
@@ -833,7 +817,7 @@ validate = func(schema srcdata)
 										result-list
 									not(eq(type(submap-data) 'map'))
 										list(false append(msg-list sprintf('field %v is not map (%s)' mkey field-path)))
-									call(validate-map head(rest(check)) submap-data plus(field-path ' -> ' str(mkey)))
+									call(validate-map head(rest(check)) submap-data plus(field-path ' -> ' str(mkey)) result-list)
 								)
 							end)
 
@@ -851,14 +835,14 @@ validate = func(schema srcdata)
 		end
 
 		if( eq(type(map-schema) 'map')
-			call(stdfu.loop map-fields-checker keyvals(map-schema) list(true list()))
-			list(false list('requires map'))
+			call(stdfu.loop map-fields-checker keyvals(map-schema) init-results)
+			list(false append(head(rest(init-results)) 'requires map'))
 		)
 	end
 
 	if( and( eq(type(schema) 'list') not(empty(schema)))
 		case( head(schema)
-			'map' call(validate-map head(rest(schema)) srcdata '')
+			'map' call(validate-map head(rest(schema)) srcdata '' list(true list()))
 			list(false list(sprintf('unknown validator: %s' str(head(schema)) )))
 		)
 		list(false list('requires non-empty list'))
@@ -882,21 +866,25 @@ end
 
 form = func(val)
 	import stdfu
-
-	indent-mark = if( gt(len(argslist()) 1)
-		call(func()
-			_ marker = argslist():
-			if( eq(type(marker) 'string') marker '\t')
-		end argslist():)
+	import stdorder
+
+	# second argument is indent string or options map:
+	# 'indent': indent string, 'sorted': true -> map items in key order
+	marker = if( gt(len(argslist()) 1) ind(argslist() 1) map())
+	indent-mark = case( type(marker)
+		'string' marker
+		'map'    get(marker 'indent' '\t')
 		'\t' # tab is default
 	)
+	sorted = and( eq(type(marker) 'map') get(marker 'sorted' false) )
+	map-items = func(m) if(sorted call(stdorder.sorted-keyvals m) keyvals(m)) end
 
 	print-list = func(v indent)
 		call(stdfu.foreach v func(x cum) call(print-value x plus(indent indent-mark) cum) end '')
 	end
 
 	print-map = func(v indent)
-		call(stdfu.foreach keyvals(v) func(kv cum)
+		call(stdfu.foreach call(map-items v) func(kv cum)
 			mkey mvalue = kv:
 			cum2 = call(print-value mkey plus(indent indent-mark) cum)
 			call(print-value mvalue plus(indent indent-mark) cum2)
@@ -918,14 +906,18 @@ end
 
 pform = proc(val)
 	import stdfu
-
-	indent-mark = if( gt(len(argslist()) 1)
-		call(proc()
-			_ marker = argslist():
-			if( eq(type(marker) 'string') marker '\t')
-		end argslist():)
+	import stdorder
+
+	# second argument is indent string or options map:
+	# 'indent': indent string, 'sorted': true -> map items in key order
+	marker = if( gt(len(argslist()) 1) ind(argslist() 1) map())
+	indent-mark = case( type(marker)
+		'string' marker
+		'map'    get(marker 'indent' '\t')
 		'\t' # tab is default
 	)
+	sorted = and( eq(type(marker) 'map') get(marker 'sorted' false) )
+	map-items = func(m) if(sorted call(stdorder.sorted-keyvals m) keyvals(m)) end
 
 	print-list = proc(v indent)
 		call(stdfu.ploop proc(x cum) call(print-value x plus(indent indent-mark) cum) end v '')
@@ -936,7 +928,7 @@ pform = proc(val)
 			mkey mvalue = kv:
 			cum2 = call(print-value mkey plus(indent indent-mark) cum)
 			call(print-value mvalue plus(indent indent-mark) cum2)
-		end keyvals(v) '')
+		end call(map-items v) '')
 	end
 
 	print-value = proc(v indent res)
@@ -993,8 +985,10 @@ import stdjson
 import stdbytes
 import stdfu
 import stdbase64
+import stdrec
+import stdopaque
 
-tags = list('int' 'float' 'bool' 'string' 'list' 'map' 'bytearray')
+tags = list('int' 'float' 'decimal' 'bool' 'string' 'list' 'map' 'bytearray' 'record' 'vector' 'set' 'opaque')
 
 encode = func(val)
 	enc-bytearray = func(inval)
@@ -1004,15 +998,25 @@ encode = func(val)
 	end
 
 	handle-item = func(inval)
-		vtype = type(inval)
+		is-enc opaque-type enc-val = call(stdopaque.encode inval):
+		vtype = cond(
+			call(stdrec.is-record inval) 'record'
+			and(is-enc not(eq(opaque-type 'bytearray'))) 'opaque'
+			type(inval)
+		)
 		case( vtype
 			'int'    list('int' inval)
 			'float'  list('float' inval)
+			'decimal' list('decimal' str(inval))
 			'bool'   list('bool' inval)
 			'string' list('string' inval)
 			'opaque:bytearray' list('bytearray' call(enc-bytearray inval))
 			'list'   list('list' call(stdfu.apply inval func(item) call(handle-item item) end))
 			'map'    list('map' call(stdfu.apply keyvals(inval) func(item) k v = item: list(call(handle-item k) call(handle-item v)) end))
+			'record' list('record' list(type(inval) call(handle-item call(stdrec.fields inval))))
+			'vector' list('vector' call(stdfu.apply conv(inval 'list') func(item) call(handle-item item) end))
+			'set'    list('set' call(stdfu.apply conv(inval 'list') func(item) call(handle-item item) end))
+			'opaque' list('opaque' list(opaque-type call(handle-item enc-val)))
 			error('unsupported type: ' vtype)
 		)
 	end
@@ -1020,7 +1024,9 @@ encode = func(val)
 	call(stdjson.encode call(handle-item val))
 end
 
-decode = func(val)
+# record and opaque constructors can be given in map
+# (record name or opaque type name -> constructor)
+decode = func(val rectypes = map())
 	handle-map = func(ml)
 		mapper = func(pair resultm)
 			kpair vpair = pair:
@@ -1041,11 +1047,24 @@ decode = func(val)
 		case( tag
 			'int'    value
 			'float'  value
+			'decimal' conv(value 'decimal')
 			'bool'   value
 			'string' value
 			'bytearray' call(dec-bytearray value)
 			'list'   call(stdfu.apply value func(pair) call(handle-pair pair) end)
 			'map'    call(handle-map value)
+			'vector' conv(call(stdfu.apply value func(pair) call(handle-pair pair) end) 'vector')
+			'set'    conv(call(stdfu.apply value func(pair) call(handle-pair pair) end) 'set')
+			'record' call(func()
+						recname fields = value:
+						found constructor = getl(rectypes recname):
+						if(found call(constructor call(handle-pair fields)) error('unsupported record type: ' recname))
+					end)
+			'opaque' call(func()
+						tname encoded = value:
+						found constructor = getl(rectypes tname):
+						if(found call(constructor call(handle-pair encoded)) error('unsupported opaque type: ' tname))
+					end)
 			error('unsupported tag: ' tag)
 		)
 	end
@@ -1064,12 +1083,17 @@ endns
 	stdfunMap["stdset"] = `
 ns stdset
 
+/*
+stdset represents sets as maps (item -> true).
+For built-in set values (type: 'set') use set -operator and stdpset module.
+*/
+
 # newset creates new set
 newset = func()
 	map()
 end
 
-# is-empty returns true if there is no items in set, otherwise true
+# is-empty returns true if there is no items in set, otherwise false
 is-empty = func(set)
 	eq(len(set), 0)
 end
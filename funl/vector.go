@@ -0,0 +1,216 @@
+package funl
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+
+	"github.com/anssihalmeaho/funl/pvec"
+)
+
+// VectorValue is persistent vector (Data is *pvec.Vector), it has
+// O(log n) indexing, update and append and O(1) len, slice and rest.
+
+// MakeVectorOfValues offers API for std to create vector for values
+func MakeVectorOfValues(values []Value) Value {
+	items := make([]pvec.Item, len(values))
+	for i, v := range values {
+		items[i] = v
+	}
+	return Value{Kind: VectorValue, Data: pvec.FromSlice(items)}
+}
+
+// VectorValues returns values of vector in slice
+func VectorValues(val Value) (values []Value) {
+	val.Data.(*pvec.Vector).Visit(func(_ int, item pvec.Item) bool {
+		values = append(values, item.(Value))
+		return true
+	})
+	return
+}
+
+func vectorString(vec *pvec.Vector) string {
+	var buf bytes.Buffer
+	buf.WriteString("vector(")
+	vec.Visit(func(i int, item pvec.Item) bool {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(fmt.Sprintf("%#v", item.(Value)))
+		return true
+	})
+	buf.WriteString(")")
+	return buf.String()
+}
+
+func listToVector(listVal Value) Value {
+	var items []pvec.Item
+	it := NewListIterator(listVal)
+	for next := it.Next(); next != nil; next = it.Next() {
+		items = append(items, *next)
+	}
+	return Value{Kind: VectorValue, Data: pvec.FromSlice(items)}
+}
+
+func vectorToList(frame *Frame, vecVal Value) Value {
+	return MakeListOfValues(frame, VectorValues(vecVal))
+}
+
+func evalOperands(frame *Frame, opName string, operands []*Item) (args []Value) {
+	for _, v := range operands {
+		switch v.Type {
+		case ValueItem:
+			args = append(args, v.Data.(Value))
+		case SymbolPathItem, OperCallItem:
+			args = append(args, EvalItem(v, frame))
+		default:
+			runTimeError2(frame, "something wrong (%s)", opName)
+		}
+	}
+	return
+}
+
+func handleVectorOP(frame *Frame, operands []*Item) (retVal Value) {
+	retVal = MakeVectorOfValues(evalOperands(frame, "vector", operands))
+	return
+}
+
+func handleSetindOP(frame *Frame, operands []*Item) (retVal Value) {
+	opName := "setind"
+	if l := len(operands); l != 3 {
+		runTimeError2(frame, "Wrong amount of arguments for %s (%d given)", opName, l)
+	}
+	args := evalOperands(frame, opName, operands)
+	if args[0].Kind != VectorValue {
+		runTimeError2(frame, "First argument not vector in %s operator", opName)
+	}
+	if args[1].Kind != IntValue {
+		runTimeError2(frame, "%s: int value assumed for 2nd argument", opName)
+	}
+	vec := args[0].Data.(*pvec.Vector)
+	newVec, ok := vec.Set(args[1].Data.(int), args[2])
+	if !ok {
+		runTimeError2(frame, "%s: index out of range (len=%d)(accessed=%d)", opName, vec.Len(), args[1].Data.(int))
+	}
+	retVal = Value{Kind: VectorValue, Data: newVec}
+	return
+}
+
+// vectorItem is ind, head and last for vector
+func vectorItem(frame *Frame, opName string, val Value, index int) Value {
+	vec := val.Data.(*pvec.Vector)
+	item, found := vec.Get(index)
+	if !found {
+		if vec.Len() == 0 {
+			runTimeError2(frame, "Attempt to access empty vector in %s operator", opName)
+		}
+		runTimeError2(frame, "%s: index out of range (len=%d)(accessed=%d)", opName, vec.Len(), index)
+	}
+	return item.(Value)
+}
+
+// vectorSlice is slice, rest and rrest for vector (end index is not included)
+func vectorSlice(frame *Frame, opName string, val Value, begin, end int) Value {
+	vec := val.Data.(*pvec.Vector)
+	if vec.Len() == 0 && (opName == "rest" || opName == "rrest") {
+		runTimeError2(frame, "Attempt to access empty vector in %s operator", opName)
+	}
+	if end > vec.Len() {
+		end = vec.Len()
+	}
+	if begin < 0 {
+		begin = 0
+	}
+	if begin > end {
+		begin = end
+	}
+	newVec, ok := vec.Slice(begin, end)
+	if !ok {
+		runTimeError2(frame, "%s: index out of range (%d - %d)", opName, begin, end)
+	}
+	return Value{Kind: VectorValue, Data: newVec}
+}
+
+func vectorAppend(val Value, values []Value) Value {
+	vec := val.Data.(*pvec.Vector)
+	for _, v := range values {
+		vec = vec.Append(v)
+	}
+	return Value{Kind: VectorValue, Data: vec}
+}
+
+// vectorExtend appends items of lists and vectors to vector
+func vectorExtend(frame *Frame, opName string, values []Value) Value {
+	vec := values[0].Data.(*pvec.Vector)
+	for _, v := range values[1:] {
+		switch v.Kind {
+		case VectorValue:
+			v.Data.(*pvec.Vector).Visit(func(_ int, item pvec.Item) bool {
+				vec = vec.Append(item)
+				return true
+			})
+		case ListValue:
+			it := NewListIterator(v)
+			for next := it.Next(); next != nil; next = it.Next() {
+				vec = vec.Append(*next)
+			}
+		default:
+			runTimeError2(frame, "%s: arguments assumed to be list or vector type", opName)
+		}
+	}
+	return Value{Kind: VectorValue, Data: vec}
+}
+
+func vectorReverse(val Value) Value {
+	values := VectorValues(val)
+	for i, j := 0, len(values)-1; i < j; i, j = i+1, j-1 {
+		values[i], values[j] = values[j], values[i]
+	}
+	return MakeVectorOfValues(values)
+}
+
+// vectorFind returns indexes of items which are equal to given value,
+// if onlyFirst is set then only first one is searched (in -operator)
+func vectorFind(frame *Frame, val Value, itemval Value, onlyFirst bool) (indexes []*Item) {
+	itemAsVal := &Item{Type: ValueItem, Data: itemval}
+	val.Data.(*pvec.Vector).Visit(func(i int, item pvec.Item) bool {
+		eqResult := handleEqOP(frame, []*Item{&Item{Type: ValueItem, Data: item.(Value)}, itemAsVal})
+		if eqResult.Data.(bool) {
+			indexes = append(indexes, &Item{Type: ValueItem, Data: Value{Kind: IntValue, Data: i}})
+			return !onlyFirst
+		}
+		return true
+	})
+	return
+}
+
+func areEqualVectors(frame *Frame, v1, v2 *pvec.Vector) bool {
+	if v1.Len() != v2.Len() {
+		return false
+	}
+	isEqual := true
+	v1.Visit(func(i int, item pvec.Item) bool {
+		other, _ := v2.Get(i)
+		eqResult := handleEqOP(frame, []*Item{&Item{Type: ValueItem, Data: item.(Value)}, &Item{Type: ValueItem, Data: other.(Value)}})
+		isEqual = eqResult.Data.(bool)
+		return isEqual
+	})
+	return isEqual
+}
+
+func getHashedVector(frame *Frame, from *pvec.Vector) int {
+	buf := new(bytes.Buffer)
+	buf.WriteString("vector")
+	from.Visit(func(_ int, item pvec.Item) bool {
+		hashedKey, err := hashOfValue(frame, item.(Value))
+		if err != nil {
+			runTimeError2(frame, "illegal type for map key")
+		}
+		binary.Write(buf, binary.LittleEndian, int64(hashedKey))
+		return true
+	})
+	hash := fnv.New64a()
+	hash.Write(buf.Bytes())
+	return int(hash.Sum64())
+}
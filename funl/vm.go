@@ -0,0 +1,221 @@
+package funl
+
+// UseTreeWalker selects tree-walking evaluator instead of bytecode VM
+// (default for interpreter setting)
+// for function calls
+var UseTreeWalker bool
+
+// maxCallDepth limits nested (non-tail) calls in VM so that runaway
+// recursion gives runtime error instead of consuming all memory
+const maxCallDepth = 1000000
+
+type vmFrame struct {
+	code   *funcCode
+	pc     int
+	frame  *Frame
+	base   int      // stack size when call started
+	scopes []*Frame // enclosing frames when in match clause frame
+}
+
+// vmCall calls function by running its bytecode, calls from it are
+// handled in same loop so that FunL calls do not consume Go stack
+func vmCall(frame *Frame, evaluatedArgs []Value, callSite *SrcPos) (retVal Value) {
+	var cur vmFrame
+	nextFrame, extp, isExtProcCall := newCallFrame(frame, evaluatedArgs, callSite)
+	if isExtProcCall {
+		retVal = extp.Impl(frame, evaluatedArgs[1:])
+		return
+	}
+
+	var stack []Value
+	var callStack []vmFrame
+	// operand items given to operators are reused between operator calls
+	// (operators which keep their operands are not compiled to opOper)
+	var operScratch []*Item
+	var itemScratch []Item
+	operandsFor := func(count int) ([]*Item, []Item) {
+		if cap(operScratch) < count {
+			operScratch = make([]*Item, count)
+			itemScratch = make([]Item, count)
+		}
+		return operScratch[:count], itemScratch[:count]
+	}
+	cur = vmFrame{code: nextFrame.FuncProto.getCode(), frame: nextFrame}
+
+	pop := func() Value {
+		v := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		return v
+	}
+	popArgs := func(count int) []Value {
+		args := make([]Value, count)
+		copy(args, stack[len(stack)-count:])
+		stack = stack[:len(stack)-count]
+		return args
+	}
+
+	for {
+		instr := cur.code.instrs[cur.pc]
+		// error gets position of instruction which is executed
+		cur.frame.pos = cur.code.srcPos[cur.pc]
+		cur.pc++
+
+		switch instr.op {
+		case opConst:
+			stack = append(stack, cur.code.consts[instr.a])
+
+		case opFuncProto:
+			fv := FuncValue{FuncProto: cur.code.consts[instr.a].Data.(*Function), AccessLink: cur.frame}
+			stack = append(stack, Value{Kind: FunctionValue, Data: fv})
+
+		case opLoadSym:
+			stack = append(stack, EvalItem(cur.code.syms[instr.a], cur.frame))
+
+		case opOper:
+			site := &cur.code.opers[instr.a]
+			operands, items := operandsFor(len(site.operands))
+			args := stack[len(stack)-site.argCount:]
+			for i, operand := range site.operands {
+				if site.computed[i] {
+					items[i] = Item{Type: ValueItem, Data: args[0]}
+					operands[i] = &items[i]
+					args = args[1:]
+				} else {
+					operands[i] = operand
+				}
+			}
+			stack = stack[:len(stack)-site.argCount]
+			stack = append(stack, operTbl[site.operID](cur.frame, operands))
+
+		case opCall, opTailCall:
+			args := popArgs(instr.b)
+			callSite := cur.code.srcPos[cur.pc-1]
+			calleeFrame, extp, isExt := newCallFrame(cur.frame, args, callSite)
+			if isExt {
+				stack = append(stack, extp.Impl(cur.frame, args[1:]))
+				continue
+			}
+			if instr.op == opTailCall {
+				// backtrace continues from original caller (tail calls replace frames)
+				calleeFrame.Previous = cur.frame.Previous
+				stack = stack[:cur.base]
+				cur = vmFrame{code: calleeFrame.FuncProto.getCode(), frame: calleeFrame, base: cur.base}
+			} else {
+				if len(callStack) >= maxCallDepth {
+					runTimeError2(cur.frame, "call depth limit exceeded (%d)", maxCallDepth)
+				}
+				callStack = append(callStack, cur)
+				cur = vmFrame{code: calleeFrame.FuncProto.getCode(), frame: calleeFrame, base: len(stack)}
+			}
+
+		case opJump:
+			cur.pc = instr.a
+
+		case opJumpIfFalse, opJumpIfTrue:
+			condVal := pop()
+			if condVal.Kind != BoolValue {
+				runTimeError2(cur.frame, "%s", cur.code.msgs[instr.b])
+			}
+			if condVal.Data.(bool) == (instr.op == opJumpIfTrue) {
+				cur.pc = instr.a
+			}
+
+		case opCaseEq:
+			matchVal := pop()
+			argsForEq, items := operandsFor(2)
+			items[0] = Item{Type: ValueItem, Data: matchVal}
+			items[1] = Item{Type: ValueItem, Data: stack[len(stack)-1]}
+			argsForEq[0], argsForEq[1] = &items[0], &items[1]
+			stack = append(stack, handleEqOP(cur.frame, argsForEq))
+
+		case opPop:
+			stack = stack[:len(stack)-1]
+
+		case opDefine:
+			// Overlapping symbol names not allowed (might cause variable -like effect)
+			if cur.frame.checkDups {
+				sid := SymID(instr.b)
+				if _, symfound := cur.frame.GetSymItem(sid); symfound {
+					runTimeError2(cur.frame, "Duplicate symbol name in scope not allowed (%s)", cur.frame.SymIDs().AsString(sid))
+				}
+			}
+			cur.frame.slots[instr.a] = pop()
+
+		case opStore:
+			cur.frame.slots[instr.a] = pop()
+
+		case opWhileNext:
+			// arguments are added to new frame (previous one may be
+			// referred by closures or fibers so it is not changed)
+			args := popArgs(instr.a)
+			nextFrame := cur.frame
+			if cur.code.framesCaptured {
+				nextFrame = cur.frame.copyForWhile()
+			}
+			nextFrame.EvaluatedArgs = args
+			nextFrame.bindArgs(cur.frame, args)
+			cur.frame = nextFrame
+
+		case opLet:
+			sid := SymID(instr.a)
+			// Overlapping symbol names not allowed (might cause variable -like effect)
+			if _, symfound := cur.frame.GetSymItem(sid); symfound {
+				runTimeError2(cur.frame, "let: Duplicate symbol name in scope not allowed (%s)", cur.frame.SymIDs().AsString(sid))
+			}
+			addLetSym(cur.frame, sid, stack[len(stack)-1])
+
+		case opMatch:
+			clauseFrame, matched := cur.code.clauses[instr.b].match(cur.frame, stack[len(stack)-1])
+			if !matched {
+				cur.pc = instr.a
+				continue
+			}
+			stack = stack[:len(stack)-1]
+			cur.scopes = append(cur.scopes, cur.frame)
+			cur.frame = clauseFrame
+
+		case opMatchFail:
+			runTimeError2(cur.frame, "match: no pattern matched value (%s)", pop())
+
+		case opLeaveScope:
+			cur.frame = cur.scopes[len(cur.scopes)-1]
+			cur.scopes = cur.scopes[:len(cur.scopes)-1]
+
+		case opEvalItem:
+			item := cur.code.items[instr.a]
+			stack = append(stack, EvalItemV2(item, cur.frame, &AddInfo{evaluatingBody: instr.b == 1}))
+
+		case opEvalTail:
+			v, tc := evalTailItem(cur.code.items[instr.a], cur.frame, false)
+			if tc == nil {
+				stack = append(stack, v)
+				continue
+			}
+			calleeFrame, extp, isExt := newCallFrame(tc.frame, tc.evaluatedArgs, tc.callSite)
+			if isExt {
+				stack = append(stack, extp.Impl(tc.frame, tc.evaluatedArgs[1:]))
+				continue
+			}
+			calleeFrame.Previous = cur.frame.Previous
+			stack = stack[:cur.base]
+			cur = vmFrame{code: calleeFrame.FuncProto.getCode(), frame: calleeFrame, base: cur.base}
+
+		case opFail:
+			runTimeError2(cur.frame, "%s", cur.code.msgs[instr.a])
+
+		case opReturn:
+			v := pop()
+			stack = stack[:cur.base]
+			if len(callStack) == 0 {
+				retVal = v
+				return
+			}
+			cur = callStack[len(callStack)-1]
+			callStack = callStack[:len(callStack)-1]
+			stack = append(stack, v)
+
+		default:
+			runTimeError2(cur.frame, "invalid instruction (%d)", instr.op)
+		}
+	}
+}
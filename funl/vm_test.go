@@ -0,0 +1,236 @@
+package funl
+
+import (
+	"strings"
+	"testing"
+)
+
+const vmTestSrc = `
+ns vmtest
+
+fib = func(n)
+	if( lt(n 2)
+		n
+		plus(call(fib minus(n 1)) call(fib minus(n 2)))
+	)
+end
+
+classify = func(x)
+	case( type(x)
+		'int'    cond( lt(x 0) 'neg' eq(x 0) 'zero' 'pos')
+		'string' 'str'
+		'other'
+	)
+end
+
+logic = func(a b)
+	list(and(a b) or(a b) and(a) or(b a true))
+end
+
+closures = func(n)
+	adder = func(x) func(y) plus(x y) end end
+	add-n = call(adder n)
+	fns = list(func(x) plus(x n) end add-n)
+	list(call(add-n 1) call(add-n 10) call(head(fns) 2))
+end
+
+expanded = func()
+	l = list(1 2 3)
+	plus(l:)
+end
+
+lazy-get = func()
+	m = map('a' 1)
+	list(get(m 'a' 'default') get(m 'b' 'default'))
+end
+
+loop = proc(n)
+	while( gt(n 0) minus(n 1) 'done')
+end
+
+sum-loop = func(n)
+	sum-to = func(i acc)
+		next = plus(acc i)
+		while( gt(i 0)
+			minus(i 1)
+			next
+			acc
+		)
+	end
+	call(sum-to n 0)
+end
+
+loop-closures = func(n)
+	collect = func(i fns)
+		f = func() i end
+		while( gt(i 0)
+			minus(i 1)
+			append(fns f)
+			list(i fns)
+		)
+	end
+	_ fns = call(collect n list()):
+	list(call(head(fns)) call(head(rest(fns))) len(fns))
+end
+
+let-oper = func(x)
+	_ = let(y mul(x 2))
+	plus(y let(z 1) z)
+end
+
+matcher = func(x)
+	match(x
+		list(a b)            plus(a b)
+		list(a rest:)        list(a len(rest))
+		map('k' v)           call(func() v end)
+		type('string' s)     if(eq(s '') 'empty' s)
+		n                    if(eq(type(n) 'int') mul(n 10) n)
+	)
+end
+
+guarded = func(x)
+	match(x
+		when(n gt(n 10)) 'big'
+		when(n gt(n 0))  'small'
+		_                'other'
+	)
+end
+
+match-loop = func(n)
+	count-down = func(i)
+		match(i
+			0 'done'
+			_ call(count-down minus(i 1))
+		)
+	end
+	call(count-down n)
+end
+
+endns
+`
+
+func evalInBothModes(t *testing.T, name string, args ...Value) {
+	treeResult := evalTestSourceWith(t, interpreterWithMode(true), vmTestSrc, name, args...)
+	vmResult := evalTestSourceWith(t, interpreterWithMode(false), vmTestSrc, name, args...)
+	if treeResult.String() != vmResult.String() {
+		t.Fatalf("%s: results differ, tree walker: %s, VM: %s", name, treeResult, vmResult)
+	}
+}
+
+func TestVMAndTreeWalkerSameResults(t *testing.T) {
+	intVal := func(i int) Value { return Value{Kind: IntValue, Data: i} }
+	boolVal := func(b bool) Value { return Value{Kind: BoolValue, Data: b} }
+
+	evalInBothModes(t, "fib", intVal(15))
+	evalInBothModes(t, "classify", intVal(-5))
+	evalInBothModes(t, "classify", intVal(0))
+	evalInBothModes(t, "classify", intVal(5))
+	evalInBothModes(t, "classify", Value{Kind: StringValue, Data: "s"})
+	evalInBothModes(t, "classify", boolVal(true))
+	evalInBothModes(t, "logic", boolVal(true), boolVal(false))
+	evalInBothModes(t, "logic", boolVal(false), boolVal(false))
+	evalInBothModes(t, "closures", intVal(5))
+	evalInBothModes(t, "expanded")
+	evalInBothModes(t, "lazy-get")
+	evalInBothModes(t, "loop", intVal(100))
+	evalInBothModes(t, "sum-loop", intVal(1000))
+	evalInBothModes(t, "loop-closures", intVal(3))
+	evalInBothModes(t, "let-oper", intVal(3))
+	evalInBothModes(t, "matcher", MakeListOfValues(nil, []Value{intVal(1), intVal(2)}))
+	evalInBothModes(t, "matcher", MakeListOfValues(nil, []Value{intVal(1), intVal(2), intVal(3)}))
+	evalInBothModes(t, "matcher", Value{Kind: StringValue, Data: ""})
+	evalInBothModes(t, "matcher", Value{Kind: StringValue, Data: "s"})
+	evalInBothModes(t, "matcher", intVal(4))
+	evalInBothModes(t, "matcher", boolVal(true))
+	evalInBothModes(t, "guarded", intVal(11))
+	evalInBothModes(t, "guarded", intVal(1))
+	evalInBothModes(t, "guarded", intVal(-1))
+	evalInBothModes(t, "match-loop", intVal(100000))
+}
+
+func TestVMCompilesLoopsAndBindings(t *testing.T) {
+	interpreter := interpreterWithMode(false)
+	interpreter.Importer = &fileImporter{}
+	topFrame := loadToInterpreter(t, interpreter, vmTestSrc)
+	for _, name := range []string{"sum-loop", "loop-closures", "let-oper", "matcher", "guarded", "match-loop"} {
+		funcItem, _ := topFrame.Syms.GetByName(name)
+		fn := funcItem.Data.(Value).Data.(FuncValue).FuncProto
+		var protos []*Function
+		protos = append(protos, fn)
+		for _, item := range fn.NSpace.Syms.AsMap() {
+			if v, ok := item.Data.(Value); ok && v.Kind == FuncProtoValue {
+				protos = append(protos, v.Data.(*Function))
+			}
+		}
+		for _, proto := range protos {
+			for _, instr := range proto.getCode().instrs {
+				if instr.op == opEvalItem || instr.op == opEvalTail {
+					t.Errorf("%s: should not be evaluated by tree walker", name)
+				}
+			}
+		}
+	}
+}
+
+func benchmarkFib(b *testing.B, treeWalker bool) {
+	interpreter := interpreterWithMode(treeWalker)
+	interpreter.Importer = &fileImporter{}
+	topFrame := loadToInterpreter(b, interpreter, vmTestSrc)
+	arg := Value{Kind: IntValue, Data: 20}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if result := callInFrame(b, topFrame, "fib", arg); result.Data != 6765 {
+			b.Fatalf("unexpected result: %s", result)
+		}
+	}
+}
+
+func BenchmarkTreeWalker(b *testing.B) {
+	benchmarkFib(b, true)
+}
+
+func BenchmarkVM(b *testing.B) {
+	benchmarkFib(b, false)
+}
+
+func benchmarkLoop(b *testing.B, treeWalker bool) {
+	interpreter := interpreterWithMode(treeWalker)
+	interpreter.Importer = &fileImporter{}
+	topFrame := loadToInterpreter(b, interpreter, vmTestSrc)
+	arg := Value{Kind: IntValue, Data: 100000}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if result := callInFrame(b, topFrame, "sum-loop", arg); result.Data != 5000050000 {
+			b.Fatalf("unexpected result: %s", result)
+		}
+	}
+}
+
+func BenchmarkTreeWalkerLoop(b *testing.B) {
+	benchmarkLoop(b, true)
+}
+
+func BenchmarkVMLoop(b *testing.B) {
+	benchmarkLoop(b, false)
+}
+
+func TestVMCallDepthLimit(t *testing.T) {
+	src := `
+ns depthtest
+runaway = func(n)
+	plus(1 call(runaway plus(n 1)))
+end
+endns
+`
+	defer func() {
+		r := recover()
+		rte, ok := r.(*RuntimeError)
+		if !ok {
+			t.Fatalf("runtime error expected: %v", r)
+		}
+		if !strings.HasPrefix(rte.Error(), "call depth limit exceeded") {
+			t.Fatalf("unexpected error: %s", rte)
+		}
+	}()
+	evalTestSourceWith(t, interpreterWithMode(false), src, "runaway", Value{Kind: IntValue, Data: 0})
+}
@@ -0,0 +1,143 @@
+package funl
+
+import (
+	"fmt"
+	"reflect"
+)
+
+var (
+	errorType = reflect.TypeOf((*error)(nil)).Elem()
+	frameType = reflect.TypeOf(&Frame{})
+)
+
+// WrapGoFunc makes external function (or procedure) from Go function.
+// Arguments are converted with FromValue and results with ToValue:
+//   - if there are no results, true is returned
+//   - one result is returned as such
+//   - several results are returned in list
+//   - if last result is error, list(ok err <results>...) is returned
+//     (ok is false and err is error text if error is not nil)
+//
+// Go function may have *Frame as first parameter, it's not given
+// as argument from FunL. Variadic function can be given any amount
+// of arguments for last parameter.
+func WrapGoFunc(name string, fn interface{}, isFunction bool) (ExtProcType, error) {
+	fv := reflect.ValueOf(fn)
+	if fv.Kind() != reflect.Func || fv.IsNil() {
+		return ExtProcType{}, fmt.Errorf("%s: function required (%T given)", name, fn)
+	}
+	ft := fv.Type()
+	hasFrame := ft.NumIn() > 0 && ft.In(0) == frameType
+	hasError := ft.NumOut() > 0 && ft.Out(ft.NumOut()-1) == errorType
+
+	var params []reflect.Type
+	for i := 0; i < ft.NumIn(); i++ {
+		params = append(params, ft.In(i))
+	}
+	if hasFrame {
+		params = params[1:]
+	}
+
+	impl := func(frame *Frame, arguments []Value) Value {
+		args, err := goArgs(name, params, ft.IsVariadic(), arguments)
+		if err != nil {
+			runTimeError2(frame, "%s", err)
+		}
+		if hasFrame {
+			args = append([]reflect.Value{reflect.ValueOf(frame)}, args...)
+		}
+		results := fv.Call(args)
+
+		if !hasError {
+			return resultValue(frame, name, results)
+		}
+		errVal := results[len(results)-1]
+		values := []Value{{Kind: BoolValue, Data: errVal.IsNil()}, {Kind: StringValue, Data: ""}}
+		if !errVal.IsNil() {
+			values[1].Data = errVal.Interface().(error).Error()
+		}
+		for _, result := range results[:len(results)-1] {
+			val, err := toValueIn(frame, result.Interface())
+			if err != nil {
+				if errVal.IsNil() {
+					runTimeError2(frame, "%s: invalid result: %v", name, err)
+				}
+				// result is not meaningful with error
+				val = Value{Kind: StringValue, Data: ""}
+			}
+			values = append(values, val)
+		}
+		return MakeListOfValues(frame, values)
+	}
+	return ExtProcType{Impl: impl, IsFunction: isFunction}, nil
+}
+
+// goArgs converts arguments to Go values for parameters
+func goArgs(name string, params []reflect.Type, isVariadic bool, arguments []Value) ([]reflect.Value, error) {
+	fixed := len(params)
+	if isVariadic {
+		fixed--
+	}
+	switch l := len(arguments); {
+	case isVariadic && l < fixed:
+		return nil, fmt.Errorf("%s: wrong amount of arguments (%d), need at least %d", name, l, fixed)
+	case !isVariadic && l != fixed:
+		return nil, fmt.Errorf("%s: wrong amount of arguments (%d), need %d", name, l, fixed)
+	}
+
+	args := make([]reflect.Value, 0, len(arguments))
+	for i, arg := range arguments {
+		var t reflect.Type
+		if i < fixed {
+			t = params[i]
+		} else {
+			t = params[fixed].Elem()
+		}
+		if isScalarValue(arg) && reflect.TypeOf(arg.Data) == t {
+			// value is already of parameter type, so no conversion needed
+			args = append(args, reflect.ValueOf(arg.Data))
+			continue
+		}
+		goVal := reflect.New(t)
+		if err := FromValue(arg, goVal.Interface()); err != nil {
+			ce, ok := err.(*ConversionError)
+			if !ok {
+				return nil, fmt.Errorf("%s: argument %d: %v", name, i+1, err)
+			}
+			if ce.Path != "" {
+				return nil, fmt.Errorf("%s: argument %d (at %s): %s", name, i+1, ce.Path, ce.Message)
+			}
+			return nil, fmt.Errorf("%s: argument %d: %s", name, i+1, ce.Message)
+		}
+		args = append(args, goVal.Elem())
+	}
+	return args, nil
+}
+
+// isScalarValue returns true if value data is Go value as such (string, int, bool, float64)
+func isScalarValue(val Value) bool {
+	switch val.Kind {
+	case StringValue, IntValue, BoolValue, FloatValue:
+		return true
+	}
+	return false
+}
+
+// resultValue converts results of Go function to value
+func resultValue(frame *Frame, name string, results []reflect.Value) Value {
+	values := make([]Value, 0, len(results))
+	for _, result := range results {
+		val, err := toValueIn(frame, result.Interface())
+		if err != nil {
+			runTimeError2(frame, "%s: invalid result: %v", name, err)
+		}
+		values = append(values, val)
+	}
+	switch len(values) {
+	case 0:
+		return Value{Kind: BoolValue, Data: true}
+	case 1:
+		return values[0]
+	}
+	return MakeListOfValues(frame, values)
+}
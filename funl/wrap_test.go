@@ -0,0 +1,100 @@
+package funl
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type wrapPoint struct {
+	X int `funl:"x"`
+	Y int `funl:"y"`
+}
+
+func newWrapTestInterpreter(t *testing.T, funcs map[string]interface{}) *Interpreter {
+	interpreter := NewInterpreter()
+	topFrame := NewTopFrameWithInterpreter(interpreter)
+	for name, fn := range funcs {
+		extProc, err := WrapGoFunc("gomod:"+name, fn, name != "doit")
+		if err != nil {
+			t.Fatalf("wrapping failed: %v", err)
+		}
+		topFrame.Syms.Add(name, &Item{Type: ValueItem, Data: Value{Kind: ExtProcValue, Data: extProc}})
+	}
+	interpreter.NsDir.Put(interpreter.SymIDs.Add("gomod"), topFrame)
+	return interpreter
+}
+
+func TestWrapGoFunc(t *testing.T) {
+	interpreter := newWrapTestInterpreter(t, map[string]interface{}{
+		"repeat": strings.Repeat,
+		"split": func(s string) ([]string, error) {
+			if s == "" {
+				return nil, errors.New("empty string")
+			}
+			return strings.Split(s, ","), nil
+		},
+		"check":  func(ok bool) error { return map[bool]error{true: nil, false: errors.New("not ok")}[ok] },
+		"move":   func(p wrapPoint, dx int) wrapPoint { return wrapPoint{X: p.X + dx, Y: p.Y} },
+		"divmod": func(a, b int) (int, int) { return a / b, a % b },
+		"sum": func(frame *Frame, prefix string, nums ...float64) string {
+			var sum float64
+			for _, n := range nums {
+				sum += n
+			}
+			return fmt.Sprintf("%s%v", prefix, sum)
+		},
+		"doit": func() {},
+	})
+
+	cases := map[string]string{
+		"call(gomod.repeat 'ab' 3)":                       "'ababab'",
+		"call(gomod.split 'a,b')":                         "list(true, '', list('a', 'b'))",
+		"call(gomod.split '')":                            "list(false, 'empty string', list())",
+		"call(gomod.check true)":                          "list(true, '')",
+		"call(gomod.check false)":                         "list(false, 'not ok')",
+		"len(call(gomod.move map('x' 1 'y' 2 'z' 3) 10))": "2",
+		"get(call(gomod.move map('x' 1 'y' 2) 10) 'x')":   "11",
+		"call(gomod.divmod 7 2)":                          "list(3, 1)",
+		"call(gomod.sum 'sum:')":                          "'sum:0'",
+		"call(gomod.sum 'sum:' 1 2.5)":                    "'sum:3.5'",
+		"call(gomod.doit)":                                "true",
+	}
+	for expr, expected := range cases {
+		v, err := interpreter.Eval(expr)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", expr, err)
+			continue
+		}
+		if s := v.String(); s != expected {
+			t.Errorf("%s: unexpected result: %s (assumed %s)", expr, s, expected)
+		}
+	}
+
+	errCases := map[string]string{
+		"call(gomod.repeat 'ab')":                             "gomod:repeat: wrong amount of arguments (1), need 2",
+		"call(gomod.repeat 'ab' 'x')":                         "gomod:repeat: argument 2: cannot convert string to int",
+		"call(gomod.sum)":                                     "gomod:sum: wrong amount of arguments (0), need at least 1",
+		"call(gomod.sum 'x' 1 true)":                          "gomod:sum: argument 3: cannot convert bool to float64",
+		"call(gomod.move map('x' 'a') 1)":                     "gomod:move: argument 1 (at .x): cannot convert string to int",
+		"call(func() call(gomod.doit) end)":                   "proc call not allowed from function",
+		"call(gomod.divmod 1 0)":                              "divide by zero",
+		"call(gomod.repeat 'a' minus(0 1))":                   "negative Repeat count",
+		"call(gomod.move list(1 2) 1)":                        "cannot convert list to funl.wrapPoint",
+		"call(gomod.check 'x')":                               "gomod:check: argument 1: cannot convert string to bool",
+		"call(gomod.split 'a' 'b')":                           "gomod:split: wrong amount of arguments (2), need 1",
+		"call(gomod.sum 'x' list(1))":                         "gomod:sum: argument 2: cannot convert list to float64",
+		"call(gomod.divmod 123456789012345678901234567890 1)": "gomod:divmod: argument 1: 123456789012345678901234567890 overflows int",
+	}
+	for expr, expected := range errCases {
+		_, err := interpreter.Eval(expr)
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("%s: unexpected error: %v (assumed %s)", expr, err, expected)
+		}
+	}
+
+	if _, err := WrapGoFunc("x", 1, true); err == nil {
+		t.Errorf("should fail")
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"io/ioutil"
@@ -35,6 +36,9 @@ func main() {
 	_, goBTset := os.LookupEnv("FUNLGOBACKTRACE")
 	defer func() {
 		if r := recover(); r != nil {
+			if rte, ok := r.(*funl.RuntimeError); ok {
+				r = rte.LocatedText()
+			}
 			fmt.Println("Runtime error: ", r)
 			if goBTset {
 				debug.PrintStack()
@@ -53,7 +57,11 @@ func main() {
 	silentPtr := flag.Bool("silent", false, "does not print result of evaluation when returning from program (not silent is default)")
 	noPrintPtr := flag.Bool("noprint", false, "prevents printing from functions (by print-operator)")
 	doRTEPrintPtr := flag.Bool("rteprint", false, "enables printing RTE location and scope")
+	treeWalkPtr := flag.Bool("treewalk", false, "uses tree-walking evaluator instead of bytecode VM")
+	hamtPtr := flag.Bool("hamt", false, "uses HAMT instead of red-black tree for maps")
+	sortedMapsPtr := flag.Bool("sortedmaps", false, "iterates and prints maps and sets in sorted key order")
 	packagePtr := flag.Bool("package", false, "source file is package")
+	checkPtr := flag.Bool("check", false, "checks source file statically and prints problems as JSON (does not evaluate)")
 	var evalStr string
 	flag.StringVar(&evalStr, "eval", "", "evaluate expression")
 	var importPackageName string
@@ -66,6 +74,15 @@ func main() {
 	if *doRTEPrintPtr {
 		funl.PrintingRTElocationAndScopeEnabled = true
 	}
+	if *treeWalkPtr {
+		funl.UseTreeWalker = true
+	}
+	if *hamtPtr {
+		funl.UseHAMTMaps = true
+	}
+	if *sortedMapsPtr {
+		funl.SortedMapIteration = true
+	}
 
 	var parsedArgs []*funl.Item
 	if fargs != "" {
@@ -122,6 +139,25 @@ func main() {
 		name = "main"
 	}
 
+	if *checkPtr {
+		var diags []funl.Diagnostic
+		if importPackageName != "" {
+			diags, err = funl.CheckModuleWithPackImport(importPackageName, string(content), srcFileName, std.InitSTD)
+		} else {
+			diags, err = funl.CheckModule(string(content), srcFileName, std.InitSTD)
+		}
+		if err != nil {
+			fmt.Println(fmt.Sprintf("Error: %v", err))
+			os.Exit(2)
+		}
+		output, _ := json.MarshalIndent(diags, "", "  ")
+		fmt.Println(string(output))
+		if funl.HasErrors(diags) {
+			os.Exit(1)
+		}
+		return
+	}
+
 	var retValue funl.Value
 	if *packagePtr {
 		retValue, err = funl.FunlMainWithPackage(parsedArgs, name, srcFileName, std.InitSTD)
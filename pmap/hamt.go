@@ -0,0 +1,330 @@
+package pmap
+
+import (
+	"math/bits"
+)
+
+const (
+	hamtBits = 5
+	hamtMask = 1<<hamtBits - 1
+)
+
+// hamtEntry is either sub-node or leaf
+type hamtEntry struct {
+	sub  *hamtNode
+	leaf *Node
+}
+
+// hamtNode is bitmap indexed node, entries are only for set bits,
+// node can be modified in place by builder which owns it
+type hamtNode struct {
+	bitmap  uint32
+	entries []hamtEntry
+	owner   *HAMTBuilder
+}
+
+// HAMT is persistent hash array mapped trie, it has same API as RBMap.
+// Leaves are Nodes (Left and Right are not used) so that NodeHandler
+// can be used for collisions (several values with same key) same way as in RBMap.
+type HAMT struct {
+	root      *hamtNode
+	itemCount int
+	Handler   NodeHandler
+}
+
+// NewHAMTWithHandler returns new HAMT with handler
+func NewHAMTWithHandler(nhandler NodeHandler) *HAMT {
+	return &HAMT{Handler: nhandler}
+}
+
+// NewHAMT returns new HAMT
+func NewHAMT() *HAMT {
+	return &HAMT{}
+}
+
+func bitpos(key MKey, shift uint) uint32 {
+	return 1 << ((uint64(key) >> shift) & hamtMask)
+}
+
+func (n *hamtNode) index(bit uint32) int {
+	return bits.OnesCount32(n.bitmap & (bit - 1))
+}
+
+func (n *hamtNode) withEntry(idx int, entry hamtEntry) *hamtNode {
+	entries := make([]hamtEntry, len(n.entries))
+	copy(entries, n.entries)
+	entries[idx] = entry
+	return &hamtNode{bitmap: n.bitmap, entries: entries}
+}
+
+func (n *hamtNode) withNewEntry(idx int, bit uint32, entry hamtEntry) *hamtNode {
+	entries := make([]hamtEntry, len(n.entries)+1)
+	copy(entries, n.entries[:idx])
+	entries[idx] = entry
+	copy(entries[idx+1:], n.entries[idx:])
+	return &hamtNode{bitmap: n.bitmap | bit, entries: entries}
+}
+
+// withoutEntry returns nil if there would not be any entries left
+func (n *hamtNode) withoutEntry(idx int, bit uint32) *hamtNode {
+	if len(n.entries) == 1 {
+		return nil
+	}
+	entries := make([]hamtEntry, len(n.entries)-1)
+	copy(entries, n.entries[:idx])
+	copy(entries[idx:], n.entries[idx+1:])
+	return &hamtNode{bitmap: n.bitmap &^ bit, entries: entries}
+}
+
+// mergeLeaves makes sub-trie for two leaves with different keys
+func mergeLeaves(shift uint, leaf1, leaf2 *Node, owner *HAMTBuilder) *hamtNode {
+	bit1, bit2 := bitpos(leaf1.Key, shift), bitpos(leaf2.Key, shift)
+	if bit1 == bit2 {
+		return &hamtNode{bitmap: bit1, entries: []hamtEntry{{sub: mergeLeaves(shift+hamtBits, leaf1, leaf2, owner)}}, owner: owner}
+	}
+	if bit1 > bit2 {
+		leaf1, leaf2 = leaf2, leaf1
+	}
+	return &hamtNode{bitmap: bit1 | bit2, entries: []hamtEntry{{leaf: leaf1}, {leaf: leaf2}}, owner: owner}
+}
+
+func (n *hamtNode) put(shift uint, key MKey, val MValue, nhandler NodeHandler) (*hamtNode, bool) {
+	bit := bitpos(key, shift)
+	idx := n.index(bit)
+	if n.bitmap&bit == 0 {
+		return n.withNewEntry(idx, bit, hamtEntry{leaf: &Node{Key: key, Val: val}}), true
+	}
+	entry := n.entries[idx]
+	var newEntry hamtEntry
+	added := true
+	switch {
+	case entry.sub != nil:
+		newEntry.sub, added = entry.sub.put(shift+hamtBits, key, val, nhandler)
+	case entry.leaf.Key == key:
+		if nhandler == nil {
+			newEntry.leaf = &Node{Key: key, Val: val}
+			added = false
+		} else {
+			newEntry.leaf = nhandler.HandleSameKey(entry.leaf, key, val)
+		}
+	default:
+		newEntry.sub = mergeLeaves(shift+hamtBits, entry.leaf, &Node{Key: key, Val: val}, nil)
+	}
+	return n.withEntry(idx, newEntry), added
+}
+
+// remove returns nil node if there are no entries left
+func (n *hamtNode) remove(shift uint, key MKey, val MValue, nhandler NodeHandler) (*hamtNode, bool) {
+	bit := bitpos(key, shift)
+	if n.bitmap&bit == 0 {
+		return n, false
+	}
+	idx := n.index(bit)
+	entry := n.entries[idx]
+	if entry.sub != nil {
+		sub, found := entry.sub.remove(shift+hamtBits, key, val, nhandler)
+		switch {
+		case !found:
+			return n, false
+		case sub == nil:
+			return n.withoutEntry(idx, bit), true
+		case len(sub.entries) == 1 && sub.entries[0].leaf != nil:
+			// single leaf is moved upwards
+			return n.withEntry(idx, sub.entries[0]), true
+		}
+		return n.withEntry(idx, hamtEntry{sub: sub}), true
+	}
+	if entry.leaf.Key != key {
+		return n, false
+	}
+	if nhandler != nil {
+		replaced, found := nhandler.HandleDeletion(entry.leaf, key, val)
+		if !found {
+			return n, false
+		}
+		if replaced != nil {
+			return n.withEntry(idx, hamtEntry{leaf: replaced}), true
+		}
+	}
+	return n.withoutEntry(idx, bit), true
+}
+
+func (n *hamtNode) visit(visitor func(*Node)) {
+	for _, entry := range n.entries {
+		if entry.leaf != nil {
+			visitor(entry.leaf)
+		} else {
+			entry.sub.visit(visitor)
+		}
+	}
+}
+
+// Get finds item from map
+func (h *HAMT) Get(key MKey) (MValue, bool) {
+	n := h.root
+	for shift := uint(0); n != nil; shift += hamtBits {
+		bit := bitpos(key, shift)
+		if n.bitmap&bit == 0 {
+			return nil, false
+		}
+		entry := n.entries[n.index(bit)]
+		if entry.leaf != nil {
+			if entry.leaf.Key == key {
+				return entry.leaf.Val, true
+			}
+			return nil, false
+		}
+		n = entry.sub
+	}
+	return nil, false
+}
+
+// Put puts value to map
+func (h *HAMT) Put(key MKey, val MValue) *HAMT {
+	if h.root == nil {
+		return &HAMT{root: &hamtNode{bitmap: bitpos(key, 0), entries: []hamtEntry{{leaf: &Node{Key: key, Val: val}}}}, itemCount: 1, Handler: h.Handler}
+	}
+	newroot, added := h.root.put(0, key, val, h.Handler)
+	count := h.itemCount
+	if added {
+		count++
+	}
+	return &HAMT{root: newroot, itemCount: count, Handler: h.Handler}
+}
+
+// Delete returns new map where key is removed, val is given to handler
+// so that it can check actual key value (in case several values have same key)
+func (h *HAMT) Delete(key MKey, val MValue) (*HAMT, bool) {
+	if h.root == nil {
+		return h, false
+	}
+	newroot, found := h.root.remove(0, key, val, h.Handler)
+	if !found {
+		return h, false
+	}
+	return &HAMT{root: newroot, itemCount: h.itemCount - 1, Handler: h.Handler}, true
+}
+
+// IsEmpty is true if map is empty
+func (h *HAMT) IsEmpty() bool {
+	return h.root == nil
+}
+
+// Count return number of items in map
+func (h *HAMT) Count() int {
+	return h.itemCount
+}
+
+// VisitAll visits all leaf nodes and calls handler
+func (h *HAMT) VisitAll(visitor func(*Node)) {
+	if h.root == nil {
+		return
+	}
+	h.root.visit(visitor)
+}
+
+// HAMTBuilder is transient HAMT which is modified in place. Nodes created by builder
+// are owned by it and other nodes are copied before modifying so that
+// HAMT from which builder was made is not changed.
+// Builder cannot be used after it's frozen to HAMT.
+type HAMTBuilder struct {
+	root      *hamtNode
+	itemCount int
+	handler   NodeHandler
+	frozen    bool
+}
+
+// Builder returns builder which has items of map
+func (h *HAMT) Builder() *HAMTBuilder {
+	return &HAMTBuilder{root: h.root, itemCount: h.itemCount, handler: h.Handler}
+}
+
+// editable returns node which can be modified by builder
+func (n *hamtNode) editable(owner *HAMTBuilder) *hamtNode {
+	if n.owner == owner {
+		return n
+	}
+	entries := make([]hamtEntry, len(n.entries), len(n.entries)+1)
+	copy(entries, n.entries)
+	return &hamtNode{bitmap: n.bitmap, entries: entries, owner: owner}
+}
+
+func (n *hamtNode) putInPlace(shift uint, key MKey, val MValue, owner *HAMTBuilder) (*hamtNode, bool) {
+	n = n.editable(owner)
+	bit := bitpos(key, shift)
+	idx := n.index(bit)
+	if n.bitmap&bit == 0 {
+		n.entries = append(n.entries, hamtEntry{})
+		copy(n.entries[idx+1:], n.entries[idx:])
+		n.entries[idx] = hamtEntry{leaf: &Node{Key: key, Val: val}}
+		n.bitmap |= bit
+		return n, true
+	}
+	entry := n.entries[idx]
+	added := true
+	switch {
+	case entry.sub != nil:
+		n.entries[idx].sub, added = entry.sub.putInPlace(shift+hamtBits, key, val, owner)
+	case entry.leaf.Key == key:
+		if owner.handler == nil {
+			n.entries[idx].leaf = &Node{Key: key, Val: val}
+			added = false
+		} else {
+			n.entries[idx].leaf = owner.handler.HandleSameKey(entry.leaf, key, val)
+		}
+	default:
+		n.entries[idx] = hamtEntry{sub: mergeLeaves(shift+hamtBits, entry.leaf, &Node{Key: key, Val: val}, owner)}
+	}
+	return n, added
+}
+
+func (b *HAMTBuilder) checkNotFrozen() {
+	if b.frozen {
+		panic("pmap: builder used after freeze")
+	}
+}
+
+// Get finds item from builder
+func (b *HAMTBuilder) Get(key MKey) (MValue, bool) {
+	b.checkNotFrozen()
+	return (&HAMT{root: b.root}).Get(key)
+}
+
+// Put puts value to builder
+func (b *HAMTBuilder) Put(key MKey, val MValue) {
+	b.checkNotFrozen()
+	if b.root == nil {
+		b.root = &hamtNode{owner: b}
+	}
+	var added bool
+	b.root, added = b.root.putInPlace(0, key, val, b)
+	if added {
+		b.itemCount++
+	}
+}
+
+// Delete removes key from builder (path is copied as in HAMT)
+func (b *HAMTBuilder) Delete(key MKey, val MValue) bool {
+	b.checkNotFrozen()
+	if b.root == nil {
+		return false
+	}
+	newroot, found := b.root.remove(0, key, val, b.handler)
+	if found {
+		b.root = newroot
+		b.itemCount--
+	}
+	return found
+}
+
+// Count returns number of items in builder
+func (b *HAMTBuilder) Count() int {
+	return b.itemCount
+}
+
+// Freeze returns HAMT of items, builder cannot be used after that
+func (b *HAMTBuilder) Freeze() *HAMT {
+	b.checkNotFrozen()
+	b.frozen = true
+	return &HAMT{root: b.root, itemCount: b.itemCount, Handler: b.handler}
+}
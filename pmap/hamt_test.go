@@ -0,0 +1,305 @@
+package pmap
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func verifyHAMT(t *testing.T, gomap map[MKey]int, h *HAMT) {
+	if h.Count() != len(gomap) {
+		t.Fatalf("counts dont match (got: %d)(expect: %d)", h.Count(), len(gomap))
+	}
+	for k, v := range gomap {
+		val, found := h.Get(k)
+		if !found || val != v {
+			t.Fatalf("not found (%d)(%v)(%v)", k, v, val)
+		}
+	}
+	visited := 0
+	h.VisitAll(func(node *Node) {
+		if v, found := gomap[node.Key]; !found || v != node.Val {
+			t.Fatalf("unexpected item (%d)(%v)", node.Key, node.Val)
+		}
+		visited++
+	})
+	if visited != len(gomap) {
+		t.Fatalf("visit count does not match (got: %d)(expect: %d)", visited, len(gomap))
+	}
+}
+
+func countHAMTNodes(n *hamtNode) (count int) {
+	if n == nil {
+		return 0
+	}
+	count = 1
+	for _, entry := range n.entries {
+		if entry.sub != nil {
+			count += countHAMTNodes(entry.sub)
+		}
+	}
+	return
+}
+
+func TestHAMTPutGetDelete(t *testing.T) {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	gomap := make(map[MKey]int)
+	h := NewHAMT()
+
+	type version struct {
+		gomap map[MKey]int
+		h     *HAMT
+	}
+	var versions []version
+	for round := 0; round < 20; round++ {
+		for i := 0; i < 500; i++ {
+			// small keys are used too so that there are long common prefixes
+			key := MKey(r.Int63())
+			if i%2 == 0 {
+				key = MKey(r.Intn(1000))
+			}
+			if r.Intn(3) == 0 {
+				var found bool
+				h, found = h.Delete(key, nil)
+				if _, expected := gomap[key]; expected != found {
+					t.Fatalf("unexpected delete result (%d)(%v)", key, found)
+				}
+				delete(gomap, key)
+			} else {
+				h = h.Put(key, i)
+				gomap[key] = i
+			}
+		}
+		copied := make(map[MKey]int)
+		for k, v := range gomap {
+			copied[k] = v
+		}
+		versions = append(versions, version{gomap: copied, h: h})
+	}
+	for _, v := range versions {
+		verifyHAMT(t, v.gomap, v.h)
+	}
+
+	for k := range gomap {
+		h, _ = h.Delete(k, nil)
+	}
+	if !h.IsEmpty() || h.Count() != 0 {
+		t.Errorf("map should be empty")
+	}
+}
+
+func TestHAMTKeysWithCommonPrefix(t *testing.T) {
+	keys := []MKey{0, 1 << 60, -1 << 63, -1, 1<<60 | 1<<55, 1 << 55}
+	h := NewHAMT()
+	gomap := make(map[MKey]int)
+	for i, k := range keys {
+		h = h.Put(k, i)
+		gomap[k] = i
+	}
+	verifyHAMT(t, gomap, h)
+	for _, k := range keys {
+		h, _ = h.Delete(k, nil)
+		delete(gomap, k)
+		verifyHAMT(t, gomap, h)
+	}
+}
+
+func TestHAMTNodeCountStaysSmallInPutDelCycles(t *testing.T) {
+	const (
+		liveCount = 1000
+		cycles    = 2 * SOME
+	)
+	r := rand.New(rand.NewSource(1))
+	keys := make([]MKey, 0, cycles+liveCount)
+	for i := 0; i < cycles+liveCount; i++ {
+		keys = append(keys, MKey(r.Int63()))
+	}
+	h := NewHAMT()
+	for i := 0; i < liveCount; i++ {
+		h = h.Put(keys[i], i)
+	}
+	maxNodes := countHAMTNodes(h.root)
+	for i := liveCount; i < cycles+liveCount; i++ {
+		h = h.Put(keys[i], i)
+		h, _ = h.Delete(keys[i-liveCount], nil)
+	}
+	if h.Count() != liveCount {
+		t.Errorf("unexpected count (%d)", h.Count())
+	}
+	if cnt := countHAMTNodes(h.root); cnt > 2*maxNodes {
+		t.Errorf("too many nodes (%d)(initially: %d)", cnt, maxNodes)
+	}
+}
+
+// benchmarks compare RBMap and HAMT with random (hash like) keys
+
+var benchSizes = []int{1000, 10 * 1000, 100 * 1000, 1000 * 1000}
+
+func benchKeys(n int) []MKey {
+	r := rand.New(rand.NewSource(int64(n)))
+	keys := make([]MKey, n)
+	for i := range keys {
+		keys[i] = MKey(r.Int63())
+	}
+	return keys
+}
+
+func benchRBMap(keys []MKey) *RBMap {
+	m := NewRBMap()
+	for i, k := range keys {
+		m = m.Put(k, i)
+	}
+	return m
+}
+
+func benchHAMT(keys []MKey) *HAMT {
+	h := NewHAMT()
+	for i, k := range keys {
+		h = h.Put(k, i)
+	}
+	return h
+}
+
+func BenchmarkGet(b *testing.B) {
+	for _, size := range benchSizes {
+		keys := benchKeys(size)
+		rbm, h := benchRBMap(keys), benchHAMT(keys)
+		b.Run(fmt.Sprintf("RBMap-%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				rbm.Get(keys[i%size])
+			}
+		})
+		b.Run(fmt.Sprintf("HAMT-%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				h.Get(keys[i%size])
+			}
+		})
+	}
+}
+
+func BenchmarkPut(b *testing.B) {
+	for _, size := range benchSizes {
+		keys := benchKeys(size)
+		newKeys := benchKeys(size + 1)
+		rbm, h := benchRBMap(keys), benchHAMT(keys)
+		b.Run(fmt.Sprintf("RBMap-%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				rbm.Put(newKeys[i%size], i)
+			}
+		})
+		b.Run(fmt.Sprintf("HAMT-%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				h.Put(newKeys[i%size], i)
+			}
+		})
+	}
+}
+
+func BenchmarkDel(b *testing.B) {
+	for _, size := range benchSizes {
+		keys := benchKeys(size)
+		rbm, h := benchRBMap(keys), benchHAMT(keys)
+		b.Run(fmt.Sprintf("RBMap-%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				rbm.Delete(keys[i%size], nil)
+			}
+		})
+		b.Run(fmt.Sprintf("HAMT-%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				h.Delete(keys[i%size], nil)
+			}
+		})
+	}
+}
+
+func BenchmarkIterate(b *testing.B) {
+	for _, size := range benchSizes {
+		keys := benchKeys(size)
+		rbm, h := benchRBMap(keys), benchHAMT(keys)
+		count := 0
+		visitor := func(*Node) { count++ }
+		b.Run(fmt.Sprintf("RBMap-%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				rbm.VisitAll(visitor)
+			}
+		})
+		b.Run(fmt.Sprintf("HAMT-%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				h.VisitAll(visitor)
+			}
+		})
+	}
+}
+
+func TestHAMTBuilder(t *testing.T) {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	origMap := make(map[MKey]int)
+	orig := NewHAMT()
+	for i := 0; i < 1000; i++ {
+		key := MKey(r.Int63())
+		orig = orig.Put(key, i)
+		origMap[key] = i
+	}
+
+	builtMap := make(map[MKey]int)
+	for k, v := range origMap {
+		builtMap[k] = v
+	}
+	b := orig.Builder()
+	for i := 0; i < 5000; i++ {
+		key := MKey(r.Int63())
+		if i%3 == 0 {
+			key = MKey(r.Intn(100))
+		}
+		if _, found := builtMap[key]; found {
+			continue
+		}
+		b.Put(key, -i)
+		builtMap[key] = -i
+	}
+	for k := range origMap {
+		if r.Intn(4) == 0 {
+			if !b.Delete(k, nil) {
+				t.Fatalf("key not found from builder (%d)", k)
+			}
+			delete(builtMap, k)
+		}
+	}
+	if b.Count() != len(builtMap) {
+		t.Errorf("unexpected count (%d)(%d)", b.Count(), len(builtMap))
+	}
+	built := b.Freeze()
+	verifyHAMT(t, builtMap, built)
+	verifyHAMT(t, origMap, orig)
+
+	// new builder from frozen map should not change it
+	b2 := built.Builder()
+	b2.Put(MKey(-1), 1)
+	verifyHAMT(t, builtMap, built)
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("using frozen builder should panic")
+		}
+	}()
+	b.Put(MKey(-1), 1)
+}
+
+func BenchmarkBuild(b *testing.B) {
+	keys := benchKeys(100 * 1000)
+	b.Run("Put", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			benchHAMT(keys)
+		}
+	})
+	b.Run("Builder", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			builder := NewHAMT().Builder()
+			for j, k := range keys {
+				builder.Put(k, j)
+			}
+			builder.Freeze()
+		}
+	})
+}
@@ -343,39 +343,157 @@ func (rbm *RBMap) Put(key MKey, val MValue) *RBMap {
 	return &RBMap{Root: newnode, itemCount: rbm.itemCount + 1, Handler: rbm.Handler}
 }
 
-func copyPath(node *Node, key MKey, val MValue, nhandler NodeHandler) (*Node, bool) {
+func isRed(node *Node) bool {
+	return node != nil && node.Color == RED
+}
+
+func isBlack(node *Node) bool {
+	return node != nil && node.Color == BLACK
+}
+
+// newNode makes new node with key and value taken from kv -node
+func newNode(color int, left *Node, kv *Node, right *Node) *Node {
+	return &Node{Left: left, Right: right, Color: color, Key: kv.Key, Val: kv.Val}
+}
+
+// rebalance is balancing used in deletion (Kahrs), it doesn't modify any node
+func rebalance(left *Node, kv *Node, right *Node) *Node {
+	switch {
+	case isRed(left) && isRed(right):
+		return newNode(RED, newNode(BLACK, left.Left, left, left.Right), kv, newNode(BLACK, right.Left, right, right.Right))
+	case isRed(left) && isRed(left.Left):
+		return newNode(RED, newNode(BLACK, left.Left.Left, left.Left, left.Left.Right), left, newNode(BLACK, left.Right, kv, right))
+	case isRed(left) && isRed(left.Right):
+		return newNode(RED, newNode(BLACK, left.Left, left, left.Right.Left), left.Right, newNode(BLACK, left.Right.Right, kv, right))
+	case isRed(right) && isRed(right.Right):
+		return newNode(RED, newNode(BLACK, left, kv, right.Left), right, newNode(BLACK, right.Right.Left, right.Right, right.Right.Right))
+	case isRed(right) && isRed(right.Left):
+		return newNode(RED, newNode(BLACK, left, kv, right.Left.Left), right.Left, newNode(BLACK, right.Left.Right, right, right.Right))
+	}
+	return newNode(BLACK, left, kv, right)
+}
+
+func redden(node *Node) *Node {
+	if !isBlack(node) {
+		panic("pmap: black node assumed")
+	}
+	return newNode(RED, node.Left, node, node.Right)
+}
+
+// balanceLeft fixes tree when black height of left subtree is decreased by one
+func balanceLeft(left *Node, kv *Node, right *Node) *Node {
+	switch {
+	case isRed(left):
+		return newNode(RED, newNode(BLACK, left.Left, left, left.Right), kv, right)
+	case isBlack(right):
+		return rebalance(left, kv, redden(right))
+	case isRed(right) && isBlack(right.Left):
+		return newNode(RED, newNode(BLACK, left, kv, right.Left.Left), right.Left, rebalance(right.Left.Right, right, redden(right.Right)))
+	}
+	panic("pmap: invalid tree in deletion")
+}
+
+// balanceRight fixes tree when black height of right subtree is decreased by one
+func balanceRight(left *Node, kv *Node, right *Node) *Node {
+	switch {
+	case isRed(right):
+		return newNode(RED, left, kv, newNode(BLACK, right.Left, right, right.Right))
+	case isBlack(left):
+		return rebalance(redden(left), kv, right)
+	case isRed(left) && isBlack(left.Right):
+		return newNode(RED, rebalance(redden(left.Left), left, left.Right.Left), left.Right, newNode(BLACK, left.Right.Right, kv, right))
+	}
+	panic("pmap: invalid tree in deletion")
+}
+
+// fuse joins subtrees of removed node
+func fuse(left *Node, right *Node) *Node {
+	switch {
+	case left == nil:
+		return right
+	case right == nil:
+		return left
+	case isBlack(left) && isRed(right):
+		return newNode(RED, fuse(left, right.Left), right, right.Right)
+	case isRed(left) && isBlack(right):
+		return newNode(RED, left.Left, left, fuse(left.Right, right))
+	case isRed(left) && isRed(right):
+		middle := fuse(left.Right, right.Left)
+		if isRed(middle) {
+			return newNode(RED, newNode(RED, left.Left, left, middle.Left), middle, newNode(RED, middle.Right, right, right.Right))
+		}
+		return newNode(RED, left.Left, left, newNode(RED, middle, right, right.Right))
+	}
+	// both are black
+	middle := fuse(left.Right, right.Left)
+	if isRed(middle) {
+		return newNode(RED, newNode(BLACK, left.Left, left, middle.Left), middle, newNode(BLACK, middle.Right, right, right.Right))
+	}
+	return balanceLeft(left.Left, left, newNode(BLACK, middle, right, right.Right))
+}
+
+// remove copies path to node with key and removes or replaces the node,
+// node is replaced if handler returns node (same hash is used by other values)
+func remove(node *Node, key MKey, val MValue, nhandler NodeHandler) (newnode *Node, found bool, removed bool) {
 	if node == nil {
-		return nil, false
+		return nil, false, false
+	}
+	if key < node.Key {
+		left, found, removed := remove(node.Left, key, val, nhandler)
+		switch {
+		case !found:
+			return node, false, false
+		case !removed:
+			return newNode(node.Color, left, node, node.Right), true, false
+		case isBlack(node.Left):
+			return balanceLeft(left, node, node.Right), true, true
+		}
+		return newNode(RED, left, node, node.Right), true, true
+	} else if key > node.Key {
+		right, found, removed := remove(node.Right, key, val, nhandler)
+		switch {
+		case !found:
+			return node, false, false
+		case !removed:
+			return newNode(node.Color, node.Left, node, right), true, false
+		case isBlack(node.Right):
+			return balanceRight(node.Left, node, right), true, true
+		}
+		return newNode(RED, node.Left, node, right), true, true
 	}
-	if key > node.Key {
-		nodecopy := *node
-		newnode, found := copyPath(nodecopy.Right, key, val, nhandler)
-		nodecopy.Right = newnode
-		return &nodecopy, found
-	} else if key < node.Key {
-		nodecopy := *node
-		newnode, found := copyPath(nodecopy.Left, key, val, nhandler)
-		nodecopy.Left = newnode
-		return &nodecopy, found
-	} else {
-		return nhandler.MarkDeletion(node, key, val)
+	if nhandler == nil {
+		return fuse(node.Left, node.Right), true, true
+	}
+	replaced, found := nhandler.HandleDeletion(node, key, val)
+	if !found {
+		return node, false, false
+	}
+	if replaced != nil {
+		return replaced, true, false
 	}
+	return fuse(node.Left, node.Right), true, true
 }
 
-//Modify copies path with modified value
-func (rbm *RBMap) Modify(key MKey, val MValue) (*RBMap, bool) {
+//Delete returns new map where key is removed, val is given to handler
+//so that it can check actual key value (in case several values have same key)
+func (rbm *RBMap) Delete(key MKey, val MValue) (*RBMap, bool) {
 	if rbm.IsEmpty() {
 		return rbm, false
 	}
-	nodeCopy := *rbm.Root
-	newnode, found := copyPath(&nodeCopy, key, val, rbm.Handler)
-	return &RBMap{Root: newnode, itemCount: rbm.itemCount, Handler: rbm.Handler}, found
+	newroot, found, removed := remove(rbm.Root, key, val, rbm.Handler)
+	if !found {
+		return rbm, false
+	}
+	if removed && isRed(newroot) {
+		newroot = newNode(BLACK, newroot.Left, newroot, newroot.Right)
+	}
+	return &RBMap{Root: newroot, itemCount: rbm.itemCount - 1, Handler: rbm.Handler}, true
 }
 
-//NodeHandler is interface
+//NodeHandler is interface, HandleDeletion returns nil node if whole node can be removed
 type NodeHandler interface {
 	HandleSameKey(*Node, MKey, MValue) *Node
-	MarkDeletion(*Node, MKey, MValue) (*Node, bool)
+	HandleDeletion(*Node, MKey, MValue) (*Node, bool)
 }
 
 //NewRBMapWithHandler returns new map with handler
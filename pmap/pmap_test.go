@@ -785,3 +785,104 @@ func TestParaRBmap(t *testing.T) {
 	}
 	wg.Wait()
 }
+
+func countNodes(rbmap *RBMap) (count int) {
+	rbmap.VisitAll(func(*Node) { count++ })
+	return
+}
+
+func TestDelete(t *testing.T) {
+	s := rand.NewSource(time.Now().UnixNano())
+	r := rand.New(s)
+	gomap := make(map[int]string)
+	rbmap := NewRBMap()
+	for i := 0; i < 2000; i++ {
+		rkey := r.Intn(1000)
+		if _, found := gomap[rkey]; !found {
+			gomap[rkey] = fmt.Sprintf("val-%d", rkey)
+			rbmap = rbmap.Put(MKey(rkey), gomap[rkey])
+		}
+	}
+
+	versions := []*RBMap{rbmap}
+	gomaps := []map[int]string{gomap}
+	for round := 0; round < 10; round++ {
+		prevGomap := gomaps[len(gomaps)-1]
+		newGomap := make(map[int]string)
+		for k, v := range prevGomap {
+			newGomap[k] = v
+		}
+		newRbmap := versions[len(versions)-1]
+		for i := 0; i < 50; i++ {
+			rkey := r.Intn(1000)
+			var found bool
+			newRbmap, found = newRbmap.Delete(MKey(rkey), nil)
+			if _, expected := newGomap[rkey]; expected != found {
+				t.Fatalf("unexpected delete result (%d)(%v)", rkey, found)
+			}
+			delete(newGomap, rkey)
+		}
+		if s, ok := verifyRBInvariants(newRbmap); !ok {
+			t.Fatalf("invariant failed (%s)", s)
+		}
+		versions = append(versions, newRbmap)
+		gomaps = append(gomaps, newGomap)
+	}
+
+	// all versions should remain unchanged
+	for ind, v := range versions {
+		if s, ok := compareMaps(gomaps[ind], v); !ok {
+			t.Errorf("version %d differs (%s)", ind, s)
+		}
+		if v.Count() != len(gomaps[ind]) {
+			t.Errorf("version %d count differs (%d)(%d)", ind, v.Count(), len(gomaps[ind]))
+		}
+	}
+
+	// deleting all should give empty map
+	last := versions[len(versions)-1]
+	for k := range gomaps[len(gomaps)-1] {
+		last, _ = last.Delete(MKey(k), nil)
+		if s, ok := verifyRBInvariants(last); !ok {
+			t.Fatalf("invariant failed (%s)", s)
+		}
+	}
+	if !last.IsEmpty() || last.Count() != 0 {
+		t.Errorf("map should be empty")
+	}
+	if _, found := last.Delete(1, nil); found {
+		t.Errorf("nothing should be found from empty map")
+	}
+}
+
+func TestNodeCountStaysSmallInPutDelCycles(t *testing.T) {
+	const (
+		liveCount = 1000
+		cycles    = 2 * SOME
+	)
+	rbmap := NewRBMap()
+	for i := 0; i < liveCount; i++ {
+		rbmap = rbmap.Put(MKey(i), i)
+	}
+	for i := liveCount; i < cycles+liveCount; i++ {
+		rbmap = rbmap.Put(MKey(i), i)
+		rbmap, _ = rbmap.Delete(MKey(i-liveCount), nil)
+	}
+	if cnt := countNodes(rbmap); cnt != liveCount || rbmap.Count() != liveCount {
+		t.Errorf("unexpected node count (%d)(%d)", cnt, rbmap.Count())
+	}
+	if s, ok := verifyRBInvariants(rbmap); !ok {
+		t.Errorf("invariant failed (%s)", s)
+	}
+}
+
+func BenchmarkDeleteRBmap(b *testing.B) {
+	rbmap := NewRBMap()
+	for i := 0; i < b.N; i++ {
+		rbmap = rbmap.Put(MKey(i), i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rbmap, _ = rbmap.Delete(MKey(i), nil)
+	}
+}
@@ -0,0 +1,209 @@
+package pvec
+
+const (
+	bits  = 5
+	width = 1 << bits
+	mask  = width - 1
+)
+
+// Item is vector item
+type Item interface{}
+
+type node struct {
+	children []*node
+	items    []Item
+}
+
+// trie is persistent bit-partitioned vector trie (last items are in tail)
+type trie struct {
+	count int
+	shift uint
+	root  *node
+	tail  []Item
+}
+
+// Vector is persistent vector, slices are views to same trie
+type Vector struct {
+	t     *trie
+	start int
+	end   int
+}
+
+var emptyTrie = &trie{shift: bits, root: &node{}}
+
+// New returns empty vector
+func New() *Vector {
+	return &Vector{t: emptyTrie}
+}
+
+// FromSlice returns vector containing given items
+func FromSlice(items []Item) *Vector {
+	t := emptyTrie
+	for _, item := range items {
+		t = t.push(item)
+	}
+	return &Vector{t: t, end: t.count}
+}
+
+// Len returns number of items in vector
+func (v *Vector) Len() int {
+	return v.end - v.start
+}
+
+// Get returns item at index
+func (v *Vector) Get(index int) (Item, bool) {
+	if index < 0 || index >= v.Len() {
+		return nil, false
+	}
+	return v.t.get(v.start + index), true
+}
+
+// Set returns new vector with item replaced at index
+func (v *Vector) Set(index int, item Item) (*Vector, bool) {
+	if index < 0 || index >= v.Len() {
+		return nil, false
+	}
+	return &Vector{t: v.t.set(v.start+index, item), start: v.start, end: v.end}, true
+}
+
+// Append returns new vector with item added to end
+func (v *Vector) Append(item Item) *Vector {
+	if v.end < v.t.count {
+		// view ends before end of trie so lets overwrite next one
+		return &Vector{t: v.t.set(v.end, item), start: v.start, end: v.end + 1}
+	}
+	return &Vector{t: v.t.push(item), start: v.start, end: v.end + 1}
+}
+
+// Slice returns vector of items from begin index to end index (end not included)
+func (v *Vector) Slice(begin, end int) (*Vector, bool) {
+	if begin < 0 || end > v.Len() || begin > end {
+		return nil, false
+	}
+	return &Vector{t: v.t, start: v.start + begin, end: v.start + end}, true
+}
+
+// Visit calls visitor for each item in order until visitor returns false
+func (v *Vector) Visit(visitor func(index int, item Item) bool) {
+	for i := v.start; i < v.end; {
+		items := v.t.itemsFor(i)
+		from := i & mask
+		if i >= v.t.tailOffset() {
+			from = i - v.t.tailOffset()
+		}
+		for j := from; j < len(items) && i < v.end; j++ {
+			if !visitor(i-v.start, items[j]) {
+				return
+			}
+			i++
+		}
+	}
+}
+
+// ToSlice returns items of vector in slice
+func (v *Vector) ToSlice() []Item {
+	items := make([]Item, 0, v.Len())
+	v.Visit(func(_ int, item Item) bool {
+		items = append(items, item)
+		return true
+	})
+	return items
+}
+
+func (t *trie) tailOffset() int {
+	if t.count < width {
+		return 0
+	}
+	return ((t.count - 1) >> bits) << bits
+}
+
+// itemsFor returns leaf items which contain index
+func (t *trie) itemsFor(index int) []Item {
+	if index >= t.tailOffset() {
+		return t.tail
+	}
+	n := t.root
+	for level := t.shift; level > 0; level -= bits {
+		n = n.children[(index>>level)&mask]
+	}
+	return n.items
+}
+
+func (t *trie) get(index int) Item {
+	if index >= t.tailOffset() {
+		return t.tail[index-t.tailOffset()]
+	}
+	return t.itemsFor(index)[index&mask]
+}
+
+func (t *trie) set(index int, item Item) *trie {
+	if index >= t.tailOffset() {
+		newTail := make([]Item, len(t.tail))
+		copy(newTail, t.tail)
+		newTail[index-t.tailOffset()] = item
+		return &trie{count: t.count, shift: t.shift, root: t.root, tail: newTail}
+	}
+	return &trie{count: t.count, shift: t.shift, root: setInNode(t.root, t.shift, index, item), tail: t.tail}
+}
+
+func setInNode(n *node, level uint, index int, item Item) *node {
+	if level == 0 {
+		items := make([]Item, len(n.items))
+		copy(items, n.items)
+		items[index&mask] = item
+		return &node{items: items}
+	}
+	children := make([]*node, len(n.children))
+	copy(children, n.children)
+	sub := (index >> level) & mask
+	children[sub] = setInNode(children[sub], level-bits, index, item)
+	return &node{children: children}
+}
+
+func (t *trie) push(item Item) *trie {
+	if t.count-t.tailOffset() < width {
+		newTail := make([]Item, len(t.tail), len(t.tail)+1)
+		copy(newTail, t.tail)
+		newTail = append(newTail, item)
+		return &trie{count: t.count + 1, shift: t.shift, root: t.root, tail: newTail}
+	}
+	// tail is full, it's moved to trie
+	tailNode := &node{items: t.tail}
+	shift := t.shift
+	var root *node
+	if (t.count >> bits) > (1 << t.shift) {
+		// root overflow
+		root = &node{children: []*node{t.root, newPath(t.shift, tailNode)}}
+		shift += bits
+	} else {
+		root = pushTail(t.count, t.shift, t.root, tailNode)
+	}
+	return &trie{count: t.count + 1, shift: shift, root: root, tail: []Item{item}}
+}
+
+func newPath(level uint, n *node) *node {
+	if level == 0 {
+		return n
+	}
+	return &node{children: []*node{newPath(level-bits, n)}}
+}
+
+func pushTail(count int, level uint, parent *node, tailNode *node) *node {
+	sub := ((count - 1) >> level) & mask
+	children := make([]*node, len(parent.children), len(parent.children)+1)
+	copy(children, parent.children)
+	var toInsert *node
+	if level == bits {
+		toInsert = tailNode
+	} else if sub < len(parent.children) {
+		toInsert = pushTail(count, level-bits, parent.children[sub], tailNode)
+	} else {
+		toInsert = newPath(level-bits, tailNode)
+	}
+	if sub < len(children) {
+		children[sub] = toInsert
+	} else {
+		children = append(children, toInsert)
+	}
+	return &node{children: children}
+}
@@ -0,0 +1,128 @@
+package pvec
+
+import (
+	"testing"
+)
+
+const (
+	MANY = 100 * 1000
+)
+
+func makeVector(n int) *Vector {
+	v := New()
+	for i := 0; i < n; i++ {
+		v = v.Append(i)
+	}
+	return v
+}
+
+func verifyItems(t *testing.T, v *Vector, expected []int) {
+	if v.Len() != len(expected) {
+		t.Fatalf("wrong length (got: %d)(expect: %d)", v.Len(), len(expected))
+	}
+	for i, exp := range expected {
+		item, found := v.Get(i)
+		if !found || item != exp {
+			t.Fatalf("wrong item at %d (got: %v)(expect: %d)", i, item, exp)
+		}
+	}
+	visited := 0
+	v.Visit(func(index int, item Item) bool {
+		if index != visited || item != expected[index] {
+			t.Fatalf("wrong visit at %d (got: %v)(expect: %d)", index, item, expected[index])
+		}
+		visited++
+		return true
+	})
+	if visited != len(expected) {
+		t.Fatalf("wrong visit count (got: %d)(expect: %d)", visited, len(expected))
+	}
+}
+
+func TestAppendAndGet(t *testing.T) {
+	for _, n := range []int{0, 1, 31, 32, 33, 1024, 1025, 32*32*32 + 100} {
+		expected := make([]int, n)
+		for i := range expected {
+			expected[i] = i
+		}
+		verifyItems(t, makeVector(n), expected)
+	}
+	if _, found := makeVector(10).Get(10); found {
+		t.Errorf("out of range index should not be found")
+	}
+}
+
+func TestSetIsPersistent(t *testing.T) {
+	orig := makeVector(MANY)
+	changed := orig
+	for i := 0; i < MANY; i += 7 {
+		changed, _ = changed.Set(i, -i)
+	}
+	for i := 0; i < MANY; i++ {
+		origItem, _ := orig.Get(i)
+		changedItem, _ := changed.Get(i)
+		if origItem != i {
+			t.Fatalf("original changed at %d (%v)", i, origItem)
+		}
+		if (i%7 == 0 && changedItem != -i) || (i%7 != 0 && changedItem != i) {
+			t.Fatalf("wrong item at %d (%v)", i, changedItem)
+		}
+	}
+	if _, ok := orig.Set(MANY, 0); ok {
+		t.Errorf("out of range set should fail")
+	}
+}
+
+func TestSlice(t *testing.T) {
+	v := makeVector(100)
+	s, ok := v.Slice(10, 50)
+	if !ok {
+		t.Fatalf("slice failed")
+	}
+	var expected []int
+	for i := 10; i < 50; i++ {
+		expected = append(expected, i)
+	}
+	verifyItems(t, s, expected)
+
+	// appending to slice should not change original
+	s2 := s.Append(-1)
+	verifyItems(t, s2, append(expected, -1))
+	verifyItems(t, s, expected)
+	if item, _ := v.Get(50); item != 50 {
+		t.Errorf("original changed (%v)", item)
+	}
+	s3, _ := s2.Slice(40, 41)
+	verifyItems(t, s3, []int{-1})
+
+	if _, ok := v.Slice(50, 10); ok {
+		t.Errorf("invalid slice should fail")
+	}
+	if _, ok := v.Slice(0, 101); ok {
+		t.Errorf("invalid slice should fail")
+	}
+}
+
+func TestFromSlice(t *testing.T) {
+	items := []Item{"a", "b", "c"}
+	v := FromSlice(items)
+	result := v.ToSlice()
+	if len(result) != 3 || result[0] != "a" || result[2] != "c" {
+		t.Errorf("unexpected result: %v", result)
+	}
+}
+
+func BenchmarkGet(b *testing.B) {
+	v := makeVector(MANY)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		v.Get(i % MANY)
+	}
+}
+
+func BenchmarkAppend(b *testing.B) {
+	v := New()
+	for i := 0; i < b.N; i++ {
+		v = v.Append(i)
+	}
+}
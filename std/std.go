@@ -13,7 +13,7 @@ type stdFuncInfo struct {
 }
 
 func setSTDFunctions(topFrame *funl.Frame, stdModuleName string, stdFuncs []stdFuncInfo, interpreter *funl.Interpreter) (err error) {
-	nsSid := funl.SymIDMap.Add(stdModuleName)
+	nsSid := interpreter.SymIDs.Add(stdModuleName)
 	interpreter.NsDir.Put(nsSid, topFrame)
 
 	for _, v := range stdFuncs {
@@ -43,7 +43,7 @@ type StdFuncInfo struct {
 
 // SetSTDFunctions exposed
 func SetSTDFunctions(topFrame *funl.Frame, stdModuleName string, stdFuncs []StdFuncInfo, interpreter *funl.Interpreter) (err error) {
-	nsSid := funl.SymIDMap.Add(stdModuleName)
+	nsSid := interpreter.SymIDs.Add(stdModuleName)
 	interpreter.NsDir.Put(nsSid, topFrame)
 
 	for _, v := range stdFuncs {
@@ -61,6 +61,42 @@ func SetSTDFunctions(topFrame *funl.Frame, stdModuleName string, stdFuncs []StdF
 	return
 }
 
+// GoFuncInfo returns StdFuncInfo for Go function which is wrapped
+// with funl.WrapGoFunc, panics if fn is not function
+func GoFuncInfo(name string, fn interface{}, isFunction bool) StdFuncInfo {
+	if _, err := funl.WrapGoFunc(name, fn, isFunction); err != nil {
+		panic(err)
+	}
+	return StdFuncInfo{
+		Name: name,
+		Getter: func(fullName string) StdFuncType {
+			extProc, _ := funl.WrapGoFunc(fullName, fn, isFunction)
+			return extProc.Impl
+		},
+		IsFunction: isFunction,
+	}
+}
+
+// goFuncInfo is GoFuncInfo for std-lib modules
+func goFuncInfo(name string, fn interface{}, isFunction bool) stdFuncInfo {
+	info := GoFuncInfo(name, fn, isFunction)
+	return stdFuncInfo{
+		Name: name,
+		Getter: func(fullName string) stdFuncType {
+			return stdFuncType(info.Getter(fullName))
+		},
+		IsFunction: isFunction,
+	}
+}
+
+// errorText returns error text, empty string if there's no error
+func errorText(err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
 // InitSTD is used for initializing standard library
 func InitSTD(interpreter *funl.Interpreter) (err error) {
 	inits := []func(*funl.Interpreter) error{
@@ -81,6 +117,14 @@ func InitSTD(interpreter *funl.Interpreter) (err error) {
 		initSTDRun,
 		initSTDLex,
 		initSTDCsv,
+		initSTDrec,
+		initSTDmm,
+		initSTDSeq,
+		initSTDpset,
+		initSTDTrans,
+		initSTDOrder,
+		initSTDOMap,
+		initSTDOpaque,
 	}
 	for _, initf := range inits {
 		err = initf(interpreter)
@@ -0,0 +1,147 @@
+package std
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/anssihalmeaho/funl/funl"
+)
+
+func TestEmbedWithSTD(t *testing.T) {
+	interpreter := funl.NewInterpreter()
+	if err := interpreter.Init(InitSTD); err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	src := `
+	ns words
+	import stdstr
+	import stdfu
+	upper-all = func(l) call(stdfu.apply l stdstr.uppercase) end
+	endns
+	`
+	if err := interpreter.LoadModule("words", src); err != nil {
+		t.Fatalf("loading failed: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := interpreter.Init(InitSTD); err != nil {
+			t.Fatalf("init failed: %v", err)
+		}
+		v, err := interpreter.Eval("call(words.upper-all list('a' 'b'))")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if s := v.String(); s != "list('A', 'B')" {
+			t.Errorf("unexpected result: %s", s)
+		}
+	}
+	if _, err := interpreter.Call("stdstr", "uppercase", funl.Value{Kind: funl.IntValue, Data: 1}); err == nil {
+		t.Errorf("should fail")
+	}
+}
+
+func TestConvertBytes(t *testing.T) {
+	val, err := funl.ToValue(map[string][]byte{"data": {1, 2, 255}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s := val.String(); s != "map('data' : opaque(bytearray(1 2 ff)))" {
+		t.Errorf("unexpected value: %s", s)
+	}
+	var back map[string][]byte
+	if err := funl.FromValue(val, &back); err != nil || len(back["data"]) != 3 || back["data"][2] != 255 {
+		t.Errorf("unexpected value: %v (%v)", back, err)
+	}
+}
+
+func TestGoFuncInfo(t *testing.T) {
+	interpreter := funl.NewInterpreter()
+	if err := interpreter.Init(InitSTD); err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	funcs := []StdFuncInfo{
+		GoFuncInfo("bytes-len", func(data []byte) int { return len(data) }, true),
+		GoFuncInfo("halves", func(n int) ([]byte, error) { return []byte{byte(n / 2)}, nil }, false),
+	}
+	if err := SetSTDFunctions(funl.NewTopFrameWithInterpreter(interpreter), "gofuncs", funcs, interpreter); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	v, err := interpreter.Eval("call(gofuncs.bytes-len call(stdbytes.str-to-bytes 'abc'))")
+	if err != nil || v.String() != "3" {
+		t.Errorf("unexpected result: %v (%v)", v, err)
+	}
+	v, err = interpreter.Eval("call(gofuncs.halves 10)")
+	if err != nil || v.String() != "list(true, '', opaque(bytearray(5)))" {
+		t.Errorf("unexpected result: %v (%v)", v, err)
+	}
+	if _, err := interpreter.Eval("call(gofuncs.bytes-len 1)"); err == nil || err.Error() != "gofuncs:bytes-len: argument 1: cannot convert int to []uint8" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestImplsDoesNotCreateGeneric(t *testing.T) {
+	interpreter := funl.NewInterpreter()
+	if err := interpreter.Init(InitSTD); err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	v, err := interpreter.Call("stdmm", "impls", funl.Value{Kind: funl.StringValue, Data: "no-such-generic"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s := v.String(); s != "list()" {
+		t.Errorf("unexpected result: %s", s)
+	}
+	if _, found := lookupGeneric(interpreter, "no-such-generic"); found {
+		t.Errorf("impls should not create generic")
+	}
+}
+
+func TestWrappedGoFuncsInSTD(t *testing.T) {
+	interpreter := funl.NewInterpreter()
+	if err := interpreter.Init(InitSTD); err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	str := func(s string) funl.Value { return funl.Value{Kind: funl.StringValue, Data: s} }
+	call := func(mod, name string, args ...funl.Value) string {
+		v, err := interpreter.Call(mod, name, args...)
+		if err != nil {
+			t.Fatalf("%s.%s: unexpected error: %v", mod, name, err)
+		}
+		return v.String()
+	}
+
+	if s := call("stdos", "setenv", str("FUNL_WRAP_TEST"), str("abc")); s != "''" {
+		t.Errorf("unexpected result: %s", s)
+	}
+	if s := call("stdos", "getenv", str("FUNL_WRAP_TEST")); s != "list(true, 'abc')" {
+		t.Errorf("unexpected result: %s", s)
+	}
+	if s := call("stdos", "unsetenv", str("FUNL_WRAP_TEST")); s != "''" {
+		t.Errorf("unexpected result: %s", s)
+	}
+	if _, found := os.LookupEnv("FUNL_WRAP_TEST"); found {
+		t.Errorf("variable should be removed")
+	}
+
+	dir := t.TempDir()
+	src, trg := filepath.Join(dir, "src.txt"), filepath.Join(dir, "trg.txt")
+	if err := os.WriteFile(src, []byte("x"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if s := call("stdfiles", "rename", str(src), str(trg)); s != "''" {
+		t.Errorf("unexpected result: %s", s)
+	}
+	if s := call("stdfiles", "remove", str(trg)); s != "''" {
+		t.Errorf("unexpected result: %s", s)
+	}
+	if s := call("stdfiles", "remove", str(trg)); !strings.Contains(s, "no such file") {
+		t.Errorf("error text assumed: %s", s)
+	}
+	if _, err := interpreter.Call("stdfiles", "remove", funl.Value{Kind: funl.IntValue, Data: 1}); err == nil || err.Error() != "stdfiles:remove: argument 1: cannot convert int to string" {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := interpreter.Eval("call(func() call(stdfiles.remove 'x') end)"); err == nil {
+		t.Errorf("wrapped proc should not be allowed in function")
+	}
+}
@@ -62,7 +62,7 @@ func parseItem(frame *funl.Frame, item *funl.Item) funl.Value {
 		v := item.Data.(funl.Value)
 		switch v.Kind {
 
-		case funl.IntValue, funl.StringValue, funl.BoolValue, funl.FloatValue:
+		case funl.IntValue, funl.BigIntValue, funl.StringValue, funl.BoolValue, funl.FloatValue:
 			mapv = putToMap(frame, mapv, "val", v)
 
 		case funl.FunctionValue:
@@ -83,7 +83,7 @@ func parseItem(frame *funl.Frame, item *funl.Item) funl.Value {
 		sp := item.Data.(funl.SymbolPath)
 		var symbolPathNames []funl.Value
 		for _, sid := range sp {
-			symnamev := funl.Value{Kind: funl.StringValue, Data: funl.SymIDMap.AsString(sid)}
+			symnamev := funl.Value{Kind: funl.StringValue, Data: frame.SymIDs().AsString(sid)}
 			symbolPathNames = append(symbolPathNames, symnamev)
 		}
 		mapv = putToMap(frame, mapv, "sym", funl.MakeListOfValues(frame, symbolPathNames))
@@ -118,7 +118,7 @@ func parseNS(frame *funl.Frame, ns funl.NSpace) funl.Value {
 	var letvalues []funl.Value
 	for _, sid := range ns.Syms.Keys() {
 		symvalue := parseItem(frame, symbolMap[sid])
-		symname := funl.SymIDMap.AsString(sid)
+		symname := frame.SymIDs().AsString(sid)
 		pairSlice := []funl.Value{funl.Value{Kind: funl.StringValue, Data: symname}, symvalue}
 		pair := funl.MakeListOfValues(frame, pairSlice)
 		letvalues = append(letvalues, pair)
@@ -127,7 +127,7 @@ func parseNS(frame *funl.Frame, ns funl.NSpace) funl.Value {
 
 	importMap := funl.HandleMapOP(frame, []*funl.Item{})
 	for k, v := range ns.OtherNS {
-		symname := funl.SymIDMap.AsString(k)
+		symname := frame.SymIDs().AsString(k)
 		importMap = putToMap(frame, importMap, symname, funl.Value{Kind: funl.StringValue, Data: v.Path()})
 	}
 	nsMap = putToMap(frame, nsMap, "imports", importMap)
@@ -140,7 +140,7 @@ func parseFunctionProto(frame *funl.Frame, funcProto *funl.Function) funl.Value
 
 	var argNameVals []funl.Value
 	for _, argSymID := range funcProto.ArgNames {
-		argNameV := funl.Value{Kind: funl.StringValue, Data: funl.SymIDMap.AsString(argSymID)}
+		argNameV := funl.Value{Kind: funl.StringValue, Data: frame.SymIDs().AsString(argSymID)}
 		argNameVals = append(argNameVals, argNameV)
 	}
 	argNameList := funl.MakeListOfValues(frame, argNameVals)
@@ -228,7 +228,7 @@ func makeNS(frame *funl.Frame, nsV funl.Value) *funl.NSpace {
 		funl.RunTimeError2(frame, "syms is invalid")
 	}
 	symsIter := funl.NewListIterator(symsV)
-	symt := funl.NewSymt()
+	symt := funl.NewSymtWithConverter(frame.SymIDs())
 	for {
 		v := symsIter.Next()
 		if v == nil {
@@ -242,7 +242,7 @@ func makeNS(frame *funl.Frame, nsV funl.Value) *funl.NSpace {
 			symName = funl.GetWastedName()
 		}
 
-		sid := funl.SymIDMap.Add(symName)
+		sid := frame.SymIDs().Add(symName)
 		letItem := makeItem(frame, symValue)
 		symAddOK := symt.AddBySID(sid, letItem)
 		if !symAddOK {
@@ -275,7 +275,7 @@ func makeNS(frame *funl.Frame, nsV funl.Value) *funl.NSpace {
 		if valv.Kind != funl.StringValue {
 			funl.RunTimeError2(frame, "value not a string: %v", valv)
 		}
-		sid := funl.SymIDMap.Add(keyv.Data.(string))
+		sid := frame.SymIDs().Add(keyv.Data.(string))
 		var importInfo funl.ImportInfo
 		importInfo.SetPath(valv.Data.(string))
 		otherNSMap[sid] = importInfo
@@ -312,9 +312,9 @@ func makeFuncValue(frame *funl.Frame, funcMap funl.Value) *funl.Item {
 		if v.Kind != funl.StringValue {
 			funl.RunTimeError2(frame, "arg not string")
 		}
-		sid, found := funl.SymIDMap.Get(v.Data.(string))
+		sid, found := frame.SymIDs().Get(v.Data.(string))
 		if !found {
-			sid = funl.SymIDMap.Add(v.Data.(string))
+			sid = frame.SymIDs().Add(v.Data.(string))
 		}
 		argNames = append(argNames, sid)
 	}
@@ -454,9 +454,9 @@ func makeItem(frame *funl.Frame, astmap funl.Value) *funl.Item {
 			if !convOK {
 				funl.RunTimeError2(frame, "Invalid symbol string (%#v)", v)
 			}
-			sid, found := funl.SymIDMap.Get(symStr)
+			sid, found := frame.SymIDs().Get(symStr)
 			if !found {
-				sid = funl.SymIDMap.Add(symStr)
+				sid = frame.SymIDs().Add(symStr)
 			}
 			symPath = append(symPath, sid)
 		}
@@ -649,6 +649,7 @@ func getParseExprToAst(name string) stdFuncType {
 
 		var srcFileName string
 		parser := funl.NewParser(funl.NewDefaultOperators(), &srcFileName)
+		parser.SetSymIDConverter(frame.SymIDs())
 		parser.SetErrorHandler(&parserErrHandler{})
 		exprItem, err := parser.ParseOneExpression(string(content))
 		if err != nil {
@@ -720,6 +721,7 @@ func getParseNSToAst(name string) stdFuncType {
 
 		var srcFileName string
 		parser := funl.NewParser(funl.NewDefaultOperators(), &srcFileName)
+		parser.SetSymIDConverter(frame.SymIDs())
 		parser.SetErrorHandler(&parserErrHandler{})
 		var nsName string
 		var nspace *funl.NSpace
@@ -2,6 +2,7 @@ package std
 
 import (
 	"bytes"
+	"encoding/base64"
 	"fmt"
 
 	"github.com/anssihalmeaho/funl/funl"
@@ -58,6 +59,12 @@ type OpaqueByteArray struct {
 	data []byte
 }
 
+func init() {
+	funl.NewBytesValue = func(data []byte) funl.Value {
+		return funl.Value{Kind: funl.OpaqueValue, Data: NewOpaqueByteArray(data)}
+	}
+}
+
 // Creates new OpaqueByteArray
 func NewOpaqueByteArray(data []byte) *OpaqueByteArray {
 	return &OpaqueByteArray{data: data}
@@ -91,6 +98,35 @@ func (ob *OpaqueByteArray) Equals(with funl.OpaqueAPI) bool {
 	return bytes.Equal(ob.data, other.data)
 }
 
+// Hash makes bytearray usable as map key
+func (ob *OpaqueByteArray) Hash() int {
+	hash, _ := funl.HashOfValue(funl.Value{Kind: funl.StringValue, Data: string(ob.data)})
+	return hash
+}
+
+// Compare orders bytearrays lexicographically
+func (ob *OpaqueByteArray) Compare(with funl.OpaqueAPI) (int, bool) {
+	other, ok := with.(*OpaqueByteArray)
+	if !ok {
+		return 0, false
+	}
+	return bytes.Compare(ob.data, other.data), true
+}
+
+// Get returns byte (as int) in given index
+func (ob *OpaqueByteArray) Get(frame *funl.Frame, key funl.Value) (funl.Value, bool) {
+	index, ok := key.Data.(int)
+	if key.Kind != funl.IntValue || !ok || index < 0 || index >= len(ob.data) {
+		return funl.Value{}, false
+	}
+	return funl.Value{Kind: funl.IntValue, Data: int(ob.data[index])}, true
+}
+
+// Encode represents bytearray as base64 encoded string
+func (ob *OpaqueByteArray) Encode(frame *funl.Frame) funl.Value {
+	return funl.Value{Kind: funl.StringValue, Data: base64.StdEncoding.EncodeToString(ob.data)}
+}
+
 func getStdBytesAsList(name string) stdFuncType {
 	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
 		if l := len(arguments); l != 1 {
@@ -3,6 +3,7 @@ package std
 import (
 	"bytes"
 	"encoding/csv"
+	"io"
 
 	"github.com/anssihalmeaho/funl/funl"
 )
@@ -16,6 +17,11 @@ func initSTDCsv(interpreter *funl.Interpreter) (err error) {
 			Getter:     getCSVReadAll,
 			IsFunction: true,
 		},
+		{
+			Name:       "row-seq",
+			Getter:     getCSVRowSeq,
+			IsFunction: true,
+		},
 		{
 			Name:       "write-all",
 			Getter:     getCSVWriteAll,
@@ -90,6 +96,46 @@ func getCSVWriteAll(name string) stdFuncType {
 	}
 }
 
+// call(stdcsv.row-seq <bytearray or file>) -> lazy sequence of rows (see stdseq)
+// (sequence reading from file can be read only in procedure)
+func getCSVRowSeq(name string) stdFuncType {
+	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
+		if l := len(arguments); l != 1 {
+			funl.RunTimeError2(frame, "%s: wrong amount of arguments (%d), needs one", name, l)
+		}
+		if arguments[0].Kind != funl.OpaqueValue {
+			funl.RunTimeError2(frame, "%s: requires opaque value", name)
+		}
+		var reader *csv.Reader
+		var impure bool
+		switch source := arguments[0].Data.(type) {
+		case *OpaqueByteArray:
+			reader = csv.NewReader(bytes.NewReader(source.data))
+		case *OpaqueFile:
+			reader, impure = csv.NewReader(source.handle), true
+		default:
+			funl.RunTimeError2(frame, "%s: argument is not bytearray or file value", name)
+		}
+
+		gen := func(frame *funl.Frame) (funl.Value, bool) {
+			record, err := reader.Read()
+			if err == io.EOF {
+				return funl.Value{}, false
+			}
+			if err != nil {
+				funl.RunTimeError2(frame, "%s: %v", name, err)
+			}
+			oneList := []funl.Value{}
+			for _, oneVal := range record {
+				oneList = append(oneList, funl.Value{Kind: funl.StringValue, Data: oneVal})
+			}
+			return funl.MakeListOfValues(frame, oneList), true
+		}
+		retVal = funl.Value{Kind: funl.OpaqueValue, Data: NewGeneratorSeq(gen, impure)}
+		return
+	}
+}
+
 func getCSVReadAll(name string) stdFuncType {
 	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
 		if l := len(arguments); l != 1 {
@@ -60,17 +60,15 @@ func initSTDFiles(interpreter *funl.Interpreter) (err error) {
 			Getter: getStdFilesReadLines,
 		},
 		{
-			Name:   "seek",
-			Getter: getStdFilesSeek,
-		},
-		{
-			Name:   "remove",
-			Getter: getStdFilesRemove,
+			Name:   "line-seq",
+			Getter: getStdFilesLineSeq,
 		},
 		{
-			Name:   "rename",
-			Getter: getStdFilesRename,
+			Name:   "seek",
+			Getter: getStdFilesSeek,
 		},
+		goFuncInfo("remove", func(fileName string) string { return errorText(os.Remove(fileName)) }, false),
+		goFuncInfo("rename", func(src, trg string) string { return errorText(os.Rename(src, trg)) }, false),
 		{
 			Name:   "close",
 			Getter: getStdFilesClose,
@@ -132,11 +130,25 @@ func (file *OpaqueFile) Str() string {
 }
 
 func (file *OpaqueFile) Equals(with funl.OpaqueAPI) bool {
-	_, ok := with.(*OpaqueFile)
-	if !ok {
-		return false
+	return with == funl.OpaqueAPI(file)
+}
+
+func (file *OpaqueFile) Hash() int {
+	return funl.HashOfPointer(file)
+}
+
+// Get gives 'name' and 'path' fields of file
+func (file *OpaqueFile) Get(frame *funl.Frame, key funl.Value) (funl.Value, bool) {
+	if key.Kind != funl.StringValue {
+		return funl.Value{}, false
+	}
+	switch key.Data.(string) {
+	case "name":
+		return funl.Value{Kind: funl.StringValue, Data: file.name}, true
+	case "path":
+		return funl.Value{Kind: funl.StringValue, Data: file.path}, true
 	}
-	return false // ==> ?????????????????????
+	return funl.Value{}, false
 }
 
 type OpaqueFileInfo struct {
@@ -152,11 +164,56 @@ func (fi *OpaqueFileInfo) Str() string {
 }
 
 func (fi *OpaqueFileInfo) Equals(with funl.OpaqueAPI) bool {
-	_, ok := with.(*OpaqueFileInfo)
-	if !ok {
-		return false
+	return with == funl.OpaqueAPI(fi)
+}
+
+func (fi *OpaqueFileInfo) Hash() int {
+	return funl.HashOfPointer(fi)
+}
+
+// Get gives same fields as finfo-map
+func (fi *OpaqueFileInfo) Get(frame *funl.Frame, key funl.Value) (funl.Value, bool) {
+	if key.Kind != funl.StringValue {
+		return funl.Value{}, false
+	}
+	for _, field := range fi.fields() {
+		if field.name == key.Data.(string) {
+			return field.val, true
+		}
+	}
+	return funl.Value{}, false
+}
+
+// Encode represents fileinfo as map (like finfo-map)
+func (fi *OpaqueFileInfo) Encode(frame *funl.Frame) funl.Value {
+	return fi.asMap(frame)
+}
+
+type fileInfoField struct {
+	name string
+	val  funl.Value
+}
+
+func (fi *OpaqueFileInfo) fields() []fileInfoField {
+	fileInfo := fi.info
+	return []fileInfoField{
+		{name: "name", val: funl.Value{Kind: funl.StringValue, Data: fileInfo.Name()}},
+		{name: "size", val: funl.Value{Kind: funl.IntValue, Data: int(fileInfo.Size())}},
+		{name: "mode", val: funl.Value{Kind: funl.StringValue, Data: fmt.Sprintf("%v", fileInfo.Mode())}},
+		{name: "modtime", val: funl.Value{Kind: funl.StringValue, Data: fmt.Sprintf("%v", fileInfo.ModTime())}},
+		{name: "is-dir", val: funl.Value{Kind: funl.BoolValue, Data: fileInfo.IsDir()}},
+	}
+}
+
+func (fi *OpaqueFileInfo) asMap(frame *funl.Frame) funl.Value {
+	var moperands []*funl.Item
+	for _, field := range fi.fields() {
+		moperands = append(moperands,
+			&funl.Item{Type: funl.ValueItem, Data: funl.Value{Kind: funl.StringValue, Data: field.name}},
+			&funl.Item{Type: funl.ValueItem, Data: field.val},
+		)
 	}
-	return false // ==> ?????????????????????
+	return funl.HandleMapOP(frame, moperands)
 }
 
 func getStdFilesMkDir(name string) stdFuncType {
@@ -220,24 +277,7 @@ func getStdFilesFInfoMap(name string) stdFuncType {
 		if !ok {
 			funl.RunTimeError2(frame, "%s: argument is not fileinfo value", name)
 		}
-		fileInfo := fInfo.info
-		moperands := []*funl.Item{
-			&funl.Item{Type: funl.ValueItem, Data: funl.Value{Kind: funl.StringValue, Data: "name"}},
-			&funl.Item{Type: funl.ValueItem, Data: funl.Value{Kind: funl.StringValue, Data: fileInfo.Name()}},
-
-			&funl.Item{Type: funl.ValueItem, Data: funl.Value{Kind: funl.StringValue, Data: "size"}},
-			&funl.Item{Type: funl.ValueItem, Data: funl.Value{Kind: funl.IntValue, Data: int(fileInfo.Size())}},
-
-			&funl.Item{Type: funl.ValueItem, Data: funl.Value{Kind: funl.StringValue, Data: "mode"}},
-			&funl.Item{Type: funl.ValueItem, Data: funl.Value{Kind: funl.StringValue, Data: fmt.Sprintf("%v", fileInfo.Mode())}},
-
-			&funl.Item{Type: funl.ValueItem, Data: funl.Value{Kind: funl.StringValue, Data: "modtime"}},
-			&funl.Item{Type: funl.ValueItem, Data: funl.Value{Kind: funl.StringValue, Data: fmt.Sprintf("%v", fileInfo.ModTime())}},
-
-			&funl.Item{Type: funl.ValueItem, Data: funl.Value{Kind: funl.StringValue, Data: "is-dir"}},
-			&funl.Item{Type: funl.ValueItem, Data: funl.Value{Kind: funl.BoolValue, Data: fileInfo.IsDir()}},
-		}
-		retVal = funl.HandleMapOP(frame, moperands)
+		retVal = fInfo.asMap(frame)
 		return
 	}
 }
@@ -397,6 +437,35 @@ func getStdFilesReadLines(name string) stdFuncType {
 	}
 }
 
+// call(stdfiles.line-seq <file>) -> lazy sequence of lines (see stdseq)
+func getStdFilesLineSeq(name string) stdFuncType {
+	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
+		if l := len(arguments); l != 1 {
+			funl.RunTimeError2(frame, "%s: wrong amount of arguments (%d)", name, l)
+		}
+		if arguments[0].Kind != funl.OpaqueValue {
+			funl.RunTimeError2(frame, "%s: requires opaque value", name)
+		}
+		file, ok := arguments[0].Data.(*OpaqueFile)
+		if !ok {
+			funl.RunTimeError2(frame, "%s: argument is not file value", name)
+		}
+
+		scanner := bufio.NewScanner(file.handle)
+		gen := func(frame *funl.Frame) (funl.Value, bool) {
+			if scanner.Scan() {
+				return funl.Value{Kind: funl.StringValue, Data: scanner.Text()}, true
+			}
+			if err := scanner.Err(); err != nil {
+				funl.RunTimeError2(frame, "%s: %v", name, err)
+			}
+			return funl.Value{}, false
+		}
+		retVal = funl.Value{Kind: funl.OpaqueValue, Data: NewGeneratorSeq(gen, true)}
+		return
+	}
+}
+
 func getStdFilesReadAll(name string) stdFuncType {
 	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
 		if l := len(arguments); l != 1 {
@@ -663,56 +732,3 @@ func getStdFilesCreate(name string) stdFuncType {
 		return
 	}
 }
-
-func getStdFilesRename(name string) stdFuncType {
-	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
-		if l := len(arguments); l != 2 {
-			funl.RunTimeError2(frame, "%s: wrong amount of arguments (%d)", name, l)
-		}
-		if arguments[0].Kind != funl.StringValue {
-			funl.RunTimeError2(frame, "%s: requires string value as source file name", name)
-		}
-		if arguments[1].Kind != funl.StringValue {
-			funl.RunTimeError2(frame, "%s: requires string value as target file name", name)
-		}
-		srcFileName, ok := arguments[0].Data.(string)
-		if !ok {
-			funl.RunTimeError2(frame, "%s: argument is not string value", name)
-		}
-		trgFileName, ok := arguments[1].Data.(string)
-		if !ok {
-			funl.RunTimeError2(frame, "%s: argument is not string value", name)
-		}
-
-		err := os.Rename(srcFileName, trgFileName)
-		var text string
-		if err != nil {
-			text = err.Error()
-		}
-		retVal = funl.Value{Kind: funl.StringValue, Data: text}
-		return
-	}
-}
-
-func getStdFilesRemove(name string) stdFuncType {
-	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
-		if l := len(arguments); l != 1 {
-			funl.RunTimeError2(frame, "%s: wrong amount of arguments (%d)", name, l)
-		}
-		if arguments[0].Kind != funl.StringValue {
-			funl.RunTimeError2(frame, "%s: requires string value as file name", name)
-		}
-		fileName, ok := arguments[0].Data.(string)
-		if !ok {
-			funl.RunTimeError2(frame, "%s: argument is not string value", name)
-		}
-
-		err := os.Remove(fileName)
-		var text string
-		if err != nil {
-			text = err.Error()
-		}
-		retVal = funl.Value{Kind: funl.StringValue, Data: text}
-		return
-	}
-}
@@ -76,11 +76,11 @@ func (mux *OpaqueHttpMux) Str() string {
 }
 
 func (mux *OpaqueHttpMux) Equals(with funl.OpaqueAPI) bool {
-	_, ok := with.(*OpaqueHttpMux)
-	if !ok {
-		return false
-	}
-	return true
+	return with == funl.OpaqueAPI(mux)
+}
+
+func (mux *OpaqueHttpMux) Hash() int {
+	return funl.HashOfPointer(mux)
 }
 
 func applyForEachKeyVal(frame *funl.Frame, name string, mapVal funl.Value, handler func(keyStr, valStr string)) {
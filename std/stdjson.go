@@ -4,9 +4,12 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"math"
+	"math/big"
 	"reflect"
 	"strconv"
+	"strings"
 
 	"github.com/anssihalmeaho/funl/funl"
 )
@@ -52,14 +55,51 @@ func (opa *OpaqueJSONnull) Equals(with funl.OpaqueAPI) bool {
 	return ok
 }
 
-// encode(<VALUE>) -> list(bool, string, opaque:bytearray)
+// jsonOptions reads bool options from options map (other keys are ignored)
+func jsonOptions(frame *funl.Frame, name string, arguments []funl.Value, optNames ...string) map[string]bool {
+	options := make(map[string]bool)
+	if len(arguments) < 2 {
+		return options
+	}
+	if arguments[1].Kind != funl.MapValue {
+		funl.RunTimeError2(frame, "%s: requires map value as options", name)
+	}
+	keyvals := funl.HandleKeyvalsOP(frame, []*funl.Item{&funl.Item{Type: funl.ValueItem, Data: arguments[1]}})
+	kvListIter := funl.NewListIterator(keyvals)
+	for {
+		nextKV := kvListIter.Next()
+		if nextKV == nil {
+			break
+		}
+		kvIter := funl.NewListIterator(*nextKV)
+		keyv := *(kvIter.Next())
+		valv := *(kvIter.Next())
+		if keyv.Kind != funl.StringValue {
+			continue
+		}
+		for _, optName := range optNames {
+			if keyv.Data.(string) == optName {
+				if valv.Kind != funl.BoolValue {
+					funl.RunTimeError2(frame, "%s: %s option requires bool value", name, optName)
+				}
+				options[optName] = valv.Data.(bool)
+			}
+		}
+	}
+	return options
+}
+
+// encode(<VALUE> [options:map]) -> list(bool, string, opaque:bytearray)
+// options: 'sorted': true -> object keys are encoded in sorted order
+// decimals are encoded as exact numeric strings ("19.99")
 func getStdJSONencode(name string) stdFuncType {
 	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
-		if l := len(arguments); l != 1 {
-			funl.RunTimeError2(frame, "%s: wrong amount of arguments (%d), need one", name, l)
+		if l := len(arguments); l != 1 && l != 2 {
+			funl.RunTimeError2(frame, "%s: wrong amount of arguments (%d), need one or two", name, l)
 		}
+		options := jsonOptions(frame, name, arguments, "sorted")
 
-		ok, errText, val := encodeJSON(name, frame, arguments[0])
+		ok, errText, val := encodeJSON(name, frame, arguments[0], options["sorted"])
 		if !ok {
 			val = funl.Value{Kind: funl.StringValue, Data: ""}
 		}
@@ -79,12 +119,15 @@ func getStdJSONencode(name string) stdFuncType {
 	}
 }
 
-// decode(opaque:bytearray) -> list(bool, string, <VALUE>)
+// decode(opaque:bytearray [options:map]) -> list(bool, string, <VALUE>)
+// options: 'decimal': true -> non-integer numbers are decoded as decimals,
+// 'ordered': true -> objects are decoded as omaps (stdomap) which keep key order
 func getStdJSONdecode(name string) stdFuncType {
 	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
-		if l := len(arguments); l != 1 {
-			funl.RunTimeError2(frame, "%s: wrong amount of arguments (%d), need one", name, l)
+		if l := len(arguments); l != 1 && l != 2 {
+			funl.RunTimeError2(frame, "%s: wrong amount of arguments (%d), need one or two", name, l)
 		}
+		options := jsonOptions(frame, name, arguments, "decimal", "ordered")
 		if arguments[0].Kind != funl.OpaqueValue {
 			funl.RunTimeError2(frame, "%s: requires opaque value", name)
 		}
@@ -93,7 +136,7 @@ func getStdJSONdecode(name string) stdFuncType {
 			funl.RunTimeError2(frame, "%s: argument is not bytearray value", name)
 		}
 
-		ok, errText, val := decodeJSON(name, frame, byteArray.data)
+		ok, errText, val := decodeJSON(name, frame, byteArray.data, options["decimal"], options["ordered"])
 		if !ok {
 			val = funl.Value{Kind: funl.StringValue, Data: ""}
 		}
@@ -113,7 +156,7 @@ func getStdJSONdecode(name string) stdFuncType {
 	}
 }
 
-func encodeJSON(name string, frame *funl.Frame, inValue funl.Value) (ok bool, errText string, val funl.Value) {
+func encodeJSON(name string, frame *funl.Frame, inValue funl.Value, sorted bool) (ok bool, errText string, val funl.Value) {
 	defer func() {
 		if r := recover(); r != nil {
 			err, _ := r.(error)
@@ -121,13 +164,13 @@ func encodeJSON(name string, frame *funl.Frame, inValue funl.Value) (ok bool, er
 		}
 	}()
 
-	resultAsBytes := traverseValuesEncode(frame, inValue, make([]byte, 0))
+	resultAsBytes := traverseValuesEncode(frame, inValue, make([]byte, 0), sorted)
 	val = funl.Value{Kind: funl.OpaqueValue, Data: &OpaqueByteArray{data: resultAsBytes}}
 	ok = true
 	return
 }
 
-func decodeJSON(name string, frame *funl.Frame, indata []byte) (ok bool, errText string, val funl.Value) {
+func decodeJSON(name string, frame *funl.Frame, indata []byte, decimals, ordered bool) (ok bool, errText string, val funl.Value) {
 	defer func() {
 		if r := recover(); r != nil {
 			err, _ := r.(error)
@@ -138,22 +181,39 @@ func decodeJSON(name string, frame *funl.Frame, indata []byte) (ok bool, errText
 	var res interface{}
 	d := json.NewDecoder(bytes.NewBuffer(indata))
 	d.UseNumber()
+	if ordered {
+		val = decodeOrdered(frame, d, decimals)
+		if _, err := d.Token(); err != io.EOF {
+			ok, errText = false, fmt.Sprintf("%s: error in unmarshal: unexpected data after value", name)
+			return
+		}
+		ok = true
+		return
+	}
 	if err := d.Decode(&res); err != nil {
 		ok, errText = false, fmt.Sprintf("%s: error in unmarshal: %v", name, err)
 		return
 	}
-	val = traverseValues(frame, res)
+	val = traverseValues(frame, res, decimals)
 	ok = true
 	return
 }
 
-func traverseValuesEncode(frame *funl.Frame, inValue funl.Value, prevsl []byte) (nextsl []byte) {
+func traverseValuesEncode(frame *funl.Frame, inValue funl.Value, prevsl []byte, sorted bool) (nextsl []byte) {
 	if inValue.Kind == funl.OpaqueValue {
 		_, isNullV := inValue.Data.(*OpaqueJSONnull)
 		if isNullV {
 			nextsl = append(prevsl, []byte("null")...)
 			return
 		}
+		if om, isOMap := inValue.Data.(*OpaqueOMap); isOMap {
+			nextsl = encodeObject(frame, om.keyVals(frame), prevsl, sorted)
+			return
+		}
+		if enc, isEncoder := inValue.Data.(funl.OpaqueEncoder); isEncoder {
+			nextsl = traverseValuesEncode(frame, enc.Encode(frame), prevsl, sorted)
+			return
+		}
 	}
 	switch inValue.Kind {
 
@@ -183,6 +243,24 @@ func traverseValuesEncode(frame *funl.Frame, inValue funl.Value, prevsl []byte)
 		nextsl = append(prevsl, intAsBytes...)
 		return
 
+	case funl.BigIntValue:
+		nextsl = append(prevsl, []byte(inValue.Data.(*big.Int).String())...)
+		return
+
+	case funl.DecimalValue:
+		decText := funl.DecimalString(inValue.Data.(*big.Rat))
+		if strings.Contains(decText, "/") {
+			panic(fmt.Errorf("decimal has no exact representation: %s", decText))
+		}
+		// decimal is written as string so that exact value is not lost
+		// by decoders which read numbers as floats
+		decAsBytes, err := json.Marshal(decText)
+		if err != nil {
+			panic(err)
+		}
+		nextsl = append(prevsl, decAsBytes...)
+		return
+
 	case funl.FloatValue:
 		floatVal := inValue.Data.(float64)
 		var floatAsBytes []byte
@@ -209,45 +287,119 @@ func traverseValuesEncode(frame *funl.Frame, inValue funl.Value, prevsl []byte)
 				listAsBytes = append(listAsBytes, []byte(", ")...)
 			}
 			isFirstRound = false
-			listAsBytes = traverseValuesEncode(frame, *nextItem, listAsBytes)
+			listAsBytes = traverseValuesEncode(frame, *nextItem, listAsBytes, sorted)
 		}
 		listAsBytes = append(listAsBytes, []byte("]")...)
 		nextsl = append(prevsl, listAsBytes...)
 		return
 
+	case funl.RecordValue:
+		nextsl = traverseValuesEncode(frame, inValue.Data.(*funl.Record).Fields, prevsl, sorted)
+		return
+
+	case funl.VectorValue:
+		nextsl = traverseValuesEncode(frame, funl.MakeListOfValues(frame, funl.VectorValues(inValue)), prevsl, sorted)
+		return
+
+	case funl.SetValue:
+		nextsl = traverseValuesEncode(frame, funl.MakeListOfValues(frame, funl.SetValues(frame, inValue)), prevsl, sorted)
+		return
+
 	case funl.MapValue:
-		var mapAsBytes []byte
-		mapAsBytes = append(mapAsBytes, []byte("{")...)
-		keyvals := funl.HandleKeyvalsOP(frame, []*funl.Item{&funl.Item{Type: funl.ValueItem, Data: inValue}})
-		kvListIter := funl.NewListIterator(keyvals)
-		isFirstRound := true
-		for {
-			nextKV := kvListIter.Next()
-			if nextKV == nil {
-				break
-			}
-			if !isFirstRound {
-				mapAsBytes = append(mapAsBytes, []byte(", ")...)
-			}
-			isFirstRound = false
-			kvIter := funl.NewListIterator(*nextKV)
-			keyv := *(kvIter.Next())
-			valv := *(kvIter.Next())
-			if keyv.Kind != funl.StringValue {
-				panic(fmt.Errorf("JSON object key not a string: %v", keyv))
+		var keyvals []funl.KeyVal
+		if sorted {
+			keyvals = funl.SortedKeyVals(inValue)
+		} else {
+			kvListIter := funl.NewListIterator(funl.HandleKeyvalsOP(frame, []*funl.Item{&funl.Item{Type: funl.ValueItem, Data: inValue}}))
+			for nextKV := kvListIter.Next(); nextKV != nil; nextKV = kvListIter.Next() {
+				kvIter := funl.NewListIterator(*nextKV)
+				keyvals = append(keyvals, funl.KeyVal{Key: *(kvIter.Next()), Val: *(kvIter.Next())})
 			}
-			mapAsBytes = traverseValuesEncode(frame, keyv, mapAsBytes)
-			mapAsBytes = append(mapAsBytes, []byte(": ")...)
-			mapAsBytes = traverseValuesEncode(frame, valv, mapAsBytes)
 		}
-		mapAsBytes = append(mapAsBytes, []byte("}")...)
-		nextsl = append(prevsl, mapAsBytes...)
+		nextsl = encodeObject(frame, keyvals, prevsl, sorted)
 		return
 	}
 	panic(fmt.Errorf("Unexpected type: %v", inValue))
 }
 
-func traverseValues(frame *funl.Frame, intf interface{}) funl.Value {
+func encodeObject(frame *funl.Frame, keyvals []funl.KeyVal, prevsl []byte, sorted bool) []byte {
+	var mapAsBytes []byte
+	mapAsBytes = append(mapAsBytes, []byte("{")...)
+	for i, kv := range keyvals {
+		if i > 0 {
+			mapAsBytes = append(mapAsBytes, []byte(", ")...)
+		}
+		if kv.Key.Kind != funl.StringValue {
+			panic(fmt.Errorf("JSON object key not a string: %v", kv.Key))
+		}
+		mapAsBytes = traverseValuesEncode(frame, kv.Key, mapAsBytes, sorted)
+		mapAsBytes = append(mapAsBytes, []byte(": ")...)
+		mapAsBytes = traverseValuesEncode(frame, kv.Val, mapAsBytes, sorted)
+	}
+	mapAsBytes = append(mapAsBytes, []byte("}")...)
+	return append(prevsl, mapAsBytes...)
+}
+
+func numberValue(num json.Number, decimals bool) (funl.Value, bool) {
+	if i64, err := num.Int64(); err == nil {
+		return funl.Value{Kind: funl.IntValue, Data: int(i64)}, true
+	}
+	if bigv, ok := new(big.Int).SetString(num.String(), 10); ok {
+		return funl.MakeIntValue(bigv), true
+	}
+	if decimals {
+		if ratv, ok := funl.ParseDecimal(num.String()); ok {
+			return funl.MakeDecimalValue(ratv), true
+		}
+	}
+	if f64, err := num.Float64(); err == nil {
+		return funl.Value{Kind: funl.FloatValue, Data: f64}, true
+	}
+	return funl.Value{}, false
+}
+
+// decodeOrdered decodes value token by token so that objects
+// can be made to omaps in same key order as in JSON
+func decodeOrdered(frame *funl.Frame, d *json.Decoder, decimals bool) funl.Value {
+	token, err := d.Token()
+	if err != nil {
+		panic(fmt.Errorf("error in unmarshal: %v", err))
+	}
+	switch tv := token.(type) {
+	case nil:
+		return funl.Value{Kind: funl.OpaqueValue, Data: &OpaqueJSONnull{}}
+	case bool:
+		return funl.Value{Kind: funl.BoolValue, Data: tv}
+	case string:
+		return funl.Value{Kind: funl.StringValue, Data: tv}
+	case json.Number:
+		if val, ok := numberValue(tv, decimals); ok {
+			return val
+		}
+		return funl.Value{Kind: funl.StringValue, Data: tv.String()}
+	case json.Delim:
+		switch tv {
+		case '[':
+			builder := funl.NewListBuilder()
+			for d.More() {
+				builder.Append(frame, decodeOrdered(frame, d, decimals))
+			}
+			d.Token() // ']'
+			return builder.Freeze(frame)
+		case '{':
+			om := newOMap(frame)
+			for d.More() {
+				keyv := decodeOrdered(frame, d, decimals)
+				om = om.set(frame, keyv, decodeOrdered(frame, d, decimals))
+			}
+			d.Token() // '}'
+			return omapValue(om)
+		}
+	}
+	panic(fmt.Errorf("Unexpected token: %v", token))
+}
+
+func traverseValues(frame *funl.Frame, intf interface{}, decimals bool) funl.Value {
 	val := reflect.ValueOf(intf)
 	if intf == nil {
 		return funl.Value{Kind: funl.OpaqueValue, Data: &OpaqueJSONnull{}}
@@ -257,11 +409,8 @@ func traverseValues(frame *funl.Frame, intf interface{}) funl.Value {
 		return funl.Value{Kind: funl.BoolValue, Data: val.Bool()}
 	case reflect.String:
 		if num, convOK := val.Interface().(json.Number); convOK {
-			if i64, err := num.Int64(); err == nil {
-				return funl.Value{Kind: funl.IntValue, Data: int(i64)}
-			}
-			if f64, err := num.Float64(); err == nil {
-				return funl.Value{Kind: funl.FloatValue, Data: f64}
+			if numVal, ok := numberValue(num, decimals); ok {
+				return numVal
 			}
 		}
 		return funl.Value{Kind: funl.StringValue, Data: val.String()}
@@ -270,30 +419,20 @@ func traverseValues(frame *funl.Frame, intf interface{}) funl.Value {
 	case reflect.Float64:
 		return funl.Value{Kind: funl.FloatValue, Data: val.Float()}
 	case reflect.Slice:
-		var values []funl.Value
+		builder := funl.NewListBuilder()
 		for i := 0; i < val.Len(); i++ {
 			item := val.Index(i)
-			listItem := traverseValues(frame, item.Interface())
-			values = append(values, listItem)
+			builder.Append(frame, traverseValues(frame, item.Interface(), decimals))
 		}
-		return funl.MakeListOfValues(frame, values)
+		return builder.Freeze(frame)
 	case reflect.Map:
-		mapval := funl.HandleMapOP(frame, []*funl.Item{})
+		builder := funl.NewMapBuilder(frame)
 		for _, k := range val.MapKeys() {
 			v := val.MapIndex(k)
 			keyv := funl.Value{Kind: funl.StringValue, Data: k.String()}
-			valv := traverseValues(frame, v.Interface())
-			putArgs := []*funl.Item{
-				&funl.Item{Type: funl.ValueItem, Data: mapval},
-				&funl.Item{Type: funl.ValueItem, Data: keyv},
-				&funl.Item{Type: funl.ValueItem, Data: valv},
-			}
-			mapval = funl.HandlePutOP(frame, putArgs)
-			if mapval.Kind != funl.MapValue {
-				panic(fmt.Errorf("failed to put key-value to map"))
-			}
+			builder.Put(frame, keyv, traverseValues(frame, v.Interface(), decimals))
 		}
-		return mapval
+		return builder.Freeze(frame)
 	}
 	panic(fmt.Errorf("Unexpected type: %v (%v)", val, val.Kind()))
 }
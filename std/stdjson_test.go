@@ -24,7 +24,7 @@ func TestEncodeOK(t *testing.T) {
 	//inValue := funl.Value{Kind: funl.BoolValue, Data: true}
 	//inValue := funl.Value{Kind: funl.OpaqueValue, Data: &OpaqueJSONnull{}}
 	inValue := funl.Value{Kind: funl.FloatValue, Data: 0.5}
-	ok, errText, val := encodeJSON("dumname", nil, inValue)
+	ok, errText, val := encodeJSON("dumname", nil, inValue, false)
 
 	t.Logf("data = %s", val.Data.(*OpaqueByteArray).data)
 	if !ok {
@@ -33,7 +33,7 @@ func TestEncodeOK(t *testing.T) {
 }
 
 func TestDecodeOK(t *testing.T) {
-	ok, errText, val := decodeJSON("dumname", nil, jsonBlob)
+	ok, errText, val := decodeJSON("dumname", nil, jsonBlob, false, false)
 	if !ok {
 		t.Logf("error text = %s", errText)
 		t.Errorf("Should be ok")
@@ -47,7 +47,7 @@ func TestDecodeOK(t *testing.T) {
 }
 
 func TestDecodeFail(t *testing.T) {
-	ok, errText, _ := decodeJSON("dumname", nil, jsonBlobFail)
+	ok, errText, _ := decodeJSON("dumname", nil, jsonBlobFail, false, false)
 	if ok {
 		t.Errorf("Should fail")
 	}
@@ -55,3 +55,79 @@ func TestDecodeFail(t *testing.T) {
 		t.Errorf("No error text")
 	}
 }
+
+func TestBigIntEncodeDecode(t *testing.T) {
+	ok, errText, val := decodeJSON("dumname", nil, []byte(`123456789012345678901234567890`), false, false)
+	if !ok {
+		t.Fatalf("Not ok: %s", errText)
+	}
+	if val.Kind != funl.BigIntValue {
+		t.Fatalf("Not big int: %v", val)
+	}
+	ok, errText, encoded := encodeJSON("dumname", nil, val, false)
+	if !ok {
+		t.Fatalf("Not ok: %s", errText)
+	}
+	if s := string(encoded.Data.(*OpaqueByteArray).data); s != "123456789012345678901234567890" {
+		t.Errorf("unexpected encoding: %s", s)
+	}
+}
+
+func TestDecimalEncodeDecode(t *testing.T) {
+	ok, errText, val := decodeJSON("dumname", nil, []byte(`0.1`), true, false)
+	if !ok {
+		t.Fatalf("Not ok: %s", errText)
+	}
+	if val.Kind != funl.DecimalValue {
+		t.Fatalf("Not decimal: %v", val)
+	}
+	ok, errText, encoded := encodeJSON("dumname", nil, val, false)
+	if !ok {
+		t.Fatalf("Not ok: %s", errText)
+	}
+	if s := string(encoded.Data.(*OpaqueByteArray).data); s != `"0.1"` {
+		t.Errorf("unexpected encoding: %s", s)
+	}
+
+	third, _ := funl.ParseDecimal("1/3")
+	if ok, _, _ := encodeJSON("dumname", nil, funl.MakeDecimalValue(third), false); ok {
+		t.Errorf("Should fail")
+	}
+}
+
+func TestSortedEncode(t *testing.T) {
+	_, _, val := decodeJSON("dumname", nil, []byte(`{"b": 1, "c": {"z": 1, "y": 2}, "a": [{"k2": 1, "k1": 2}]}`), false, false)
+	ok, errText, encoded := encodeJSON("dumname", nil, val, true)
+	if !ok {
+		t.Fatalf("Not ok: %s", errText)
+	}
+	expected := `{"a": [{"k1": 2, "k2": 1}], "b": 1, "c": {"y": 2, "z": 1}}`
+	if s := string(encoded.Data.(*OpaqueByteArray).data); s != expected {
+		t.Errorf("unexpected encoding: %s", s)
+	}
+}
+
+func TestOrderedDecode(t *testing.T) {
+	src := `{"z": 1, "a": [true, {"q": null, "b": 0.5}], "m": "text"}`
+	ok, errText, val := decodeJSON("dumname", nil, []byte(src), false, true)
+	if !ok {
+		t.Fatalf("Not ok: %s", errText)
+	}
+	if _, isOMap := val.Data.(*OpaqueOMap); !isOMap {
+		t.Fatalf("Not omap: %v", val)
+	}
+	ok, errText, encoded := encodeJSON("dumname", nil, val, false)
+	if !ok {
+		t.Fatalf("Not ok: %s", errText)
+	}
+	if s := string(encoded.Data.(*OpaqueByteArray).data); s != src {
+		t.Errorf("unexpected encoding: %s", s)
+	}
+
+	if ok, _, _ := decodeJSON("dumname", nil, []byte(`{"a": 1} 2`), false, true); ok {
+		t.Errorf("Should fail")
+	}
+	if ok, _, _ := decodeJSON("dumname", nil, []byte(`{"a": }`), false, true); ok {
+		t.Errorf("Should fail")
+	}
+}
@@ -25,6 +25,21 @@ func initSTDlog(interpreter *funl.Interpreter) (err error) {
 	return
 }
 
+// defaultLoggerConfig is config map of get-default-logger
+type defaultLoggerConfig struct {
+	Prefix       string `funl:"prefix"`
+	Separator    string `funl:"separator"`
+	Date         bool   `funl:"date"`
+	Time         bool   `funl:"time"`
+	Microseconds bool   `funl:"microseconds"`
+	UTC          bool   `funl:"UTC"`
+}
+
+// loggerConfig is config map of get-logger
+type loggerConfig struct {
+	BufferSize int `funl:"buffer-size"`
+}
+
 func getStdLogGetDefaultLogger(name string) stdFuncType {
 	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
 		l := len(arguments)
@@ -32,72 +47,32 @@ func getStdLogGetDefaultLogger(name string) stdFuncType {
 			funl.RunTimeError2(frame, "%s: wrong amount of arguments (%d), needs one or two", name, l)
 		}
 
-		var prefix string
-		var flag int
-		var separator = ":"
+		config := defaultLoggerConfig{Separator: ":"}
 		// lets check config map if there is such
 		if l == 1 {
 			if arguments[0].Kind != funl.MapValue {
 				funl.RunTimeError2(frame, "%s: assuming map as 1st argument", name)
 			}
-
-			keyvals := funl.HandleKeyvalsOP(frame, []*funl.Item{&funl.Item{Type: funl.ValueItem, Data: arguments[0]}})
-			kvListIter := funl.NewListIterator(keyvals)
-			for {
-				nextKV := kvListIter.Next()
-				if nextKV == nil {
-					break
-				}
-				kvIter := funl.NewListIterator(*nextKV)
-				keyv := *(kvIter.Next())
-				valv := *(kvIter.Next())
-				if keyv.Kind != funl.StringValue {
-					funl.RunTimeError2(frame, "%s:config key not a string: %v", name, keyv)
-				}
-				switch keyStr := keyv.Data.(string); keyStr {
-				case "prefix":
-					if valv.Kind != funl.StringValue {
-						funl.RunTimeError2(frame, "%s: %s value not string: %v", name, keyStr, keyv)
-					}
-					prefix = valv.Data.(string)
-				case "separator":
-					if valv.Kind != funl.StringValue {
-						funl.RunTimeError2(frame, "%s: %s value not string: %v", name, keyStr, keyv)
-					}
-					separator = valv.Data.(string)
-				case "date":
-					if valv.Kind != funl.BoolValue {
-						funl.RunTimeError2(frame, "%s: %s value not bool value: %v", name, keyStr, keyv)
-					}
-					if valv.Data.(bool) {
-						flag |= log.Ldate
-					}
-				case "time":
-					if valv.Kind != funl.BoolValue {
-						funl.RunTimeError2(frame, "%s: %s value not bool value: %v", name, keyStr, keyv)
-					}
-					if valv.Data.(bool) {
-						flag |= log.Ltime
-					}
-				case "microseconds":
-					if valv.Kind != funl.BoolValue {
-						funl.RunTimeError2(frame, "%s: %s value not bool value: %v", name, keyStr, keyv)
-					}
-					if valv.Data.(bool) {
-						flag |= log.Lmicroseconds
-					}
-				case "UTC":
-					if valv.Kind != funl.BoolValue {
-						funl.RunTimeError2(frame, "%s: %s value not bool value: %v", name, keyStr, keyv)
-					}
-					if valv.Data.(bool) {
-						flag |= log.LUTC
-					}
-				}
+			if err := funl.FromValue(arguments[0], &config); err != nil {
+				funl.RunTimeError2(frame, "%s: invalid config: %v", name, err)
 			}
 		}
+		var flag int
+		if config.Date {
+			flag |= log.Ldate
+		}
+		if config.Time {
+			flag |= log.Ltime
+		}
+		if config.Microseconds {
+			flag |= log.Lmicroseconds
+		}
+		if config.UTC {
+			flag |= log.LUTC
+		}
+		separator := config.Separator
 
-		logger := log.New(os.Stdout, prefix, flag)
+		logger := log.New(os.Stdout, config.Prefix, flag)
 
 		logWrapper := func(wFrame *funl.Frame, wArguments []funl.Value) funl.Value {
 			if l := len(wArguments); l == 0 {
@@ -135,35 +110,17 @@ func getStdLogGetLogger(name string) stdFuncType {
 		}
 
 		const defaultLogBufferSize = 1024
-		bufSize := defaultLogBufferSize
+		config := loggerConfig{BufferSize: defaultLogBufferSize}
 		// lets check config map if there is such
 		if l == 2 {
 			if arguments[1].Kind != funl.MapValue {
 				funl.RunTimeError2(frame, "%s: assuming map as 2nd argument", name)
 			}
-
-			keyvals := funl.HandleKeyvalsOP(frame, []*funl.Item{&funl.Item{Type: funl.ValueItem, Data: arguments[1]}})
-			kvListIter := funl.NewListIterator(keyvals)
-			for {
-				nextKV := kvListIter.Next()
-				if nextKV == nil {
-					break
-				}
-				kvIter := funl.NewListIterator(*nextKV)
-				keyv := *(kvIter.Next())
-				valv := *(kvIter.Next())
-				if keyv.Kind != funl.StringValue {
-					funl.RunTimeError2(frame, "%s:config key not a string: %v", name, keyv)
-				}
-				switch keyStr := keyv.Data.(string); keyStr {
-				case "buffer-size":
-					if valv.Kind != funl.IntValue {
-						funl.RunTimeError2(frame, "%s: %s value not int: %v", name, keyStr, keyv)
-					}
-					bufSize = valv.Data.(int)
-				}
+			if err := funl.FromValue(arguments[1], &config); err != nil {
+				funl.RunTimeError2(frame, "%s: invalid config: %v", name, err)
 			}
 		}
+		bufSize := config.BufferSize
 
 		logCh := make(chan []*funl.Item, bufSize)
 
@@ -2,6 +2,7 @@ package std
 
 import (
 	"math"
+	"math/big"
 
 	"github.com/anssihalmeaho/funl/funl"
 )
@@ -175,6 +176,11 @@ func initSTDMath(interpreter *funl.Interpreter) (err error) {
 			Getter:     getStdMathCbrt,
 			IsFunction: true,
 		},
+		{
+			Name:       "round",
+			Getter:     getStdMathRound,
+			IsFunction: true,
+		},
 	}
 	err = setSTDFunctions(topFrame, stdModuleName, stdMathFuncs, interpreter)
 
@@ -247,6 +253,35 @@ func getStdMathPow(name string) stdFuncType {
 	}
 }
 
+// round(<decimal> <places:int> [<mode:string>]) -> decimal
+// mode is one of: 'half-even' (default), 'half-up', 'half-down', 'up', 'down', 'ceiling', 'floor'
+func getStdMathRound(name string) stdFuncType {
+	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
+		if l := len(arguments); l != 2 && l != 3 {
+			funl.RunTimeError2(frame, "%s: wrong amount of arguments (%d), need two or three", name, l)
+		}
+		if arguments[0].Kind != funl.DecimalValue {
+			funl.RunTimeError2(frame, "%s: requires decimal value as 1st argument", name)
+		}
+		if arguments[1].Kind != funl.IntValue {
+			funl.RunTimeError2(frame, "%s: requires int value as 2nd argument", name)
+		}
+		mode := "half-even"
+		if len(arguments) == 3 {
+			if arguments[2].Kind != funl.StringValue {
+				funl.RunTimeError2(frame, "%s: requires string value as 3rd argument", name)
+			}
+			mode = arguments[2].Data.(string)
+		}
+		result, err := funl.RoundDecimal(arguments[0].Data.(*big.Rat), arguments[1].Data.(int), mode)
+		if err != nil {
+			funl.RunTimeError2(frame, "%s: %v", name, err)
+		}
+		retVal = funl.MakeDecimalValue(result)
+		return
+	}
+}
+
 func getStdMathLdexp(name string) stdFuncType {
 	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
 		if l := len(arguments); l != 2 {
@@ -0,0 +1,327 @@
+package std
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/anssihalmeaho/funl/funl"
+)
+
+// Generic functions dispatch call to implementation selected by
+// type name (as given by type -operator) of first argument.
+// Generics are in interpreter specific registry by name so that implementations
+// can be added from any module (also from Go extensions with RegisterMethod).
+
+type genericFunc struct {
+	name       string
+	isProc     bool
+	declared   bool // kind (func/proc) is fixed when generic is declared
+	impls      map[string]funl.Value
+	defaultImp *funl.Value
+	sync.RWMutex
+}
+
+// genericRegistry has generics of one interpreter
+type genericRegistry struct {
+	byName map[string]*genericFunc
+	sync.Mutex
+}
+
+type genericRegistryKey struct{}
+
+func genericsOf(interpreter *funl.Interpreter) *genericRegistry {
+	return interpreter.State(genericRegistryKey{}, func() interface{} {
+		return &genericRegistry{byName: map[string]*genericFunc{}}
+	}).(*genericRegistry)
+}
+
+// getGeneric returns generic by name, new one is created if not found
+// (so implementations can be added before generic is declared)
+func getGeneric(interpreter *funl.Interpreter, name string) *genericFunc {
+	generics := genericsOf(interpreter)
+	generics.Lock()
+	defer generics.Unlock()
+
+	gen, found := generics.byName[name]
+	if !found {
+		gen = &genericFunc{name: name, impls: map[string]funl.Value{}}
+		generics.byName[name] = gen
+	}
+	return gen
+}
+
+// lookupGeneric returns generic by name if it exists
+func lookupGeneric(interpreter *funl.Interpreter, name string) (*genericFunc, bool) {
+	generics := genericsOf(interpreter)
+	generics.Lock()
+	defer generics.Unlock()
+
+	gen, found := generics.byName[name]
+	return gen, found
+}
+
+// declare fixes kind of generic, implementations added so far are checked
+func (gen *genericFunc) declare(isProc bool) error {
+	gen.Lock()
+	defer gen.Unlock()
+
+	if gen.declared {
+		if gen.isProc != isProc {
+			return fmt.Errorf("generic %s already defined with different kind (proc: %v)", gen.name, gen.isProc)
+		}
+		return nil
+	}
+	for _, impl := range gen.impls {
+		if err := gen.checkImplKind(impl, !isProc); err != nil {
+			return err
+		}
+	}
+	if gen.defaultImp != nil {
+		if err := gen.checkImplKind(*gen.defaultImp, !isProc); err != nil {
+			return err
+		}
+	}
+	gen.isProc, gen.declared = isProc, true
+	return nil
+}
+
+func (gen *genericFunc) checkImpl(impl funl.Value) error {
+	return gen.checkImplKind(impl, gen.declared && !gen.isProc)
+}
+
+func (gen *genericFunc) checkImplKind(impl funl.Value, mustBeFunc bool) error {
+	switch impl.Kind {
+	case funl.FunctionValue:
+		if mustBeFunc && impl.Data.(funl.FuncValue).FuncProto.IsProc {
+			return fmt.Errorf("proc not allowed as implementation of function generic %s", gen.name)
+		}
+	case funl.ExtProcValue:
+		if mustBeFunc && !impl.Data.(funl.ExtProcType).IsFunction {
+			return fmt.Errorf("ext-proc not allowed as implementation of function generic %s", gen.name)
+		}
+	default:
+		return fmt.Errorf("implementation should be func or proc (%s)", gen.name)
+	}
+	return nil
+}
+
+func (gen *genericFunc) implement(typeName string, impl funl.Value) error {
+	gen.Lock()
+	defer gen.Unlock()
+
+	if err := gen.checkImpl(impl); err != nil {
+		return err
+	}
+	gen.impls[typeName] = impl
+	return nil
+}
+
+func (gen *genericFunc) setDefault(impl funl.Value) error {
+	gen.Lock()
+	defer gen.Unlock()
+
+	if err := gen.checkImpl(impl); err != nil {
+		return err
+	}
+	gen.defaultImp = &impl
+	return nil
+}
+
+func (gen *genericFunc) dispatch(frame *funl.Frame, arguments []funl.Value) funl.Value {
+	if len(arguments) == 0 {
+		funl.RunTimeError2(frame, "%s: generic needs at least one argument", gen.name)
+	}
+	typeName := funl.TypeNameOf(arguments[0])
+
+	gen.RLock()
+	impl, found := gen.impls[typeName]
+	if !found && gen.defaultImp != nil {
+		impl, found = *gen.defaultImp, true
+	}
+	gen.RUnlock()
+	if !found {
+		funl.RunTimeError2(frame, "%s: no implementation for type %s", gen.name, typeName)
+	}
+
+	argsForCall := []*funl.Item{{Type: funl.ValueItem, Data: impl}}
+	for _, arg := range arguments {
+		argsForCall = append(argsForCall, &funl.Item{Type: funl.ValueItem, Data: arg})
+	}
+	return funl.HandleCallOP(frame, argsForCall)
+}
+
+func (gen *genericFunc) value() funl.Value {
+	extProc := funl.ExtProcType{
+		Impl:       gen.dispatch,
+		IsFunction: !gen.isProc,
+	}
+	return funl.Value{Kind: funl.ExtProcValue, Data: extProc}
+}
+
+// RegisterMethod adds implementation for type name to generic
+// (generic may be declared later with stdmm.generic)
+func RegisterMethod(interpreter *funl.Interpreter, genericName, typeName string, impl funl.Value) error {
+	return getGeneric(interpreter, genericName).implement(typeName, impl)
+}
+
+func initSTDmm(interpreter *funl.Interpreter) (err error) {
+	stdModuleName := "stdmm"
+	topFrame := funl.NewTopFrameWithInterpreter(interpreter)
+	stdFuncs := []stdFuncInfo{
+		{
+			Name:   "generic",
+			Getter: getStdMMGeneric,
+		},
+		{
+			Name:   "implement",
+			Getter: getStdMMImplement,
+		},
+		{
+			Name:   "set-default",
+			Getter: getStdMMSetDefault,
+		},
+		{
+			Name:       "impls",
+			Getter:     getStdMMImpls,
+			IsFunction: true,
+		},
+	}
+	err = setSTDFunctions(topFrame, stdModuleName, stdFuncs, interpreter)
+	return
+}
+
+// genericNameArg returns generic name given as argument
+func genericNameArg(frame *funl.Frame, name string, arg funl.Value) string {
+	if arg.Kind != funl.StringValue {
+		funl.RunTimeError2(frame, "%s: requires generic name as string", name)
+	}
+	return arg.Data.(string)
+}
+
+// genericArg returns generic by name, new one is created if not found
+func genericArg(frame *funl.Frame, name string, arg funl.Value) *genericFunc {
+	return getGeneric(frame.GetTopFrame().Interpreter, genericNameArg(frame, name, arg))
+}
+
+// call(stdmm.generic <name:string> [<options:map>]) -> generic
+// options: 'default': <func/proc>, 'proc': <bool>
+// (it's procedure as it registers generic and its default implementation)
+func getStdMMGeneric(name string) stdFuncType {
+	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
+		if l := len(arguments); l != 1 && l != 2 {
+			funl.RunTimeError2(frame, "%s: wrong amount of arguments (%d)", name, l)
+		}
+		if arguments[0].Kind != funl.StringValue {
+			funl.RunTimeError2(frame, "%s: requires string as generic name", name)
+		}
+		var isProc bool
+		var defaultImp *funl.Value
+		if len(arguments) == 2 {
+			if arguments[1].Kind != funl.MapValue {
+				funl.RunTimeError2(frame, "%s: requires map as options", name)
+			}
+			keyvals := funl.HandleKeyvalsOP(frame, []*funl.Item{{Type: funl.ValueItem, Data: arguments[1]}})
+			kvListIter := funl.NewListIterator(keyvals)
+			for kv := kvListIter.Next(); kv != nil; kv = kvListIter.Next() {
+				kvIter := funl.NewListIterator(*kv)
+				keyv, valv := *kvIter.Next(), *kvIter.Next()
+				switch keyv.Data {
+				case "proc":
+					if valv.Kind != funl.BoolValue {
+						funl.RunTimeError2(frame, "%s: proc option should be bool", name)
+					}
+					isProc = valv.Data.(bool)
+				case "default":
+					defaultImp = &valv
+				default:
+					funl.RunTimeError2(frame, "%s: unknown option (%v)", name, keyv)
+				}
+			}
+		}
+		gen := getGeneric(frame.GetTopFrame().Interpreter, arguments[0].Data.(string))
+		if err := gen.declare(isProc); err != nil {
+			funl.RunTimeError2(frame, "%s: %v", name, err)
+		}
+		if defaultImp != nil {
+			if err := gen.setDefault(*defaultImp); err != nil {
+				funl.RunTimeError2(frame, "%s: %v", name, err)
+			}
+		}
+		retVal = gen.value()
+		return
+	}
+}
+
+// call(stdmm.implement <generic-name> <type-name or list of type-names> <func/proc>) -> true
+func getStdMMImplement(name string) stdFuncType {
+	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
+		if l := len(arguments); l != 3 {
+			funl.RunTimeError2(frame, "%s: wrong amount of arguments (%d), need three", name, l)
+		}
+		gen := genericArg(frame, name, arguments[0])
+
+		var typeNames []string
+		switch arguments[1].Kind {
+		case funl.StringValue:
+			typeNames = append(typeNames, arguments[1].Data.(string))
+		case funl.ListValue:
+			lit := funl.NewListIterator(arguments[1])
+			for v := lit.Next(); v != nil; v = lit.Next() {
+				if v.Kind != funl.StringValue {
+					funl.RunTimeError2(frame, "%s: type name should be string (%v)", name, *v)
+				}
+				typeNames = append(typeNames, v.Data.(string))
+			}
+		default:
+			funl.RunTimeError2(frame, "%s: requires type name or list of type names", name)
+		}
+		for _, typeName := range typeNames {
+			if err := gen.implement(typeName, arguments[2]); err != nil {
+				funl.RunTimeError2(frame, "%s: %v", name, err)
+			}
+		}
+		retVal = funl.Value{Kind: funl.BoolValue, Data: true}
+		return
+	}
+}
+
+// call(stdmm.set-default <generic-name> <func/proc>) -> true
+func getStdMMSetDefault(name string) stdFuncType {
+	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
+		if l := len(arguments); l != 2 {
+			funl.RunTimeError2(frame, "%s: wrong amount of arguments (%d), need two", name, l)
+		}
+		gen := genericArg(frame, name, arguments[0])
+		if err := gen.setDefault(arguments[1]); err != nil {
+			funl.RunTimeError2(frame, "%s: %v", name, err)
+		}
+		retVal = funl.Value{Kind: funl.BoolValue, Data: true}
+		return
+	}
+}
+
+// call(stdmm.impls <generic-name>) -> list of type names (sorted)
+func getStdMMImpls(name string) stdFuncType {
+	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
+		if l := len(arguments); l != 1 {
+			funl.RunTimeError2(frame, "%s: wrong amount of arguments (%d), need one", name, l)
+		}
+		var typeNames []string
+		if gen, found := lookupGeneric(frame.GetTopFrame().Interpreter, genericNameArg(frame, name, arguments[0])); found {
+			gen.RLock()
+			for typeName := range gen.impls {
+				typeNames = append(typeNames, typeName)
+			}
+			gen.RUnlock()
+			sort.Strings(typeNames)
+		}
+
+		var values []funl.Value
+		for _, typeName := range typeNames {
+			values = append(values, funl.Value{Kind: funl.StringValue, Data: typeName})
+		}
+		retVal = funl.MakeListOfValues(frame, values)
+		return
+	}
+}
@@ -0,0 +1,386 @@
+package std
+
+import (
+	"strings"
+
+	"github.com/anssihalmeaho/funl/funl"
+	"github.com/anssihalmeaho/funl/pvec"
+)
+
+// OpaqueOMap is persistent insertion-ordered map, keys are iterated
+// in order in which they were added (setting value of existing key keeps its place)
+type OpaqueOMap struct {
+	vals  funl.Value   // key -> value
+	index funl.Value   // key -> position of key in order
+	order *pvec.Vector // keys in insertion order, deleted keys are nil
+	count int
+}
+
+// TypeName ...
+func (om *OpaqueOMap) TypeName() string {
+	return "omap"
+}
+
+// Str ...
+func (om *OpaqueOMap) Str() string {
+	var items []string
+	for _, kv := range om.keyVals(nil) {
+		items = append(items, kv.Key.String()+" : "+kv.Val.String())
+	}
+	return "omap(" + strings.Join(items, ", ") + ")"
+}
+
+// Equals ...
+func (om *OpaqueOMap) Equals(with funl.OpaqueAPI) bool {
+	other, ok := with.(*OpaqueOMap)
+	if !ok {
+		return false
+	}
+	kvs1, kvs2 := om.keyVals(nil), other.keyVals(nil)
+	if len(kvs1) != len(kvs2) {
+		return false
+	}
+	for i := range kvs1 {
+		if !isEqualValues(kvs1[i].Key, kvs2[i].Key) || !isEqualValues(kvs1[i].Val, kvs2[i].Val) {
+			return false
+		}
+	}
+	return true
+}
+
+// Get makes get and getl operators work with omap
+func (om *OpaqueOMap) Get(frame *funl.Frame, key funl.Value) (funl.Value, bool) {
+	return om.get(frame, key)
+}
+
+// Encode represents omap as list of key-value pairs (in insertion order)
+func (om *OpaqueOMap) Encode(frame *funl.Frame) funl.Value {
+	var pairs []funl.Value
+	for _, kv := range om.keyVals(frame) {
+		pairs = append(pairs, funl.MakeListOfValues(frame, []funl.Value{kv.Key, kv.Val}))
+	}
+	return funl.MakeListOfValues(frame, pairs)
+}
+
+func isEqualValues(v1, v2 funl.Value) bool {
+	result := funl.HandleEqOP(nil, []*funl.Item{{Type: funl.ValueItem, Data: v1}, {Type: funl.ValueItem, Data: v2}})
+	return result.Data.(bool)
+}
+
+func newOMap(frame *funl.Frame) *OpaqueOMap {
+	emptyMap := funl.NewMapBuilder(frame).Freeze(frame)
+	return &OpaqueOMap{vals: emptyMap, index: emptyMap, order: pvec.New()}
+}
+
+func (om *OpaqueOMap) get(frame *funl.Frame, key funl.Value) (funl.Value, bool) {
+	return funl.MapGet(frame, om.vals, key)
+}
+
+func mapPut(frame *funl.Frame, mapVal, key, val funl.Value) funl.Value {
+	return funl.HandlePutOP(frame, []*funl.Item{
+		{Type: funl.ValueItem, Data: mapVal},
+		{Type: funl.ValueItem, Data: key},
+		{Type: funl.ValueItem, Data: val},
+	})
+}
+
+// set returns new omap where key has value, new key is added to end
+func (om *OpaqueOMap) set(frame *funl.Frame, key, val funl.Value) *OpaqueOMap {
+	if vals, found := funl.MapDel(frame, om.vals, key); found {
+		return &OpaqueOMap{vals: mapPut(frame, vals, key, val), index: om.index, order: om.order, count: om.count}
+	}
+	return &OpaqueOMap{
+		vals:  mapPut(frame, om.vals, key, val),
+		index: mapPut(frame, om.index, key, funl.Value{Kind: funl.IntValue, Data: om.order.Len()}),
+		order: om.order.Append(key),
+		count: om.count + 1,
+	}
+}
+
+// del returns new omap without key, place of key in order is left empty
+// and order is compacted when there are more empty places than keys
+func (om *OpaqueOMap) del(frame *funl.Frame, key funl.Value) *OpaqueOMap {
+	vals, found := funl.MapDel(frame, om.vals, key)
+	if !found {
+		return om
+	}
+	pos, _ := funl.MapGet(frame, om.index, key)
+	index, _ := funl.MapDel(frame, om.index, key)
+	order, _ := om.order.Set(pos.Data.(int), nil)
+	result := &OpaqueOMap{vals: vals, index: index, order: order, count: om.count - 1}
+	if order.Len() > 2*result.count+32 {
+		result.compact(frame)
+	}
+	return result
+}
+
+// compact removes empty places from order
+func (om *OpaqueOMap) compact(frame *funl.Frame) {
+	index := funl.NewMapBuilder(frame)
+	order := pvec.New()
+	om.visitKeys(func(key funl.Value) {
+		index.Put(frame, key, funl.Value{Kind: funl.IntValue, Data: order.Len()})
+		order = order.Append(key)
+	})
+	om.index, om.order = index.Freeze(frame), order
+}
+
+func (om *OpaqueOMap) len() int {
+	return om.count
+}
+
+// visitKeys calls visitor for keys in insertion order
+func (om *OpaqueOMap) visitKeys(visitor func(key funl.Value)) {
+	om.order.Visit(func(_ int, item pvec.Item) bool {
+		if item != nil {
+			visitor(item.(funl.Value))
+		}
+		return true
+	})
+}
+
+// keyVals returns key-values in insertion order
+func (om *OpaqueOMap) keyVals(frame *funl.Frame) []funl.KeyVal {
+	kvs := make([]funl.KeyVal, 0, om.count)
+	om.visitKeys(func(key funl.Value) {
+		val, _ := om.get(frame, key)
+		kvs = append(kvs, funl.KeyVal{Key: key, Val: val})
+	})
+	return kvs
+}
+
+func omapValue(om *OpaqueOMap) funl.Value {
+	return funl.Value{Kind: funl.OpaqueValue, Data: om}
+}
+
+func omapArg(frame *funl.Frame, name string, arguments []funl.Value, count int) *OpaqueOMap {
+	if l := len(arguments); l != count {
+		funl.RunTimeError2(frame, "%s: wrong amount of arguments (%d), need %d", name, l, count)
+	}
+	om, ok := arguments[0].Data.(*OpaqueOMap)
+	if arguments[0].Kind != funl.OpaqueValue || !ok {
+		funl.RunTimeError2(frame, "%s: requires omap as first argument", name)
+	}
+	return om
+}
+
+func initSTDOMap(interpreter *funl.Interpreter) (err error) {
+	stdModuleName := "stdomap"
+	topFrame := funl.NewTopFrameWithInterpreter(interpreter)
+	stdFuncs := []stdFuncInfo{
+		{
+			Name:       "new",
+			Getter:     getStdOMapNew,
+			IsFunction: true,
+		},
+		{
+			Name:       "from-keyvals",
+			Getter:     getStdOMapFromKeyvals,
+			IsFunction: true,
+		},
+		{
+			Name:       "put",
+			Getter:     getStdOMapPut(false),
+			IsFunction: true,
+		},
+		{
+			Name:       "set",
+			Getter:     getStdOMapPut(true),
+			IsFunction: true,
+		},
+		{
+			Name:       "get",
+			Getter:     getStdOMapGet,
+			IsFunction: true,
+		},
+		{
+			Name:       "getl",
+			Getter:     getStdOMapGetl,
+			IsFunction: true,
+		},
+		{
+			Name:       "del",
+			Getter:     getStdOMapDel,
+			IsFunction: true,
+		},
+		{
+			Name:       "len",
+			Getter:     getStdOMapLen,
+			IsFunction: true,
+		},
+		{
+			Name:       "keys",
+			Getter:     getStdOMapItems(func(kv funl.KeyVal, frame *funl.Frame) funl.Value { return kv.Key }),
+			IsFunction: true,
+		},
+		{
+			Name:       "vals",
+			Getter:     getStdOMapItems(func(kv funl.KeyVal, frame *funl.Frame) funl.Value { return kv.Val }),
+			IsFunction: true,
+		},
+		{
+			Name: "keyvals",
+			Getter: getStdOMapItems(func(kv funl.KeyVal, frame *funl.Frame) funl.Value {
+				return funl.MakeListOfValues(frame, []funl.Value{kv.Key, kv.Val})
+			}),
+			IsFunction: true,
+		},
+		{
+			Name:       "to-map",
+			Getter:     getStdOMapToMap,
+			IsFunction: true,
+		},
+		{
+			Name:       "is-omap",
+			Getter:     getStdOMapIsOMap,
+			IsFunction: true,
+		},
+	}
+	err = setSTDFunctions(topFrame, stdModuleName, stdFuncs, interpreter)
+	return
+}
+
+// call(stdomap.new <key> <value> <key> <value> ...) -> omap
+func getStdOMapNew(name string) stdFuncType {
+	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
+		if l := len(arguments); l%2 != 0 {
+			funl.RunTimeError2(frame, "%s: even amount of arguments needed (%d given)", name, l)
+		}
+		om := newOMap(frame)
+		for i := 0; i < len(arguments); i += 2 {
+			if _, found := om.get(frame, arguments[i]); found {
+				funl.RunTimeError2(frame, "%s: duplicate key (%v)", name, arguments[i])
+			}
+			om = om.set(frame, arguments[i], arguments[i+1])
+		}
+		retVal = omapValue(om)
+		return
+	}
+}
+
+// call(stdomap.from-keyvals <list of key-value pairs>) -> omap
+// (if same key is several times then last value is used)
+func getStdOMapFromKeyvals(name string) stdFuncType {
+	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
+		if l := len(arguments); l != 1 {
+			funl.RunTimeError2(frame, "%s: wrong amount of arguments (%d), need one", name, l)
+		}
+		if arguments[0].Kind != funl.ListValue {
+			funl.RunTimeError2(frame, "%s: requires list", name)
+		}
+		om := newOMap(frame)
+		it := funl.NewListIterator(arguments[0])
+		for next := it.Next(); next != nil; next = it.Next() {
+			if next.Kind != funl.ListValue {
+				funl.RunTimeError2(frame, "%s: key-value pair should be list", name)
+			}
+			kvIter := funl.NewListIterator(*next)
+			key, val := kvIter.Next(), kvIter.Next()
+			if key == nil || val == nil || kvIter.Next() != nil {
+				funl.RunTimeError2(frame, "%s: key-value pair should have two items", name)
+			}
+			om = om.set(frame, *key, *val)
+		}
+		retVal = omapValue(om)
+		return
+	}
+}
+
+// call(stdomap.put <omap> <key> <value>) -> omap
+// (set replaces value if key exists (keeping its place), put gives error)
+func getStdOMapPut(replace bool) func(name string) stdFuncType {
+	return func(name string) stdFuncType {
+		return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
+			om := omapArg(frame, name, arguments, 3)
+			if !replace {
+				if _, found := om.get(frame, arguments[1]); found {
+					funl.RunTimeError2(frame, "%s: key already exists (%v)", name, arguments[1])
+				}
+			}
+			retVal = omapValue(om.set(frame, arguments[1], arguments[2]))
+			return
+		}
+	}
+}
+
+// call(stdomap.get <omap> <key>) -> value (error if not found)
+func getStdOMapGet(name string) stdFuncType {
+	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
+		om := omapArg(frame, name, arguments, 2)
+		val, found := om.get(frame, arguments[1])
+		if !found {
+			funl.RunTimeError2(frame, "%s: key not found (%v)", name, arguments[1])
+		}
+		retVal = val
+		return
+	}
+}
+
+// call(stdomap.getl <omap> <key>) -> list(<found> <value>)
+func getStdOMapGetl(name string) stdFuncType {
+	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
+		om := omapArg(frame, name, arguments, 2)
+		val, found := om.get(frame, arguments[1])
+		if !found {
+			val = funl.Value{Kind: funl.BoolValue, Data: false}
+		}
+		retVal = funl.MakeListOfValues(frame, []funl.Value{{Kind: funl.BoolValue, Data: found}, val})
+		return
+	}
+}
+
+// call(stdomap.del <omap> <key>) -> omap
+func getStdOMapDel(name string) stdFuncType {
+	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
+		om := omapArg(frame, name, arguments, 2)
+		retVal = omapValue(om.del(frame, arguments[1]))
+		return
+	}
+}
+
+// call(stdomap.len <omap>) -> int
+func getStdOMapLen(name string) stdFuncType {
+	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
+		om := omapArg(frame, name, arguments, 1)
+		retVal = funl.Value{Kind: funl.IntValue, Data: om.len()}
+		return
+	}
+}
+
+// call(stdomap.keys <omap>) -> list
+// (vals and keyvals are similar, all are in insertion order)
+func getStdOMapItems(itemOf func(funl.KeyVal, *funl.Frame) funl.Value) func(name string) stdFuncType {
+	return func(name string) stdFuncType {
+		return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
+			om := omapArg(frame, name, arguments, 1)
+			kvs := om.keyVals(frame)
+			values := make([]funl.Value, 0, len(kvs))
+			for _, kv := range kvs {
+				values = append(values, itemOf(kv, frame))
+			}
+			retVal = funl.MakeListOfValues(frame, values)
+			return
+		}
+	}
+}
+
+// call(stdomap.to-map <omap>) -> map
+func getStdOMapToMap(name string) stdFuncType {
+	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
+		om := omapArg(frame, name, arguments, 1)
+		retVal = om.vals
+		return
+	}
+}
+
+// call(stdomap.is-omap <value>) -> bool
+func getStdOMapIsOMap(name string) stdFuncType {
+	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
+		if l := len(arguments); l != 1 {
+			funl.RunTimeError2(frame, "%s: wrong amount of arguments (%d), need one", name, l)
+		}
+		_, ok := arguments[0].Data.(*OpaqueOMap)
+		retVal = funl.Value{Kind: funl.BoolValue, Data: arguments[0].Kind == funl.OpaqueValue && ok}
+		return
+	}
+}
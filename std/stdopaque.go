@@ -0,0 +1,43 @@
+package std
+
+import (
+	"github.com/anssihalmeaho/funl/funl"
+)
+
+func initSTDOpaque(interpreter *funl.Interpreter) (err error) {
+	stdModuleName := "stdopaque"
+	topFrame := funl.NewTopFrameWithInterpreter(interpreter)
+	stdFuncs := []stdFuncInfo{
+		{
+			Name:       "encode",
+			Getter:     getStdOpaqueEncode,
+			IsFunction: true,
+		},
+	}
+	err = setSTDFunctions(topFrame, stdModuleName, stdFuncs, interpreter)
+	return
+}
+
+// call(stdopaque.encode <value>) -> list(<ok:bool> <type-name:string> <value>)
+// (ok is false if value is not opaque value which can be encoded)
+func getStdOpaqueEncode(name string) stdFuncType {
+	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
+		if l := len(arguments); l != 1 {
+			funl.RunTimeError2(frame, "%s: wrong amount of arguments (%d), need one", name, l)
+		}
+		values := []funl.Value{
+			{Kind: funl.BoolValue, Data: false},
+			{Kind: funl.StringValue, Data: ""},
+			{Kind: funl.StringValue, Data: ""},
+		}
+		if enc, ok := arguments[0].Data.(funl.OpaqueEncoder); ok && arguments[0].Kind == funl.OpaqueValue {
+			values = []funl.Value{
+				{Kind: funl.BoolValue, Data: true},
+				{Kind: funl.StringValue, Data: arguments[0].Data.(funl.OpaqueAPI).TypeName()},
+				enc.Encode(frame),
+			}
+		}
+		retVal = funl.MakeListOfValues(frame, values)
+		return
+	}
+}
@@ -0,0 +1,100 @@
+package std
+
+import (
+	"github.com/anssihalmeaho/funl/funl"
+)
+
+func initSTDOrder(interpreter *funl.Interpreter) (err error) {
+	stdModuleName := "stdorder"
+	topFrame := funl.NewTopFrameWithInterpreter(interpreter)
+	stdFuncs := []stdFuncInfo{
+		{
+			Name:       "compare",
+			Getter:     getStdOrderCompare,
+			IsFunction: true,
+		},
+		{
+			Name:       "sort",
+			Getter:     getStdOrderSort,
+			IsFunction: true,
+		},
+		{
+			Name:       "sorted-keys",
+			Getter:     getStdOrderSortedMap(func(kv funl.KeyVal, frame *funl.Frame) funl.Value { return kv.Key }),
+			IsFunction: true,
+		},
+		{
+			Name:       "sorted-vals",
+			Getter:     getStdOrderSortedMap(func(kv funl.KeyVal, frame *funl.Frame) funl.Value { return kv.Val }),
+			IsFunction: true,
+		},
+		{
+			Name: "sorted-keyvals",
+			Getter: getStdOrderSortedMap(func(kv funl.KeyVal, frame *funl.Frame) funl.Value {
+				return funl.MakeListOfValues(frame, []funl.Value{kv.Key, kv.Val})
+			}),
+			IsFunction: true,
+		},
+	}
+	err = setSTDFunctions(topFrame, stdModuleName, stdFuncs, interpreter)
+	return
+}
+
+// call(stdorder.compare <value> <value>) -> int (-1, 0 or 1)
+func getStdOrderCompare(name string) stdFuncType {
+	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
+		if l := len(arguments); l != 2 {
+			funl.RunTimeError2(frame, "%s: wrong amount of arguments (%d), need two", name, l)
+		}
+		retVal = funl.Value{Kind: funl.IntValue, Data: funl.CompareValues(arguments[0], arguments[1])}
+		return
+	}
+}
+
+// call(stdorder.sort <list/vector/set>) -> list (sorted)
+func getStdOrderSort(name string) stdFuncType {
+	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
+		if l := len(arguments); l != 1 {
+			funl.RunTimeError2(frame, "%s: wrong amount of arguments (%d), need one", name, l)
+		}
+		var values []funl.Value
+		switch arguments[0].Kind {
+		case funl.ListValue:
+			it := funl.NewListIterator(arguments[0])
+			for next := it.Next(); next != nil; next = it.Next() {
+				values = append(values, *next)
+			}
+		case funl.VectorValue:
+			values = funl.VectorValues(arguments[0])
+		case funl.SetValue:
+			values = funl.SetValues(frame, arguments[0])
+		default:
+			funl.RunTimeError2(frame, "%s: requires list, vector or set", name)
+		}
+		funl.SortValues(values)
+		retVal = funl.MakeListOfValues(frame, values)
+		return
+	}
+}
+
+// call(stdorder.sorted-keys <map>) -> list
+// (sorted-vals and sorted-keyvals are similar, all are in key order)
+func getStdOrderSortedMap(itemOf func(funl.KeyVal, *funl.Frame) funl.Value) func(name string) stdFuncType {
+	return func(name string) stdFuncType {
+		return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
+			if l := len(arguments); l != 1 {
+				funl.RunTimeError2(frame, "%s: wrong amount of arguments (%d), need one", name, l)
+			}
+			if arguments[0].Kind != funl.MapValue && arguments[0].Kind != funl.RecordValue {
+				funl.RunTimeError2(frame, "%s: requires map", name)
+			}
+			kvs := funl.SortedKeyVals(arguments[0])
+			values := make([]funl.Value, 0, len(kvs))
+			for _, kv := range kvs {
+				values = append(values, itemOf(kv, frame))
+			}
+			retVal = funl.MakeListOfValues(frame, values)
+			return
+		}
+	}
+}
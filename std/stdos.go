@@ -27,14 +27,8 @@ func initSTDos(interpreter *funl.Interpreter) (err error) {
 			Name:   "getenv",
 			Getter: getStdOSGetEnv,
 		},
-		{
-			Name:   "setenv",
-			Getter: getStdOSSetEnv,
-		},
-		{
-			Name:   "unsetenv",
-			Getter: getStdOSUnSetEnv,
-		},
+		goFuncInfo("setenv", func(key, value string) string { return errorText(os.Setenv(key, value)) }, false),
+		goFuncInfo("unsetenv", func(key string) string { return errorText(os.Unsetenv(key)) }, false),
 		{
 			Name:   "exec",
 			Getter: getStdOSExec,
@@ -180,49 +174,6 @@ func getStdOSExec(name string) stdFuncType {
 	}
 }
 
-func getStdOSUnSetEnv(name string) stdFuncType {
-	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
-		l := len(arguments)
-		if l != 1 {
-			funl.RunTimeError2(frame, "%s: wrong amount of arguments (%d), needs one", name, l)
-		}
-
-		if arguments[0].Kind != funl.StringValue {
-			funl.RunTimeError2(frame, "%s: assuming string as key", name)
-		}
-		err := os.Unsetenv(arguments[0].Data.(string))
-		var errText string
-		if err != nil {
-			errText = err.Error()
-		}
-		retVal = funl.Value{Kind: funl.StringValue, Data: errText}
-		return
-	}
-}
-
-func getStdOSSetEnv(name string) stdFuncType {
-	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
-		l := len(arguments)
-		if l != 2 {
-			funl.RunTimeError2(frame, "%s: wrong amount of arguments (%d), needs two", name, l)
-		}
-
-		if arguments[0].Kind != funl.StringValue {
-			funl.RunTimeError2(frame, "%s: assuming string as key", name)
-		}
-		if arguments[1].Kind != funl.StringValue {
-			funl.RunTimeError2(frame, "%s: assuming string as value", name)
-		}
-		err := os.Setenv(arguments[0].Data.(string), arguments[1].Data.(string))
-		var errText string
-		if err != nil {
-			errText = err.Error()
-		}
-		retVal = funl.Value{Kind: funl.StringValue, Data: errText}
-		return
-	}
-}
-
 func getStdOSGetEnv(name string) stdFuncType {
 	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
 		l := len(arguments)
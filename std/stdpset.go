@@ -0,0 +1,127 @@
+package std
+
+import (
+	"github.com/anssihalmeaho/funl/funl"
+)
+
+func initSTDpset(interpreter *funl.Interpreter) (err error) {
+	stdModuleName := "stdpset"
+	topFrame := funl.NewTopFrameWithInterpreter(interpreter)
+	stdFuncs := []stdFuncInfo{
+		{
+			Name:       "add",
+			Getter:     getStdPsetAdd,
+			IsFunction: true,
+		},
+		{
+			Name:       "remove",
+			Getter:     getStdPsetRemove,
+			IsFunction: true,
+		},
+		{
+			Name:       "contains",
+			Getter:     getStdPsetContains,
+			IsFunction: true,
+		},
+		{
+			Name:       "union",
+			Getter:     getStdPsetSetOper(funl.SetUnion),
+			IsFunction: true,
+		},
+		{
+			Name:       "intersection",
+			Getter:     getStdPsetSetOper(funl.SetIntersection),
+			IsFunction: true,
+		},
+		{
+			Name:       "difference",
+			Getter:     getStdPsetSetOper(funl.SetDifference),
+			IsFunction: true,
+		},
+		{
+			Name:       "is-subset",
+			Getter:     getStdPsetIsSubset,
+			IsFunction: true,
+		},
+		{
+			Name:       "is-set",
+			Getter:     getStdPsetIsSet,
+			IsFunction: true,
+		},
+	}
+	err = setSTDFunctions(topFrame, stdModuleName, stdFuncs, interpreter)
+	return
+}
+
+func checkSetArgs(frame *funl.Frame, name string, arguments []funl.Value, count int) {
+	if l := len(arguments); l < count {
+		funl.RunTimeError2(frame, "%s: wrong amount of arguments (%d), need at least %d", name, l, count)
+	}
+	if arguments[0].Kind != funl.SetValue {
+		funl.RunTimeError2(frame, "%s: requires set as first argument", name)
+	}
+}
+
+// call(stdpset.add <set> <item> ...) -> set
+func getStdPsetAdd(name string) stdFuncType {
+	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
+		checkSetArgs(frame, name, arguments, 1)
+		retVal = funl.SetAdd(frame, arguments[0], arguments[1:]...)
+		return
+	}
+}
+
+// call(stdpset.remove <set> <item> ...) -> set
+func getStdPsetRemove(name string) stdFuncType {
+	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
+		checkSetArgs(frame, name, arguments, 1)
+		retVal = funl.SetRemove(frame, arguments[0], arguments[1:]...)
+		return
+	}
+}
+
+// call(stdpset.contains <set> <item>) -> bool
+func getStdPsetContains(name string) stdFuncType {
+	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
+		checkSetArgs(frame, name, arguments, 2)
+		retVal = funl.Value{Kind: funl.BoolValue, Data: arguments[0].Data.(*funl.Set).Contains(frame, arguments[1])}
+		return
+	}
+}
+
+// call(stdpset.<union/intersection/difference> <set> <set>) -> set
+func getStdPsetSetOper(oper func(*funl.Frame, funl.Value, funl.Value) funl.Value) func(name string) stdFuncType {
+	return func(name string) stdFuncType {
+		return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
+			checkSetArgs(frame, name, arguments, 2)
+			if arguments[1].Kind != funl.SetValue {
+				funl.RunTimeError2(frame, "%s: requires set as second argument", name)
+			}
+			retVal = oper(frame, arguments[0], arguments[1])
+			return
+		}
+	}
+}
+
+// call(stdpset.is-subset <set> <subset>) -> bool
+func getStdPsetIsSubset(name string) stdFuncType {
+	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
+		checkSetArgs(frame, name, arguments, 2)
+		if arguments[1].Kind != funl.SetValue {
+			funl.RunTimeError2(frame, "%s: requires set as second argument", name)
+		}
+		retVal = funl.Value{Kind: funl.BoolValue, Data: funl.IsSubset(frame, arguments[0], arguments[1])}
+		return
+	}
+}
+
+// call(stdpset.is-set <value>) -> bool
+func getStdPsetIsSet(name string) stdFuncType {
+	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
+		if l := len(arguments); l != 1 {
+			funl.RunTimeError2(frame, "%s: wrong amount of arguments (%d), need one", name, l)
+		}
+		retVal = funl.Value{Kind: funl.BoolValue, Data: arguments[0].Kind == funl.SetValue}
+		return
+	}
+}
@@ -0,0 +1,49 @@
+package std
+
+import (
+	"github.com/anssihalmeaho/funl/funl"
+)
+
+func initSTDrec(interpreter *funl.Interpreter) (err error) {
+	stdModuleName := "stdrec"
+	topFrame := funl.NewTopFrameWithInterpreter(interpreter)
+	stdFuncs := []stdFuncInfo{
+		{
+			Name:       "is-record",
+			Getter:     getStdRecIsRecord,
+			IsFunction: true,
+		},
+		{
+			Name:       "fields",
+			Getter:     getStdRecFields,
+			IsFunction: true,
+		},
+	}
+	err = setSTDFunctions(topFrame, stdModuleName, stdFuncs, interpreter)
+	return
+}
+
+// call(stdrec.is-record <value>) -> bool
+func getStdRecIsRecord(name string) stdFuncType {
+	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
+		if l := len(arguments); l != 1 {
+			funl.RunTimeError2(frame, "%s: wrong amount of arguments (%d), need one", name, l)
+		}
+		retVal = funl.Value{Kind: funl.BoolValue, Data: arguments[0].Kind == funl.RecordValue}
+		return
+	}
+}
+
+// call(stdrec.fields <record>) -> map
+func getStdRecFields(name string) stdFuncType {
+	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
+		if l := len(arguments); l != 1 {
+			funl.RunTimeError2(frame, "%s: wrong amount of arguments (%d), need one", name, l)
+		}
+		if arguments[0].Kind != funl.RecordValue {
+			funl.RunTimeError2(frame, "%s: requires record value", name)
+		}
+		retVal = arguments[0].Data.(*funl.Record).Fields
+		return
+	}
+}
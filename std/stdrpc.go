@@ -419,9 +419,38 @@ func (proxy *RProxy) Str() string {
 	return fmt.Sprintf("rproxy:%s", proxy.Addr)
 }
 
-// Equals returns equality
+// Equals returns equality (proxy is equal only to itself)
 func (proxy *RProxy) Equals(with funl.OpaqueAPI) bool {
-	return false
+	return with == funl.OpaqueAPI(proxy)
+}
+
+// Hash is based on identity of proxy
+func (proxy *RProxy) Hash() int {
+	return funl.HashOfPointer(proxy)
+}
+
+// Get gives 'addr' field of proxy
+func (proxy *RProxy) Get(frame *funl.Frame, key funl.Value) (funl.Value, bool) {
+	if key.Kind != funl.StringValue || key.Data.(string) != "addr" {
+		return funl.Value{}, false
+	}
+	return funl.Value{Kind: funl.StringValue, Data: proxy.Addr}, true
+}
+
+// Callable makes remote call with proxy:
+// call(<proxy> <rproc-name:string> <arg1> <arg2> ...) -> list(<ok> <err> <value>)
+func (proxy *RProxy) Callable() funl.ExtProcType {
+	impl := func(frame *funl.Frame, arguments []funl.Value) funl.Value {
+		if len(arguments) < 1 {
+			funl.RunTimeError2(frame, "rproxy: remote procedure name required")
+		}
+		if arguments[0].Kind != funl.StringValue {
+			funl.RunTimeError2(frame, "rproxy: assuming string argument")
+		}
+		arsgList := funl.MakeListOfValues(frame, arguments[1:])
+		return proxy.MakeRemoteCall(frame, arguments[0].Data.(string), arsgList)
+	}
+	return funl.ExtProcType{Impl: impl, IsFunction: false}
 }
 
 // NewExtProxy ...
@@ -28,31 +28,12 @@ func getBacktrace(name string) stdFuncType {
 		stack := []funl.Value{}
 
 		// if debug printing disabled in pure functions return just empty list
-		if funl.PrintingDisabledInFunctions {
+		if frame.Settings().PrintingDisabledInFunctions {
 			retVal = funl.MakeListOfValues(frame, stack)
 			return
 		}
 
-		prevFrame := frame
-		for {
-			if prevFrame == nil {
-				break
-			}
-			mapv := funl.HandleMapOP(frame, []*funl.Item{})
-			if prevFrame.FuncProto == nil {
-				mapv = putToMap(frame, mapv, "line", funl.Value{Kind: funl.IntValue, Data: 0})
-				mapv = putToMap(frame, mapv, "file", funl.Value{Kind: funl.StringValue, Data: "-"})
-				mapv = putToMap(frame, mapv, "args", funl.MakeListOfValues(frame, []funl.Value{}))
-			} else {
-				mapv = putToMap(frame, mapv, "line", funl.Value{Kind: funl.IntValue, Data: prevFrame.FuncProto.Lineno})
-				mapv = putToMap(frame, mapv, "file", funl.Value{Kind: funl.StringValue, Data: prevFrame.FuncProto.SrcFileName})
-				mapv = putToMap(frame, mapv, "args", funl.MakeListOfValues(frame, prevFrame.EvaluatedArgs))
-			}
-
-			stack = append(stack, mapv)
-			prevFrame = prevFrame.Previous
-		}
-		retVal = funl.MakeListOfValues(frame, stack)
+		retVal = funl.MakeBacktrace(frame)
 		return
 	}
 }
@@ -70,7 +51,7 @@ func getAddToModCache(name string) stdFuncType {
 		}
 
 		importModName := arguments[0].Data.(string)
-		nspace := &funl.NSpace{OtherNS: make(map[funl.SymID]funl.ImportInfo), Syms: funl.NewSymt()}
+		nspace := &funl.NSpace{OtherNS: make(map[funl.SymID]funl.ImportInfo), Syms: funl.NewSymtWithConverter(frame.SymIDs())}
 
 		// loop symbol to value mappings
 		keyvals := funl.HandleKeyvalsOP(frame, []*funl.Item{&funl.Item{Type: funl.ValueItem, Data: arguments[1]}})
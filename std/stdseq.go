@@ -0,0 +1,576 @@
+package std
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/anssihalmeaho/funl/funl"
+)
+
+// OpaqueSeq is lazy sequence. Elements are produced only when needed
+// and each produced element is memoized so that sequence is persistent
+// (same sequence can be traversed several times).
+// Sequence which reads from impure source (file, channel etc.)
+// can be realized only in procedure.
+type OpaqueSeq struct {
+	step   func(frame *funl.Frame) (funl.Value, *OpaqueSeq, bool)
+	impure bool
+
+	sync.Mutex
+	realized bool
+	head     funl.Value
+	rest     *OpaqueSeq
+	ok       bool
+}
+
+// TypeName ...
+func (seq *OpaqueSeq) TypeName() string {
+	return "seq"
+}
+
+// Str ...
+func (seq *OpaqueSeq) Str() string {
+	return fmt.Sprintf("seq(%p)", seq)
+}
+
+// Equals ...
+func (seq *OpaqueSeq) Equals(with funl.OpaqueAPI) bool {
+	other, ok := with.(*OpaqueSeq)
+	return ok && seq == other
+}
+
+// force returns first element and rest of sequence (ok is false if sequence is empty)
+func (seq *OpaqueSeq) force(frame *funl.Frame) (funl.Value, *OpaqueSeq, bool) {
+	seq.Lock()
+	defer seq.Unlock()
+
+	if !seq.realized {
+		if seq.impure && !frame.IsInProcCall() {
+			funl.RunTimeError2(frame, "impure sequence cannot be read in function")
+		}
+		seq.head, seq.rest, seq.ok = seq.step(frame)
+		seq.realized, seq.step = true, nil
+	}
+	return seq.head, seq.rest, seq.ok
+}
+
+func seqValue(seq *OpaqueSeq) funl.Value {
+	return funl.Value{Kind: funl.OpaqueValue, Data: seq}
+}
+
+func emptySeq() *OpaqueSeq {
+	return &OpaqueSeq{realized: true}
+}
+
+// NewGeneratorSeq returns sequence which gets elements from generator,
+// generator is called in order once per element and it returns false in the end
+func NewGeneratorSeq(gen func(frame *funl.Frame) (funl.Value, bool), impure bool) *OpaqueSeq {
+	return &OpaqueSeq{
+		impure: impure,
+		step: func(frame *funl.Frame) (funl.Value, *OpaqueSeq, bool) {
+			val, ok := gen(frame)
+			if !ok {
+				return val, emptySeq(), false
+			}
+			return val, NewGeneratorSeq(gen, impure), true
+		},
+	}
+}
+
+func seqFromList(listVal funl.Value) *OpaqueSeq {
+	iter := funl.NewListIterator(listVal)
+	return NewGeneratorSeq(func(frame *funl.Frame) (funl.Value, bool) {
+		if next := iter.Next(); next != nil {
+			return *next, true
+		}
+		return funl.Value{}, false
+	}, false)
+}
+
+// toSeq accepts sequence or list as sequence
+func toSeq(frame *funl.Frame, name string, val funl.Value) *OpaqueSeq {
+	switch val.Kind {
+	case funl.ListValue:
+		return seqFromList(val)
+	case funl.OpaqueValue:
+		if seq, ok := val.Data.(*OpaqueSeq); ok {
+			return seq
+		}
+	}
+	funl.RunTimeError2(frame, "%s: requires sequence or list", name)
+	return nil
+}
+
+func isProcValue(val funl.Value) bool {
+	switch val.Kind {
+	case funl.FunctionValue:
+		return val.Data.(funl.FuncValue).FuncProto.IsProc
+	case funl.ExtProcValue:
+		return !val.Data.(funl.ExtProcType).IsFunction
+	}
+	return false
+}
+
+func checkCallable(frame *funl.Frame, name string, val funl.Value) {
+	if val.Kind != funl.FunctionValue && val.Kind != funl.ExtProcValue {
+		funl.RunTimeError2(frame, "%s: requires func or proc", name)
+	}
+}
+
+func callWith(frame *funl.Frame, fn funl.Value, args ...funl.Value) funl.Value {
+	argsForCall := []*funl.Item{{Type: funl.ValueItem, Data: fn}}
+	for _, arg := range args {
+		argsForCall = append(argsForCall, &funl.Item{Type: funl.ValueItem, Data: arg})
+	}
+	return funl.HandleCallOP(frame, argsForCall)
+}
+
+func intArg(frame *funl.Frame, name string, val funl.Value) int {
+	if val.Kind != funl.IntValue {
+		funl.RunTimeError2(frame, "%s: requires int value", name)
+	}
+	return val.Data.(int)
+}
+
+func mapSeq(name string, src *OpaqueSeq, fn funl.Value) *OpaqueSeq {
+	return &OpaqueSeq{
+		impure: src.impure || isProcValue(fn),
+		step: func(frame *funl.Frame) (funl.Value, *OpaqueSeq, bool) {
+			val, rest, ok := src.force(frame)
+			if !ok {
+				return val, rest, false
+			}
+			return callWith(frame, fn, val), mapSeq(name, rest, fn), true
+		},
+	}
+}
+
+func filterSeq(name string, src *OpaqueSeq, fn funl.Value) *OpaqueSeq {
+	return &OpaqueSeq{
+		impure: src.impure || isProcValue(fn),
+		step: func(frame *funl.Frame) (funl.Value, *OpaqueSeq, bool) {
+			for cur := src; ; {
+				val, rest, ok := cur.force(frame)
+				if !ok {
+					return val, rest, false
+				}
+				isIncluded := callWith(frame, fn, val)
+				if isIncluded.Kind != funl.BoolValue {
+					funl.RunTimeError2(frame, "%s: bool value expected from func", name)
+				}
+				if isIncluded.Data.(bool) {
+					return val, filterSeq(name, rest, fn), true
+				}
+				cur = rest
+			}
+		},
+	}
+}
+
+func takeSeq(src *OpaqueSeq, n int) *OpaqueSeq {
+	if n <= 0 {
+		return emptySeq()
+	}
+	return &OpaqueSeq{
+		impure: src.impure,
+		step: func(frame *funl.Frame) (funl.Value, *OpaqueSeq, bool) {
+			val, rest, ok := src.force(frame)
+			if !ok {
+				return val, rest, false
+			}
+			return val, takeSeq(rest, n-1), true
+		},
+	}
+}
+
+func dropSeq(src *OpaqueSeq, n int) *OpaqueSeq {
+	return &OpaqueSeq{
+		impure: src.impure,
+		step: func(frame *funl.Frame) (funl.Value, *OpaqueSeq, bool) {
+			cur := src
+			for i := 0; i < n; i++ {
+				_, rest, ok := cur.force(frame)
+				if !ok {
+					return funl.Value{}, rest, false
+				}
+				cur = rest
+			}
+			return cur.force(frame)
+		},
+	}
+}
+
+func zipSeq(srcs []*OpaqueSeq) *OpaqueSeq {
+	var impure bool
+	for _, src := range srcs {
+		impure = impure || src.impure
+	}
+	return &OpaqueSeq{
+		impure: impure,
+		step: func(frame *funl.Frame) (funl.Value, *OpaqueSeq, bool) {
+			var vals []funl.Value
+			var rests []*OpaqueSeq
+			for _, src := range srcs {
+				val, rest, ok := src.force(frame)
+				if !ok {
+					return funl.Value{}, emptySeq(), false
+				}
+				vals = append(vals, val)
+				rests = append(rests, rest)
+			}
+			return funl.MakeListOfValues(frame, vals), zipSeq(rests), true
+		},
+	}
+}
+
+func chunkSeq(src *OpaqueSeq, n int) *OpaqueSeq {
+	return &OpaqueSeq{
+		impure: src.impure,
+		step: func(frame *funl.Frame) (funl.Value, *OpaqueSeq, bool) {
+			var vals []funl.Value
+			cur := src
+			for len(vals) < n {
+				val, rest, ok := cur.force(frame)
+				cur = rest
+				if !ok {
+					break
+				}
+				vals = append(vals, val)
+			}
+			if len(vals) == 0 {
+				return funl.Value{}, cur, false
+			}
+			return funl.MakeListOfValues(frame, vals), chunkSeq(cur, n), true
+		},
+	}
+}
+
+func iterateSeq(fn funl.Value, val funl.Value) *OpaqueSeq {
+	return &OpaqueSeq{
+		impure:   isProcValue(fn),
+		realized: true,
+		ok:       true,
+		head:     val,
+		rest:     iterateNextSeq(fn, val),
+	}
+}
+
+func iterateNextSeq(fn funl.Value, prev funl.Value) *OpaqueSeq {
+	return &OpaqueSeq{
+		impure: isProcValue(fn),
+		step: func(frame *funl.Frame) (funl.Value, *OpaqueSeq, bool) {
+			val := callWith(frame, fn, prev)
+			return val, iterateNextSeq(fn, val), true
+		},
+	}
+}
+
+func rangeSeq(from, to, step int) *OpaqueSeq {
+	return &OpaqueSeq{
+		step: func(frame *funl.Frame) (funl.Value, *OpaqueSeq, bool) {
+			if (step > 0 && from >= to) || (step < 0 && from <= to) {
+				return funl.Value{}, emptySeq(), false
+			}
+			return funl.Value{Kind: funl.IntValue, Data: from}, rangeSeq(from+step, to, step), true
+		},
+	}
+}
+
+func initSTDSeq(interpreter *funl.Interpreter) (err error) {
+	stdModuleName := "stdseq"
+	topFrame := funl.NewTopFrameWithInterpreter(interpreter)
+	stdFuncs := []stdFuncInfo{
+		{
+			Name:       "from-list",
+			Getter:     getStdSeqFromList,
+			IsFunction: true,
+		},
+		{
+			Name:       "iterate",
+			Getter:     getStdSeqIterate,
+			IsFunction: true,
+		},
+		{
+			Name:       "range",
+			Getter:     getStdSeqRange,
+			IsFunction: true,
+		},
+		{
+			Name:       "from-chan",
+			Getter:     getStdSeqFromChan,
+			IsFunction: true,
+		},
+		{
+			Name:       "map",
+			Getter:     getStdSeqMap,
+			IsFunction: true,
+		},
+		{
+			Name:       "filter",
+			Getter:     getStdSeqFilter,
+			IsFunction: true,
+		},
+		{
+			Name:       "take",
+			Getter:     getStdSeqTake,
+			IsFunction: true,
+		},
+		{
+			Name:       "drop",
+			Getter:     getStdSeqDrop,
+			IsFunction: true,
+		},
+		{
+			Name:       "zip",
+			Getter:     getStdSeqZip,
+			IsFunction: true,
+		},
+		{
+			Name:       "chunk",
+			Getter:     getStdSeqChunk,
+			IsFunction: true,
+		},
+		{
+			Name:       "next",
+			Getter:     getStdSeqNext,
+			IsFunction: true,
+		},
+		{
+			Name:       "reduce",
+			Getter:     getStdSeqReduce,
+			IsFunction: true,
+		},
+		{
+			Name:       "to-list",
+			Getter:     getStdSeqToList,
+			IsFunction: true,
+		},
+		{
+			Name:       "is-seq",
+			Getter:     getStdSeqIsSeq,
+			IsFunction: true,
+		},
+	}
+	err = setSTDFunctions(topFrame, stdModuleName, stdFuncs, interpreter)
+	return
+}
+
+// call(stdseq.from-list <list>) -> seq
+func getStdSeqFromList(name string) stdFuncType {
+	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
+		if l := len(arguments); l != 1 {
+			funl.RunTimeError2(frame, "%s: wrong amount of arguments (%d), need one", name, l)
+		}
+		if arguments[0].Kind != funl.ListValue {
+			funl.RunTimeError2(frame, "%s: requires list value", name)
+		}
+		retVal = seqValue(seqFromList(arguments[0]))
+		return
+	}
+}
+
+// call(stdseq.iterate <func> <initial-value>) -> seq: x, f(x), f(f(x)), ...
+func getStdSeqIterate(name string) stdFuncType {
+	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
+		if l := len(arguments); l != 2 {
+			funl.RunTimeError2(frame, "%s: wrong amount of arguments (%d), need two", name, l)
+		}
+		checkCallable(frame, name, arguments[0])
+		retVal = seqValue(iterateSeq(arguments[0], arguments[1]))
+		return
+	}
+}
+
+// call(stdseq.range <start> <end> [<step>]) -> seq of ints (end not included)
+func getStdSeqRange(name string) stdFuncType {
+	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
+		if l := len(arguments); l != 2 && l != 3 {
+			funl.RunTimeError2(frame, "%s: wrong amount of arguments (%d)", name, l)
+		}
+		step := 1
+		if len(arguments) == 3 {
+			step = intArg(frame, name, arguments[2])
+			if step == 0 {
+				funl.RunTimeError2(frame, "%s: step cannot be zero", name)
+			}
+		}
+		retVal = seqValue(rangeSeq(intArg(frame, name, arguments[0]), intArg(frame, name, arguments[1]), step))
+		return
+	}
+}
+
+// call(stdseq.from-chan <channel> [<end-marker>]) -> seq of received values
+// (sequence ends when end-marker is received), it can be read only in procedure
+func getStdSeqFromChan(name string) stdFuncType {
+	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
+		if l := len(arguments); l != 1 && l != 2 {
+			funl.RunTimeError2(frame, "%s: wrong amount of arguments (%d)", name, l)
+		}
+		if arguments[0].Kind != funl.ChanValue {
+			funl.RunTimeError2(frame, "%s: requires channel", name)
+		}
+		ch := arguments[0].Data.(chan funl.Value)
+		hasMarker := len(arguments) == 2
+		gen := func(frame *funl.Frame) (funl.Value, bool) {
+			val := <-ch
+			if hasMarker {
+				isEnd := funl.HandleEqOP(frame, []*funl.Item{
+					{Type: funl.ValueItem, Data: val},
+					{Type: funl.ValueItem, Data: arguments[1]},
+				})
+				if isEnd.Data.(bool) {
+					return val, false
+				}
+			}
+			return val, true
+		}
+		retVal = seqValue(NewGeneratorSeq(gen, true))
+		return
+	}
+}
+
+// call(stdseq.map <seq> <func>) -> seq
+func getStdSeqMap(name string) stdFuncType {
+	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
+		if l := len(arguments); l != 2 {
+			funl.RunTimeError2(frame, "%s: wrong amount of arguments (%d), need two", name, l)
+		}
+		checkCallable(frame, name, arguments[1])
+		retVal = seqValue(mapSeq(name, toSeq(frame, name, arguments[0]), arguments[1]))
+		return
+	}
+}
+
+// call(stdseq.filter <seq> <func>) -> seq
+func getStdSeqFilter(name string) stdFuncType {
+	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
+		if l := len(arguments); l != 2 {
+			funl.RunTimeError2(frame, "%s: wrong amount of arguments (%d), need two", name, l)
+		}
+		checkCallable(frame, name, arguments[1])
+		retVal = seqValue(filterSeq(name, toSeq(frame, name, arguments[0]), arguments[1]))
+		return
+	}
+}
+
+// call(stdseq.take <seq> <n>) -> seq
+func getStdSeqTake(name string) stdFuncType {
+	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
+		if l := len(arguments); l != 2 {
+			funl.RunTimeError2(frame, "%s: wrong amount of arguments (%d), need two", name, l)
+		}
+		retVal = seqValue(takeSeq(toSeq(frame, name, arguments[0]), intArg(frame, name, arguments[1])))
+		return
+	}
+}
+
+// call(stdseq.drop <seq> <n>) -> seq
+func getStdSeqDrop(name string) stdFuncType {
+	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
+		if l := len(arguments); l != 2 {
+			funl.RunTimeError2(frame, "%s: wrong amount of arguments (%d), need two", name, l)
+		}
+		retVal = seqValue(dropSeq(toSeq(frame, name, arguments[0]), intArg(frame, name, arguments[1])))
+		return
+	}
+}
+
+// call(stdseq.zip <seq> <seq> ...) -> seq of lists (ends when shortest ends)
+func getStdSeqZip(name string) stdFuncType {
+	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
+		if len(arguments) == 0 {
+			funl.RunTimeError2(frame, "%s: requires at least one sequence", name)
+		}
+		var srcs []*OpaqueSeq
+		for _, arg := range arguments {
+			srcs = append(srcs, toSeq(frame, name, arg))
+		}
+		retVal = seqValue(zipSeq(srcs))
+		return
+	}
+}
+
+// call(stdseq.chunk <seq> <n>) -> seq of lists (last one may be shorter)
+func getStdSeqChunk(name string) stdFuncType {
+	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
+		if l := len(arguments); l != 2 {
+			funl.RunTimeError2(frame, "%s: wrong amount of arguments (%d), need two", name, l)
+		}
+		n := intArg(frame, name, arguments[1])
+		if n <= 0 {
+			funl.RunTimeError2(frame, "%s: chunk size should be positive", name)
+		}
+		retVal = seqValue(chunkSeq(toSeq(frame, name, arguments[0]), n))
+		return
+	}
+}
+
+// call(stdseq.next <seq>) -> list(<found:bool> <value> <rest-seq>)
+func getStdSeqNext(name string) stdFuncType {
+	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
+		if l := len(arguments); l != 1 {
+			funl.RunTimeError2(frame, "%s: wrong amount of arguments (%d), need one", name, l)
+		}
+		val, rest, ok := toSeq(frame, name, arguments[0]).force(frame)
+		if !ok {
+			val = funl.Value{Kind: funl.StringValue, Data: ""}
+		}
+		retVal = funl.MakeListOfValues(frame, []funl.Value{{Kind: funl.BoolValue, Data: ok}, val, seqValue(rest)})
+		return
+	}
+}
+
+// call(stdseq.reduce <seq> <func> <initial-value>) -> value
+// func is called with accumulated value and element
+func getStdSeqReduce(name string) stdFuncType {
+	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
+		if l := len(arguments); l != 3 {
+			funl.RunTimeError2(frame, "%s: wrong amount of arguments (%d), need three", name, l)
+		}
+		checkCallable(frame, name, arguments[1])
+		retVal = arguments[2]
+		for cur := toSeq(frame, name, arguments[0]); ; {
+			val, rest, ok := cur.force(frame)
+			if !ok {
+				return
+			}
+			retVal = callWith(frame, arguments[1], retVal, val)
+			cur = rest
+		}
+	}
+}
+
+// call(stdseq.to-list <seq>) -> list
+func getStdSeqToList(name string) stdFuncType {
+	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
+		if l := len(arguments); l != 1 {
+			funl.RunTimeError2(frame, "%s: wrong amount of arguments (%d), need one", name, l)
+		}
+		var vals []funl.Value
+		for cur := toSeq(frame, name, arguments[0]); ; {
+			val, rest, ok := cur.force(frame)
+			if !ok {
+				break
+			}
+			vals = append(vals, val)
+			cur = rest
+		}
+		retVal = funl.MakeListOfValues(frame, vals)
+		return
+	}
+}
+
+// call(stdseq.is-seq <value>) -> bool
+func getStdSeqIsSeq(name string) stdFuncType {
+	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
+		if l := len(arguments); l != 1 {
+			funl.RunTimeError2(frame, "%s: wrong amount of arguments (%d), need one", name, l)
+		}
+		var isSeq bool
+		if arguments[0].Kind == funl.OpaqueValue {
+			_, isSeq = arguments[0].Data.(*OpaqueSeq)
+		}
+		retVal = funl.Value{Kind: funl.BoolValue, Data: isSeq}
+		return
+	}
+}
@@ -94,6 +94,10 @@ func (ot *opaqueTimer) Equals(with funl.OpaqueAPI) bool {
 	return *ot == *timerVal
 }
 
+func (ot *opaqueTimer) Hash() int {
+	return funl.HashOfPointer(ot.t)
+}
+
 func getStdStopTimer(name string) stdFuncType {
 	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
 		if l := len(arguments); l != 1 {
@@ -187,6 +191,10 @@ func (ot *opaqueTicker) Equals(with funl.OpaqueAPI) bool {
 	return *ot == *tickerVal
 }
 
+func (ot *opaqueTicker) Hash() int {
+	return funl.HashOfPointer(ot.t)
+}
+
 func getStdStopTicker(name string) stdFuncType {
 	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
 		if l := len(arguments); l != 1 {
@@ -0,0 +1,298 @@
+package std
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/anssihalmeaho/funl/funl"
+)
+
+// Transients are mutable map/list builders which are usable only inside
+// call of build-map/build-list, after that they are frozen and the
+// result is ordinary (immutable) map or list. So mutation is not visible outside.
+// Transient is owned by frame which called build-map/build-list and it can be
+// used only in calls made from that (so not from other fibers for example).
+
+// OpaqueTransMap is transient map
+type OpaqueTransMap struct {
+	sync.Mutex
+	mb     *funl.MapBuilder
+	owner  *funl.Frame
+	active bool
+}
+
+// TypeName ...
+func (tm *OpaqueTransMap) TypeName() string {
+	return "transient-map"
+}
+
+// Str ...
+func (tm *OpaqueTransMap) Str() string {
+	return fmt.Sprintf("transient-map(%p)", tm)
+}
+
+// Equals ...
+func (tm *OpaqueTransMap) Equals(with funl.OpaqueAPI) bool {
+	other, ok := with.(*OpaqueTransMap)
+	return ok && tm == other
+}
+
+// OpaqueTransList is transient list
+type OpaqueTransList struct {
+	sync.Mutex
+	lb     *funl.ListBuilder
+	owner  *funl.Frame
+	active bool
+}
+
+// TypeName ...
+func (tl *OpaqueTransList) TypeName() string {
+	return "transient-list"
+}
+
+// Str ...
+func (tl *OpaqueTransList) Str() string {
+	return fmt.Sprintf("transient-list(%p)", tl)
+}
+
+// Equals ...
+func (tl *OpaqueTransList) Equals(with funl.OpaqueAPI) bool {
+	other, ok := with.(*OpaqueTransList)
+	return ok && tl == other
+}
+
+func initSTDTrans(interpreter *funl.Interpreter) (err error) {
+	stdModuleName := "stdtrans"
+	topFrame := funl.NewTopFrameWithInterpreter(interpreter)
+	stdFuncs := []stdFuncInfo{
+		{
+			Name:       "build-map",
+			Getter:     getStdTransBuildMap,
+			IsFunction: true,
+		},
+		{
+			Name:       "build-list",
+			Getter:     getStdTransBuildList,
+			IsFunction: true,
+		},
+		{
+			Name:       "put",
+			Getter:     getStdTransPut(false),
+			IsFunction: true,
+		},
+		{
+			Name:       "set",
+			Getter:     getStdTransPut(true),
+			IsFunction: true,
+		},
+		{
+			Name:       "del",
+			Getter:     getStdTransDel,
+			IsFunction: true,
+		},
+		{
+			Name:       "getl",
+			Getter:     getStdTransGetl,
+			IsFunction: true,
+		},
+		{
+			Name:       "append",
+			Getter:     getStdTransAppend,
+			IsFunction: true,
+		},
+		{
+			Name:       "len",
+			Getter:     getStdTransLen,
+			IsFunction: true,
+		},
+	}
+	err = setSTDFunctions(topFrame, stdModuleName, stdFuncs, interpreter)
+	return
+}
+
+// isCalledFrom returns true if frame is in call made from owner frame
+func isCalledFrom(frame, owner *funl.Frame) bool {
+	for caller := frame; caller != nil; caller = caller.Previous {
+		if caller == owner {
+			return true
+		}
+	}
+	return false
+}
+
+// withTransMap locks transient map and checks that it's still in use
+// (and used in call made from build-map)
+func withTransMap(frame *funl.Frame, name string, val funl.Value, handler func(*funl.MapBuilder)) {
+	if val.Kind != funl.OpaqueValue {
+		funl.RunTimeError2(frame, "%s: requires transient map", name)
+	}
+	tm, ok := val.Data.(*OpaqueTransMap)
+	if !ok {
+		funl.RunTimeError2(frame, "%s: requires transient map", name)
+	}
+	tm.Lock()
+	defer tm.Unlock()
+	if !tm.active || !isCalledFrom(frame, tm.owner) {
+		funl.RunTimeError2(frame, "%s: transient used outside of build-map", name)
+	}
+	handler(tm.mb)
+}
+
+// withTransList locks transient list and checks that it's still in use
+// (and used in call made from build-list)
+func withTransList(frame *funl.Frame, name string, val funl.Value, handler func(*funl.ListBuilder)) {
+	tl, ok := val.Data.(*OpaqueTransList)
+	if val.Kind != funl.OpaqueValue || !ok {
+		funl.RunTimeError2(frame, "%s: requires transient list", name)
+	}
+	tl.Lock()
+	defer tl.Unlock()
+	if !tl.active || !isCalledFrom(frame, tl.owner) {
+		funl.RunTimeError2(frame, "%s: transient used outside of build-list", name)
+	}
+	handler(tl.lb)
+}
+
+// call(stdtrans.build-map <builder-func> [<initial-map>]) -> map
+func getStdTransBuildMap(name string) stdFuncType {
+	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
+		if l := len(arguments); l != 1 && l != 2 {
+			funl.RunTimeError2(frame, "%s: wrong amount of arguments (%d), need one or two", name, l)
+		}
+		checkCallable(frame, name, arguments[0])
+		mb := funl.NewMapBuilder(frame)
+		if len(arguments) == 2 {
+			mb = funl.NewMapBuilderFrom(frame, arguments[1])
+		}
+		tm := &OpaqueTransMap{mb: mb, owner: frame, active: true}
+		defer func() {
+			tm.Lock()
+			tm.active = false
+			tm.Unlock()
+		}()
+
+		tmVal := funl.Value{Kind: funl.OpaqueValue, Data: tm}
+		result := callWith(frame, arguments[0], tmVal)
+		if result.Kind != funl.OpaqueValue || result.Data != tm {
+			funl.RunTimeError2(frame, "%s: builder should return the transient map", name)
+		}
+		tm.Lock()
+		defer tm.Unlock()
+		retVal = mb.Freeze(frame)
+		return
+	}
+}
+
+// call(stdtrans.build-list <builder-func>) -> list
+func getStdTransBuildList(name string) stdFuncType {
+	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
+		if l := len(arguments); l != 1 {
+			funl.RunTimeError2(frame, "%s: wrong amount of arguments (%d), need one", name, l)
+		}
+		checkCallable(frame, name, arguments[0])
+		tl := &OpaqueTransList{lb: funl.NewListBuilder(), owner: frame, active: true}
+		defer func() {
+			tl.Lock()
+			tl.active = false
+			tl.Unlock()
+		}()
+
+		tlVal := funl.Value{Kind: funl.OpaqueValue, Data: tl}
+		result := callWith(frame, arguments[0], tlVal)
+		if result.Kind != funl.OpaqueValue || result.Data != tl {
+			funl.RunTimeError2(frame, "%s: builder should return the transient list", name)
+		}
+		tl.Lock()
+		defer tl.Unlock()
+		retVal = tl.lb.Freeze(frame)
+		return
+	}
+}
+
+// call(stdtrans.put <transient-map> <key> <value>) -> transient-map
+// (set replaces value if key exists, put gives error)
+func getStdTransPut(replace bool) func(name string) stdFuncType {
+	return func(name string) stdFuncType {
+		return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
+			if l := len(arguments); l != 3 {
+				funl.RunTimeError2(frame, "%s: wrong amount of arguments (%d), need three", name, l)
+			}
+			withTransMap(frame, name, arguments[0], func(mb *funl.MapBuilder) {
+				if replace {
+					mb.Set(frame, arguments[1], arguments[2])
+					return
+				}
+				if _, found := mb.Get(frame, arguments[1]); found {
+					funl.RunTimeError2(frame, "%s: key already exists (%v)", name, arguments[1])
+				}
+				mb.Put(frame, arguments[1], arguments[2])
+			})
+			retVal = arguments[0]
+			return
+		}
+	}
+}
+
+// call(stdtrans.del <transient-map> <key>) -> transient-map
+func getStdTransDel(name string) stdFuncType {
+	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
+		if l := len(arguments); l != 2 {
+			funl.RunTimeError2(frame, "%s: wrong amount of arguments (%d), need two", name, l)
+		}
+		withTransMap(frame, name, arguments[0], func(mb *funl.MapBuilder) {
+			mb.Del(frame, arguments[1])
+		})
+		retVal = arguments[0]
+		return
+	}
+}
+
+// call(stdtrans.getl <transient-map> <key>) -> list(<found> <value>)
+func getStdTransGetl(name string) stdFuncType {
+	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
+		if l := len(arguments); l != 2 {
+			funl.RunTimeError2(frame, "%s: wrong amount of arguments (%d), need two", name, l)
+		}
+		withTransMap(frame, name, arguments[0], func(mb *funl.MapBuilder) {
+			val, found := mb.Get(frame, arguments[1])
+			if !found {
+				val = funl.Value{Kind: funl.BoolValue, Data: false}
+			}
+			retVal = funl.MakeListOfValues(frame, []funl.Value{{Kind: funl.BoolValue, Data: found}, val})
+		})
+		return
+	}
+}
+
+// call(stdtrans.append <transient-list> <item> ...) -> transient-list
+func getStdTransAppend(name string) stdFuncType {
+	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
+		if l := len(arguments); l < 1 {
+			funl.RunTimeError2(frame, "%s: wrong amount of arguments (%d), need at least one", name, l)
+		}
+		withTransList(frame, name, arguments[0], func(lb *funl.ListBuilder) {
+			lb.Append(frame, arguments[1:]...)
+		})
+		retVal = arguments[0]
+		return
+	}
+}
+
+// call(stdtrans.len <transient-map/transient-list>) -> int
+func getStdTransLen(name string) stdFuncType {
+	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
+		if l := len(arguments); l != 1 {
+			funl.RunTimeError2(frame, "%s: wrong amount of arguments (%d), need one", name, l)
+		}
+		if _, ok := arguments[0].Data.(*OpaqueTransList); ok && arguments[0].Kind == funl.OpaqueValue {
+			withTransList(frame, name, arguments[0], func(lb *funl.ListBuilder) {
+				retVal = funl.Value{Kind: funl.IntValue, Data: lb.Len()}
+			})
+			return
+		}
+		withTransMap(frame, name, arguments[0], func(mb *funl.MapBuilder) {
+			retVal = funl.Value{Kind: funl.IntValue, Data: mb.Len()}
+		})
+		return
+	}
+}
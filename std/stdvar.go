@@ -69,6 +69,11 @@ func (ref *OpaqueVarRef) Equals(with funl.OpaqueAPI) bool {
 	return isSame
 }
 
+// Hash is based on identity as each var-ref has its own value pointer
+func (ref *OpaqueVarRef) Hash() int {
+	return funl.HashOfPointer(ref)
+}
+
 func getStdVarChangeV2(name string) stdFuncType {
 	return func(frame *funl.Frame, arguments []funl.Value) (retVal funl.Value) {
 		if l := len(arguments); l < 2 {